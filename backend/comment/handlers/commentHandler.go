@@ -0,0 +1,10 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type CommentHandler interface {
+	GetComments(c echo.Context) error
+	CreateComment(c echo.Context) error
+	UpdateComment(c echo.Context) error
+	DeleteComment(c echo.Context) error
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/comment/repository"
+	"DiplomaV2/backend/comment/usecase"
+	"DiplomaV2/backend/internal/dto"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/validator"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type commentHttpHandler struct {
+	commentUseCase usecase.CommentUseCase
+}
+
+// GetComments lists a page of top-level comments of a post with their
+// replies nested.
+func (h *commentHttpHandler) GetComments(c echo.Context) error {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+	}
+
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 20, v),
+		Sort:         helpers.ReadString(qs, "sort", "created_at"),
+		SortSafeList: []string{"created_at", "-created_at"},
+	}
+
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	viewerID, _ := c.Get("userID").(int64)
+
+	comments, metadata, err := h.commentUseCase.GetComments(postID, viewerID, filters)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	type Response struct {
+		Comments []dto.Comment        `json:"comments"`
+		Metadata postsFilter.Metadata `json:"metadata"`
+	}
+
+	return c.JSON(http.StatusOK, Response{Comments: dto.NewComments(comments), Metadata: metadata})
+}
+
+func (h *commentHttpHandler) CreateComment(c echo.Context) error {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+	}
+
+	var input struct {
+		Body     string `json:"body"`
+		ParentID *int64 `json:"parentId"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	userID := c.Get("userID").(int64)
+	comment := &entity.Comment{
+		PostID:   postID,
+		AuthorID: &userID,
+		ParentID: input.ParentID,
+		Body:     strings.TrimSpace(input.Body),
+	}
+
+	v := validator.New()
+	if validator.ValidateComment(v, comment); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	created, err := h.commentUseCase.CreateComment(comment)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, dto.NewComment(created))
+}
+
+func (h *commentHttpHandler) UpdateComment(c echo.Context) error {
+	commentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid comment id"})
+	}
+
+	var input struct {
+		Body string `json:"body"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	body := strings.TrimSpace(input.Body)
+
+	v := validator.New()
+	if validator.ValidateComment(v, &entity.Comment{Body: body}); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	comment, err := h.commentUseCase.UpdateComment(commentID, c.Get("userID").(int64), isModerator(c), body)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, dto.NewComment(comment))
+}
+
+func (h *commentHttpHandler) DeleteComment(c echo.Context) error {
+	commentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid comment id"})
+	}
+
+	if err := h.commentUseCase.DeleteComment(commentID, c.Get("userID").(int64), isModerator(c)); err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *commentHttpHandler) errorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, postRepository.ErrPostNotFound), errors.Is(err, repository.ErrCommentNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNotCommentAuthor):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCommentDeleted):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// isModerator reports whether the signed in user can edit and delete any
+// comment.
+func isModerator(c echo.Context) bool {
+	role, _ := c.Get("userRole").(string)
+	return role == entity.RoleAdmin
+}
+
+func NewCommentHttpHandler(commentUseCase usecase.CommentUseCase) CommentHandler {
+	return &commentHttpHandler{
+		commentUseCase: commentUseCase,
+	}
+}
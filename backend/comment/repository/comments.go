@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+)
+
+type CommentRepository interface {
+	Insert(comment *entity.Comment) error
+	GetByID(id int64) (*entity.Comment, error)
+	Update(comment *entity.Comment) error
+	GetThreads(postID int64, filters postsFilter.Filters) ([]*entity.Comment, postsFilter.Metadata, error)
+	GetReplies(threadIDs []int64) ([]*entity.Comment, error)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"math"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+type commentRepository struct {
+	DB database.Database
+}
+
+func NewCommentRepository(db database.Database) CommentRepository {
+	return &commentRepository{DB: db}
+}
+
+func (r *commentRepository) Insert(comment *entity.Comment) error {
+	return r.DB.GetDb().Omit("Post", "Author").Create(comment).Error
+}
+
+func (r *commentRepository) GetByID(id int64) (*entity.Comment, error) {
+	var comment entity.Comment
+	if err := r.DB.GetDb().Preload("Author", compactAuthor).First(&comment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) Update(comment *entity.Comment) error {
+	return r.DB.GetDb().Omit("Post", "Author").Save(comment).Error
+}
+
+// GetThreads lists the top-level comments of a post, deleted ones included.
+func (r *commentRepository) GetThreads(postID int64, filters postsFilter.Filters) ([]*entity.Comment, postsFilter.Metadata, error) {
+	var comments []*entity.Comment
+	query := r.DB.GetDb().Model(&entity.Comment{}).
+		Where("post_id = ? AND parent_id IS NULL", postID)
+
+	var totalRecords int64
+	countQuery := *query
+	if err := countQuery.Count(&totalRecords).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s, id", filters.SortColumn(), filters.SortDirection())).
+		Offset((filters.Page - 1) * filters.PageSize).
+		Limit(filters.PageSize)
+
+	if err := query.Preload("Author", compactAuthor).Find(&comments).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	metadata := calculateMetadata(int(totalRecords), filters.Page, filters.PageSize)
+	return comments, metadata, nil
+}
+
+// GetReplies returns every reply in the given threads, oldest first.
+func (r *commentRepository) GetReplies(threadIDs []int64) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	if len(threadIDs) == 0 {
+		return comments, nil
+	}
+
+	err := r.DB.GetDb().Preload("Author", compactAuthor).
+		Where("thread_id IN ?", threadIDs).
+		Order("created_at, id").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// compactAuthor only reads the columns of the public author summary.
+func compactAuthor(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "username", "name", "profile_image")
+}
+
+func calculateMetadata(totalRecords, page, pageSize int) postsFilter.Metadata {
+	if totalRecords == 0 {
+		return postsFilter.Metadata{}
+	}
+	return postsFilter.Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
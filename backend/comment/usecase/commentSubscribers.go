@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	notificationUseCase "DiplomaV2/backend/notification/usecase"
+	"fmt"
+)
+
+// SubscribeNotifications tells post authors about new comments on their
+// posts. It must be called once per bus.
+func SubscribeNotifications(bus *events.Bus, notifier notificationUseCase.NotificationUseCase) {
+	events.SubscribeAsync(bus, func(event events.CommentCreated) error {
+		if event.Comment.WrittenBy(event.Post.AuthorID) {
+			return nil
+		}
+		return notifier.Notify(&entity.Notification{
+			UserID: event.Post.AuthorID,
+			Type:   entity.NotificationPostComment,
+			Title:  fmt.Sprintf("New comment on \"%s\"", event.Post.Name),
+			Body:   event.Comment.Body,
+			Link:   fmt.Sprintf("/manage-post/%d", event.Post.ID),
+		})
+	})
+}
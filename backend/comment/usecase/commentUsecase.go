@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+)
+
+type CommentUseCase interface {
+	CreateComment(comment *entity.Comment) (*entity.Comment, error)
+	GetComments(postID, viewerID int64, filters postsFilter.Filters) ([]*entity.Comment, postsFilter.Metadata, error)
+	UpdateComment(commentID, userID int64, isModerator bool, body string) (*entity.Comment, error)
+	DeleteComment(commentID, userID int64, isModerator bool) error
+}
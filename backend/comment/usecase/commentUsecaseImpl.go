@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/comment/repository"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrNotCommentAuthor = errors.New("comment doesn't belong to this user")
+	ErrCommentDeleted   = errors.New("comment has been deleted")
+)
+
+type commentUseCaseImpl struct {
+	repo     repository.CommentRepository
+	postRepo postRepository.PostRepository
+	bus      *events.Bus
+}
+
+func NewCommentUseCase(repo repository.CommentRepository, postRepo postRepository.PostRepository, bus *events.Bus) CommentUseCase {
+	return &commentUseCaseImpl{
+		repo:     repo,
+		postRepo: postRepo,
+		bus:      bus,
+	}
+}
+
+// CreateComment adds a comment, or a reply when ParentID is set. Replies to
+// a deleted comment aren't allowed.
+func (c *commentUseCaseImpl) CreateComment(comment *entity.Comment) (*entity.Comment, error) {
+	post, err := c.visiblePost(comment.PostID, *comment.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.ParentID != nil {
+		parent, err := c.repo.GetByID(*comment.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != post.ID {
+			return nil, repository.ErrCommentNotFound
+		}
+		if parent.IsDeleted() {
+			return nil, ErrCommentDeleted
+		}
+
+		threadID := parent.ID
+		if parent.ThreadID != nil {
+			threadID = *parent.ThreadID
+		}
+		comment.ThreadID = &threadID
+	}
+
+	if err := c.repo.Insert(comment); err != nil {
+		return nil, err
+	}
+
+	created, err := c.repo.GetByID(comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	c.bus.Publish(events.CommentCreated{Comment: *created, Post: *post})
+	return created, nil
+}
+
+// GetComments returns a page of threads with all their replies nested
+// under their parents.
+func (c *commentUseCaseImpl) GetComments(postID, viewerID int64, filters postsFilter.Filters) ([]*entity.Comment, postsFilter.Metadata, error) {
+	if _, err := c.visiblePost(postID, viewerID); err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	threads, metadata, err := c.repo.GetThreads(postID, filters)
+	if err != nil {
+		return nil, metadata, err
+	}
+
+	byID := make(map[int64]*entity.Comment, len(threads))
+	threadIDs := make([]int64, 0, len(threads))
+	for _, thread := range threads {
+		byID[thread.ID] = thread
+		threadIDs = append(threadIDs, thread.ID)
+	}
+
+	replies, err := c.repo.GetReplies(threadIDs)
+	if err != nil {
+		return nil, metadata, err
+	}
+	// Replies come oldest first, so a parent is always seen before its
+	// replies. A reply whose thread isn't on the page is skipped.
+	for _, reply := range replies {
+		parent, ok := byID[*reply.ParentID]
+		if !ok {
+			if parent, ok = byID[*reply.ThreadID]; !ok {
+				continue
+			}
+		}
+		byID[reply.ID] = reply
+		parent.Replies = append(parent.Replies, reply)
+	}
+
+	return threads, metadata, nil
+}
+
+func (c *commentUseCaseImpl) UpdateComment(commentID, userID int64, isModerator bool, body string) (*entity.Comment, error) {
+	comment, err := c.getEditable(commentID, userID, isModerator)
+	if err != nil {
+		return nil, err
+	}
+	if comment.IsDeleted() {
+		return nil, ErrCommentDeleted
+	}
+
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+	if err := c.repo.Update(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment soft deletes a comment so its replies stay in the thread.
+// Deleting it again does nothing.
+func (c *commentUseCaseImpl) DeleteComment(commentID, userID int64, isModerator bool) error {
+	comment, err := c.getEditable(commentID, userID, isModerator)
+	if err != nil {
+		return err
+	}
+	if comment.IsDeleted() {
+		return nil
+	}
+
+	now := time.Now()
+	comment.DeletedAt = &now
+	return c.repo.Update(comment)
+}
+
+// getEditable returns the comment when userID wrote it or is a moderator.
+func (c *commentUseCaseImpl) getEditable(commentID, userID int64, isModerator bool) (*entity.Comment, error) {
+	comment, err := c.repo.GetByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if !comment.WrittenBy(userID) && !isModerator {
+		return nil, ErrNotCommentAuthor
+	}
+	return comment, nil
+}
+
+// visiblePost returns the post when viewerID can see it. Hidden posts and
+// other users' drafts are treated as missing.
+func (c *commentUseCaseImpl) visiblePost(postID, viewerID int64) (*entity.Post, error) {
+	post, err := c.postRepo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if !post.VisibleTo(viewerID) {
+		return nil, postRepository.ErrPostNotFound
+	}
+	return post, nil
+}
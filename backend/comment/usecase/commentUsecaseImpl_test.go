@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/comment/repository"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+// fakeCommentRepo keeps comments in memory. GetThreads and GetReplies
+// return the comments set on threads and replies.
+type fakeCommentRepo struct {
+	repository.CommentRepository
+	comments map[int64]*entity.Comment
+	threads  []*entity.Comment
+	replies  []*entity.Comment
+}
+
+func newFakeCommentRepo() *fakeCommentRepo {
+	return &fakeCommentRepo{comments: make(map[int64]*entity.Comment)}
+}
+
+func (r *fakeCommentRepo) Insert(comment *entity.Comment) error {
+	comment.ID = int64(len(r.comments) + 1)
+	stored := *comment
+	r.comments[comment.ID] = &stored
+	return nil
+}
+
+func (r *fakeCommentRepo) GetByID(id int64) (*entity.Comment, error) {
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, repository.ErrCommentNotFound
+	}
+	found := *comment
+	return &found, nil
+}
+
+func (r *fakeCommentRepo) Update(comment *entity.Comment) error {
+	stored := *comment
+	r.comments[comment.ID] = &stored
+	return nil
+}
+
+func (r *fakeCommentRepo) GetThreads(int64, postsFilter.Filters) ([]*entity.Comment, postsFilter.Metadata, error) {
+	return r.threads, postsFilter.Metadata{}, nil
+}
+
+func (r *fakeCommentRepo) GetReplies([]int64) ([]*entity.Comment, error) {
+	return r.replies, nil
+}
+
+type fakePostRepo struct {
+	postRepository.PostRepository
+	post *entity.Post
+}
+
+func (r fakePostRepo) GetByID(id int64) (*entity.Post, error) {
+	if id != r.post.ID {
+		return nil, postRepository.ErrPostNotFound
+	}
+	post := *r.post
+	return &post, nil
+}
+
+func newTestUseCase() (CommentUseCase, *fakeCommentRepo, *events.Bus) {
+	post := &entity.Post{ID: 1, AuthorID: 1, Author: entity.User{ID: 1}, Status: entity.PostStatusOpen}
+	repo := newFakeCommentRepo()
+	bus := events.New(echo.New().Logger)
+	return NewCommentUseCase(repo, fakePostRepo{post: post}, bus), repo, bus
+}
+
+func newComment(authorID int64, parentID *int64, body string) *entity.Comment {
+	return &entity.Comment{PostID: 1, AuthorID: &authorID, ParentID: parentID, Body: body}
+}
+
+func TestCreateCommentKeepsRepliesInTheirThread(t *testing.T) {
+	uc, _, bus := newTestUseCase()
+	var created []events.CommentCreated
+	events.Subscribe(bus, func(event events.CommentCreated) error {
+		created = append(created, event)
+		return nil
+	})
+
+	root, err := uc.CreateComment(newComment(2, nil, "Is this remote?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.ThreadID != nil {
+		t.Fatalf("top-level comment has thread %d", *root.ThreadID)
+	}
+	reply, err := uc.CreateComment(newComment(1, &root.ID, "Yes."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, err := uc.CreateComment(newComment(2, &reply.ID, "Thanks!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, comment := range []*entity.Comment{reply, nested} {
+		if comment.ThreadID == nil || *comment.ThreadID != root.ID {
+			t.Fatalf("comment %d thread = %v, want %d", comment.ID, comment.ThreadID, root.ID)
+		}
+	}
+	if len(created) != 3 || created[2].Comment.ID != nested.ID || created[2].Post.ID != 1 {
+		t.Fatalf("published %+v, want an event per comment", created)
+	}
+}
+
+func TestCreateCommentRejectsReplyToDeletedComment(t *testing.T) {
+	uc, repo, _ := newTestUseCase()
+	root, err := uc.CreateComment(newComment(2, nil, "Is this remote?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.DeleteComment(root.ID, 2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uc.CreateComment(newComment(1, &root.ID, "Yes.")); !errors.Is(err, ErrCommentDeleted) {
+		t.Fatalf("err = %v, want ErrCommentDeleted", err)
+	}
+	if len(repo.comments) != 1 {
+		t.Fatalf("%d comments stored, want 1", len(repo.comments))
+	}
+}
+
+func TestDeleteCommentIsSoft(t *testing.T) {
+	uc, repo, _ := newTestUseCase()
+	root, err := uc.CreateComment(newComment(2, nil, "Is this remote?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uc.DeleteComment(root.ID, 3, false); !errors.Is(err, ErrNotCommentAuthor) {
+		t.Fatalf("other user: err = %v, want ErrNotCommentAuthor", err)
+	}
+	if err := uc.DeleteComment(root.ID, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	stored, ok := repo.comments[root.ID]
+	if !ok || !stored.IsDeleted() {
+		t.Fatalf("comment = %+v, want it kept and marked deleted", stored)
+	}
+	deletedAt := *stored.DeletedAt
+
+	if err := uc.DeleteComment(root.ID, 2, false); err != nil {
+		t.Fatalf("deleting again: err = %v", err)
+	}
+	if !repo.comments[root.ID].DeletedAt.Equal(deletedAt) {
+		t.Fatal("deleting again moved the deletion time")
+	}
+	if _, err := uc.UpdateComment(root.ID, 2, false, "Edited"); !errors.Is(err, ErrCommentDeleted) {
+		t.Fatalf("editing a deleted comment: err = %v, want ErrCommentDeleted", err)
+	}
+}
+
+func TestModeratorCanEditComment(t *testing.T) {
+	uc, repo, _ := newTestUseCase()
+	root, err := uc.CreateComment(newComment(2, nil, "Is this remote?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uc.UpdateComment(root.ID, 3, false, "Spam"); !errors.Is(err, ErrNotCommentAuthor) {
+		t.Fatalf("other user: err = %v, want ErrNotCommentAuthor", err)
+	}
+	updated, err := uc.UpdateComment(root.ID, 3, true, "[removed link]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Body != "[removed link]" || updated.EditedAt == nil {
+		t.Fatalf("comment = %+v, want the new body and an edit time", updated)
+	}
+	if !repo.comments[root.ID].WrittenBy(2) {
+		t.Fatal("the moderator's edit changed the author")
+	}
+}
+
+func TestGetCommentsSkipsRepliesOutsideThePage(t *testing.T) {
+	uc, repo, _ := newTestUseCase()
+	id := func(id int64) *int64 { return &id }
+	repo.threads = []*entity.Comment{{ID: 1, PostID: 1}}
+	repo.replies = []*entity.Comment{
+		{ID: 2, PostID: 1, ParentID: id(1), ThreadID: id(1)},
+		{ID: 3, PostID: 1, ParentID: id(9), ThreadID: id(9)},
+		{ID: 4, PostID: 1, ParentID: id(3), ThreadID: id(9)},
+		{ID: 5, PostID: 1, ParentID: id(2), ThreadID: id(1)},
+	}
+
+	threads, _, err := uc.GetComments(1, 0, postsFilter.Filters{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 1 || len(threads[0].Replies) != 1 {
+		t.Fatalf("threads = %+v, want one thread with one reply", threads)
+	}
+	reply := threads[0].Replies[0]
+	if reply.ID != 2 || len(reply.Replies) != 1 || reply.Replies[0].ID != 5 {
+		t.Fatalf("reply = %+v, want comment 2 with reply 5", reply)
+	}
+}
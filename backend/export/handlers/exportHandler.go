@@ -0,0 +1,7 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type ExportHandler interface {
+	ExportMyData(c echo.Context) error
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/export/usecase"
+	"bytes"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+type exportHttpHandler struct {
+	exportUseCase usecase.ExportUseCase
+}
+
+// ExportMyData returns everything stored about the current user as a
+// downloadable JSON document (default) or ZIP archive (?format=zip).
+func (e *exportHttpHandler) ExportMyData(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var (
+		buf         bytes.Buffer
+		err         error
+		contentType string
+	)
+	switch format {
+	case "json":
+		err = e.exportUseCase.ExportJSON(userID, &buf)
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+	case "zip":
+		err = e.exportUseCase.ExportZIP(userID, &buf)
+		contentType = "application/zip"
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"format": "must be json or zip"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to export user data"})
+	}
+
+	filename := fmt.Sprintf("teamfinder-export-%d-%s.%s", userID, time.Now().UTC().Format("20060102"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+func NewExportHttpHandler(exportUseCase usecase.ExportUseCase) ExportHandler {
+	return &exportHttpHandler{
+		exportUseCase: exportUseCase,
+	}
+}
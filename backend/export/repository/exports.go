@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+)
+
+// ExportRepository reads the rows the data export needs that the other
+// repositories only expose per post or page by page.
+type ExportRepository interface {
+	GetSavedPosts(userID int64) ([]*entity.SavedPost, error)
+	GetSavedSearches(userID int64) ([]*entity.SavedSearch, error)
+	GetNotifications(userID int64) ([]*entity.Notification, error)
+	GetNotificationPreferences(userID int64) ([]*entity.NotificationPreference, error)
+	GetComments(userID int64) ([]*entity.Comment, error)
+	GetWebhooks(userID int64) ([]*entity.Webhook, error)
+	GetUsernameHistory(userID int64) ([]*entity.UsernameHistory, error)
+	GetReportsFiled(userID int64) ([]*entity.Report, error)
+	GetVerifications(userID int64) ([]*entity.HandleVerification, error)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+)
+
+type exportRepository struct {
+	DB database.Database
+}
+
+func NewExportRepository(db database.Database) ExportRepository {
+	return &exportRepository{DB: db}
+}
+
+func (r *exportRepository) GetSavedPosts(userID int64) ([]*entity.SavedPost, error) {
+	var saved []*entity.SavedPost
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&saved).Error
+	return saved, err
+}
+
+func (r *exportRepository) GetSavedSearches(userID int64) ([]*entity.SavedSearch, error) {
+	var searches []*entity.SavedSearch
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&searches).Error
+	return searches, err
+}
+
+func (r *exportRepository) GetNotifications(userID int64) ([]*entity.Notification, error) {
+	var notifications []*entity.Notification
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *exportRepository) GetNotificationPreferences(userID int64) ([]*entity.NotificationPreference, error) {
+	var preferences []*entity.NotificationPreference
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("type").Find(&preferences).Error
+	return preferences, err
+}
+
+// GetComments includes deleted comments, which are still stored to keep
+// their replies in place.
+func (r *exportRepository) GetComments(userID int64) ([]*entity.Comment, error) {
+	var comments []*entity.Comment
+	err := r.DB.GetDb().Where("author_id = ?", userID).Order("created_at").Find(&comments).Error
+	return comments, err
+}
+
+func (r *exportRepository) GetWebhooks(userID int64) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *exportRepository) GetUsernameHistory(userID int64) ([]*entity.UsernameHistory, error) {
+	var history []*entity.UsernameHistory
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("changed_at").Find(&history).Error
+	return history, err
+}
+
+func (r *exportRepository) GetReportsFiled(userID int64) ([]*entity.Report, error) {
+	var reports []*entity.Report
+	err := r.DB.GetDb().Where("reporter_id = ?", userID).Order("created_at").Find(&reports).Error
+	return reports, err
+}
+
+func (r *exportRepository) GetVerifications(userID int64) ([]*entity.HandleVerification, error) {
+	var verifications []*entity.HandleVerification
+	err := r.DB.GetDb().Where("user_id = ?", userID).Order("platform").Find(&verifications).Error
+	return verifications, err
+}
@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"io"
+)
+
+type ExportUseCase interface {
+	ExportJSON(userID int64, w io.Writer) error
+	ExportZIP(userID int64, w io.Writer) error
+}
@@ -0,0 +1,450 @@
+package usecase
+
+import (
+	exportRepository "DiplomaV2/backend/export/repository"
+	identityRepository "DiplomaV2/backend/identity/repository"
+	"DiplomaV2/backend/internal/entity"
+	postRepository "DiplomaV2/backend/post/repository"
+	userRepository "DiplomaV2/backend/user/repository"
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type exportUseCaseImpl struct {
+	userRepo     userRepository.UserRepository
+	postRepo     postRepository.PostRepository
+	identityRepo identityRepository.IdentityRepository
+	exportRepo   exportRepository.ExportRepository
+}
+
+// Everything we store about a user, in a shape that doesn't depend on the
+// API response types.
+type userExport struct {
+	ExportedAt      time.Time                `json:"exportedAt"`
+	Profile         profileExport            `json:"profile"`
+	SkillLevels     []skillLevelExport       `json:"skillLevels"`
+	UsernameHistory []usernameExport         `json:"usernameHistory"`
+	Verifications   []verificationExport     `json:"handleVerifications"`
+	Posts           []postExport             `json:"posts"`
+	SavedPosts      []savedPostExport        `json:"savedPosts"`
+	SavedSearches   []savedSearchExport      `json:"savedSearches"`
+	Comments        []commentExport          `json:"comments"`
+	Notifications   []notificationExport     `json:"notifications"`
+	Preferences     []notificationPreference `json:"notificationPreferences"`
+	Webhooks        []webhookExport          `json:"webhooks"`
+	ReportsFiled    []reportExport           `json:"reportsFiled"`
+	Identities      []identityExport         `json:"linkedIdentities"`
+}
+
+type profileExport struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Name         string    `json:"name"`
+	Surname      string    `json:"surname"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	Telegram     string    `json:"telegram"`
+	Discord      string    `json:"discord"`
+	Skills       []string  `json:"skills"`
+	ProfileImage string    `json:"profileImage"`
+	TwoFactor    bool      `json:"twoFactorEnabled"`
+	Privacy      privacy   `json:"privacy"`
+}
+
+type privacy struct {
+	Telegram         string `json:"telegram"`
+	Discord          string `json:"discord"`
+	Skills           string `json:"skills"`
+	HiddenFromSearch bool   `json:"hiddenFromSearch"`
+}
+
+type skillLevelExport struct {
+	Skill string `json:"skill"`
+	Level string `json:"level"`
+	Years int    `json:"years"`
+}
+
+type usernameExport struct {
+	Username  string    `json:"username"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+type verificationExport struct {
+	Platform  string    `json:"platform"`
+	Handle    string    `json:"handle"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type postExport struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Skills      []string  `json:"skills"`
+}
+
+type savedPostExport struct {
+	PostID  int64     `json:"postId"`
+	SavedAt time.Time `json:"savedAt"`
+}
+
+type savedSearchExport struct {
+	ID           int64     `json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Name         string    `json:"name"`
+	PostName     string    `json:"postName"`
+	Description  string    `json:"description"`
+	Type         string    `json:"type"`
+	Skills       []string  `json:"skills"`
+	SkillLevels  []string  `json:"skillLevels"`
+	Roles        []string  `json:"roles"`
+	WorkMode     string    `json:"workMode"`
+	City         string    `json:"city"`
+	Commitment   string    `json:"commitment"`
+	Stage        string    `json:"stage"`
+	OpenSlots    int       `json:"openSlots"`
+	Frequency    string    `json:"frequency"`
+	EmailEnabled bool      `json:"emailEnabled"`
+	LastRunAt    time.Time `json:"lastRunAt"`
+}
+
+type commentExport struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"createdAt"`
+	EditedAt  *time.Time `json:"editedAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
+	PostID    int64      `json:"postId"`
+	ParentID  *int64     `json:"parentId"`
+	Body      string     `json:"body"`
+}
+
+type notificationExport struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link"`
+	ReadAt    *time.Time `json:"readAt"`
+}
+
+type notificationPreference struct {
+	Type  string `json:"type"`
+	InApp bool   `json:"inApp"`
+	Email bool   `json:"email"`
+}
+
+// webhookExport leaves out the signing secret, which the user was shown when
+// the webhook was created.
+type webhookExport struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+}
+
+type reportExport struct {
+	CreatedAt    time.Time  `json:"createdAt"`
+	TargetPostID *int64     `json:"targetPostId,omitempty"`
+	TargetUserID *int64     `json:"targetUserId,omitempty"`
+	Reason       string     `json:"reason"`
+	Details      string     `json:"details"`
+	Status       string     `json:"status"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+}
+
+type identityExport struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"linkedAt"`
+}
+
+func NewExportUseCase(userRepo userRepository.UserRepository, postRepo postRepository.PostRepository, identityRepo identityRepository.IdentityRepository, exportRepo exportRepository.ExportRepository) ExportUseCase {
+	return &exportUseCaseImpl{
+		userRepo:     userRepo,
+		postRepo:     postRepo,
+		identityRepo: identityRepo,
+		exportRepo:   exportRepo,
+	}
+}
+
+func (e *exportUseCaseImpl) ExportJSON(userID int64, w io.Writer) error {
+	export, err := e.collect(userID)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, export)
+}
+
+// ExportZIP writes the same data as ExportJSON split into one file per section.
+func (e *exportUseCaseImpl) ExportZIP(userID int64, w io.Writer) error {
+	export, err := e.collect(userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	files := map[string]interface{}{
+		"profile.json":                  export.Profile,
+		"skill_levels.json":             export.SkillLevels,
+		"username_history.json":         export.UsernameHistory,
+		"handle_verifications.json":     export.Verifications,
+		"posts.json":                    export.Posts,
+		"saved_posts.json":              export.SavedPosts,
+		"saved_searches.json":           export.SavedSearches,
+		"comments.json":                 export.Comments,
+		"notifications.json":            export.Notifications,
+		"notification_preferences.json": export.Preferences,
+		"webhooks.json":                 export.Webhooks,
+		"reports_filed.json":            export.ReportsFiled,
+		"identities.json":               export.Identities,
+	}
+	for name, content := range files {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(file, content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (e *exportUseCaseImpl) collect(userID int64) (*userExport, error) {
+	user, err := e.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := e.postRepo.GetAllByAuthor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := e.identityRepo.GetAllForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &userExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     toProfileExport(user),
+		SkillLevels: make([]skillLevelExport, 0, len(user.SkillLevels)),
+		Posts:       make([]postExport, 0, len(posts)),
+		Identities:  make([]identityExport, 0, len(identities)),
+	}
+	for _, level := range user.SkillLevels {
+		export.SkillLevels = append(export.SkillLevels, skillLevelExport{
+			Skill: level.Skill,
+			Level: level.Level,
+			Years: level.Years,
+		})
+	}
+	for _, post := range posts {
+		export.Posts = append(export.Posts, postExport{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			Name:        post.Name,
+			Description: post.Description,
+			Type:        post.Type,
+			Skills:      post.Skills,
+		})
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, identityExport{
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	if err := e.collectAccount(userID, export); err != nil {
+		return nil, err
+	}
+	if err := e.collectActivity(userID, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (e *exportUseCaseImpl) collectAccount(userID int64, export *userExport) error {
+	history, err := e.exportRepo.GetUsernameHistory(userID)
+	if err != nil {
+		return err
+	}
+	export.UsernameHistory = make([]usernameExport, 0, len(history))
+	for _, entry := range history {
+		export.UsernameHistory = append(export.UsernameHistory, usernameExport{
+			Username:  entry.Username,
+			ChangedAt: entry.ChangedAt,
+		})
+	}
+
+	verifications, err := e.exportRepo.GetVerifications(userID)
+	if err != nil {
+		return err
+	}
+	export.Verifications = make([]verificationExport, 0, len(verifications))
+	for _, verification := range verifications {
+		export.Verifications = append(export.Verifications, verificationExport{
+			Platform:  verification.Platform,
+			Handle:    verification.Handle,
+			ExpiresAt: verification.ExpiresAt,
+		})
+	}
+
+	preferences, err := e.exportRepo.GetNotificationPreferences(userID)
+	if err != nil {
+		return err
+	}
+	export.Preferences = make([]notificationPreference, 0, len(preferences))
+	for _, preference := range preferences {
+		export.Preferences = append(export.Preferences, notificationPreference{
+			Type:  preference.Type,
+			InApp: preference.InApp,
+			Email: preference.Email,
+		})
+	}
+
+	webhooks, err := e.exportRepo.GetWebhooks(userID)
+	if err != nil {
+		return err
+	}
+	export.Webhooks = make([]webhookExport, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		export.Webhooks = append(export.Webhooks, webhookExport{
+			ID:          webhook.ID,
+			CreatedAt:   webhook.CreatedAt,
+			URL:         webhook.URL,
+			Events:      webhook.Events,
+			Description: webhook.Description,
+			Active:      webhook.Active,
+		})
+	}
+	return nil
+}
+
+func (e *exportUseCaseImpl) collectActivity(userID int64, export *userExport) error {
+	saved, err := e.exportRepo.GetSavedPosts(userID)
+	if err != nil {
+		return err
+	}
+	export.SavedPosts = make([]savedPostExport, 0, len(saved))
+	for _, entry := range saved {
+		export.SavedPosts = append(export.SavedPosts, savedPostExport{
+			PostID:  entry.PostID,
+			SavedAt: entry.CreatedAt,
+		})
+	}
+
+	searches, err := e.exportRepo.GetSavedSearches(userID)
+	if err != nil {
+		return err
+	}
+	export.SavedSearches = make([]savedSearchExport, 0, len(searches))
+	for _, search := range searches {
+		export.SavedSearches = append(export.SavedSearches, savedSearchExport{
+			ID:           search.ID,
+			CreatedAt:    search.CreatedAt,
+			Name:         search.Name,
+			PostName:     search.PostName,
+			Description:  search.Description,
+			Type:         search.Type,
+			Skills:       search.Skills,
+			SkillLevels:  search.SkillLevels,
+			Roles:        search.Roles,
+			WorkMode:     search.WorkMode,
+			City:         search.City,
+			Commitment:   search.Commitment,
+			Stage:        search.Stage,
+			OpenSlots:    search.OpenSlots,
+			Frequency:    search.Frequency,
+			EmailEnabled: search.EmailEnabled,
+			LastRunAt:    search.LastRunAt,
+		})
+	}
+
+	comments, err := e.exportRepo.GetComments(userID)
+	if err != nil {
+		return err
+	}
+	export.Comments = make([]commentExport, 0, len(comments))
+	for _, comment := range comments {
+		export.Comments = append(export.Comments, commentExport{
+			ID:        comment.ID,
+			CreatedAt: comment.CreatedAt,
+			EditedAt:  comment.EditedAt,
+			DeletedAt: comment.DeletedAt,
+			PostID:    comment.PostID,
+			ParentID:  comment.ParentID,
+			Body:      comment.Body,
+		})
+	}
+
+	notifications, err := e.exportRepo.GetNotifications(userID)
+	if err != nil {
+		return err
+	}
+	export.Notifications = make([]notificationExport, 0, len(notifications))
+	for _, notification := range notifications {
+		export.Notifications = append(export.Notifications, notificationExport{
+			CreatedAt: notification.CreatedAt,
+			Type:      notification.Type,
+			Title:     notification.Title,
+			Body:      notification.Body,
+			Link:      notification.Link,
+			ReadAt:    notification.ReadAt,
+		})
+	}
+
+	reports, err := e.exportRepo.GetReportsFiled(userID)
+	if err != nil {
+		return err
+	}
+	export.ReportsFiled = make([]reportExport, 0, len(reports))
+	for _, report := range reports {
+		export.ReportsFiled = append(export.ReportsFiled, reportExport{
+			CreatedAt:    report.CreatedAt,
+			TargetPostID: report.TargetPostID,
+			TargetUserID: report.TargetUserID,
+			Reason:       report.Reason,
+			Details:      report.Details,
+			Status:       report.Status,
+			ResolvedAt:   report.ResolvedAt,
+		})
+	}
+	return nil
+}
+
+func toProfileExport(user *entity.User) profileExport {
+	return profileExport{
+		ID:           user.ID,
+		CreatedAt:    user.CreatedAt,
+		Name:         user.Name,
+		Surname:      user.Surname,
+		Username:     user.Username,
+		Email:        user.Email,
+		Telegram:     user.Telegram,
+		Discord:      user.Discord,
+		Skills:       user.Skills,
+		ProfileImage: user.ProfileImage,
+		TwoFactor:    user.TOTPEnabled,
+		Privacy: privacy{
+			Telegram:         user.Privacy.Telegram,
+			Discord:          user.Privacy.Discord,
+			Skills:           user.Privacy.Skills,
+			HiddenFromSearch: user.Privacy.HiddenFromSearch,
+		},
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
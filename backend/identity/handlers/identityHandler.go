@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type IdentityHandler interface {
+	GetProviders(c echo.Context) error
+	Login(c echo.Context) error
+	Callback(c echo.Context) error
+	GetIdentities(c echo.Context) error
+	Unlink(c echo.Context) error
+}
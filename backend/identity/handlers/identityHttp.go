@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/identity/usecase"
+	middleware2 "DiplomaV2/backend/internal/middleware"
+	userUseCase "DiplomaV2/backend/user/usecase"
+	"crypto/subtle"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stateCookieName = "oauth_state"
+
+type identityHttpHandler struct {
+	identityUseCase usecase.IdentityUseCase
+	userUseCase     userUseCase.UserUseCase
+	frontendURL     string
+}
+
+func (i *identityHttpHandler) GetProviders(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{"providers": i.identityUseCase.Providers()})
+}
+
+// Login redirects to the provider. State and PKCE verifier travel in a
+// short-lived cookie scoped to the callback.
+func (i *identityHttpHandler) Login(c echo.Context) error {
+	provider := c.Param("provider")
+
+	authURL, state, verifier, err := i.identityUseCase.BeginLogin(provider)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnknownProvider) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookieName,
+		Value:    state + "." + verifier,
+		Path:     "/v2/auth/" + provider,
+		Expires:  time.Now().Add(10 * time.Minute),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (i *identityHttpHandler) Callback(c echo.Context) error {
+	provider := c.Param("provider")
+
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return i.redirectWithError(c, providerErr)
+	}
+
+	cookie, err := c.Cookie(stateCookieName)
+	if err != nil {
+		return i.redirectWithError(c, "missing_state")
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookieName,
+		Value:    "",
+		Path:     "/v2/auth/" + provider,
+		Expires:  time.Unix(0, 0),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	state, verifier, found := strings.Cut(cookie.Value, ".")
+	if !found || subtle.ConstantTimeCompare([]byte(state), []byte(c.QueryParam("state"))) != 1 {
+		return i.redirectWithError(c, "invalid_state")
+	}
+
+	user, err := i.identityUseCase.CompleteLogin(c.Request().Context(), provider, c.QueryParam("code"), verifier)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrEmailNotVerified):
+			return i.redirectWithError(c, "email_not_verified")
+		case errors.Is(err, usecase.ErrUnknownProvider):
+			return i.redirectWithError(c, "unknown_provider")
+		default:
+			c.Logger().Error(err)
+			return i.redirectWithError(c, "login_failed")
+		}
+	}
+
+	if user.TOTPEnabled {
+		token, err := i.userUseCase.CreateMFAPendingToken(user)
+		if err != nil {
+			return i.redirectWithError(c, "login_failed")
+		}
+		middleware2.SetMFAPendingCookie(c, token)
+		return c.Redirect(http.StatusFound, i.frontendURL+"/login?mfa=required")
+	}
+
+	token, err := i.userUseCase.CreateAuthenticationToken(user)
+	if err != nil {
+		return i.redirectWithError(c, "login_failed")
+	}
+	middleware2.SetAuthCookie(c, token)
+
+	return c.Redirect(http.StatusFound, i.frontendURL+"/")
+}
+
+func (i *identityHttpHandler) GetIdentities(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	identities, err := i.identityUseCase.GetIdentities(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"identities": identities})
+}
+
+func (i *identityHttpHandler) Unlink(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	identityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid identity id"})
+	}
+
+	if err := i.identityUseCase.Unlink(userID, identityID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Identity not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (i *identityHttpHandler) redirectWithError(c echo.Context, reason string) error {
+	return c.Redirect(http.StatusFound, i.frontendURL+"/login?error="+url.QueryEscape(reason))
+}
+
+func NewIdentityHttpHandler(identityUseCase usecase.IdentityUseCase, userUseCase userUseCase.UserUseCase, frontendURL string) IdentityHandler {
+	return &identityHttpHandler{
+		identityUseCase: identityUseCase,
+		userUseCase:     userUseCase,
+		frontendURL:     strings.TrimSuffix(frontendURL, "/"),
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/identity/usecase"
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	middleware2 "DiplomaV2/backend/internal/middleware"
+	"DiplomaV2/backend/internal/oauth/oauthtest"
+	userRepository "DiplomaV2/backend/user/repository"
+	userUseCase "DiplomaV2/backend/user/usecase"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const frontendURL = "http://frontend.test"
+
+type fakeUserUseCase struct {
+	userUseCase.UserUseCase
+}
+
+func (fakeUserUseCase) CreateAuthenticationToken(user *entity.User) (string, error) {
+	return "session-token", nil
+}
+
+type fakeUserRepo struct {
+	userRepository.UserRepository
+	users map[int64]*entity.User
+}
+
+func (r *fakeUserRepo) GetByID(id int64) (*entity.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) GetByEmail(string) (*entity.User, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) UsernameTaken(string, int64) (bool, error) {
+	return false, nil
+}
+
+type fakeIdentityRepo struct {
+	users      *fakeUserRepo
+	identities []*entity.UserIdentity
+}
+
+func (r *fakeIdentityRepo) Insert(identity *entity.UserIdentity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) GetByProviderSubject(provider, subject string) (*entity.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) GetAllForUser(int64) ([]*entity.UserIdentity, error) {
+	return r.identities, nil
+}
+
+func (r *fakeIdentityRepo) Delete(int64, int64) error {
+	return nil
+}
+
+func (r *fakeIdentityRepo) InsertWithUser(user *entity.User, identity *entity.UserIdentity) error {
+	user.ID = int64(len(r.users.users) + 1)
+	r.users.users[user.ID] = user
+	identity.UserID = user.ID
+	return r.Insert(identity)
+}
+
+func (r *fakeIdentityRepo) InsertWithTakeover(user *entity.User, identity *entity.UserIdentity) error {
+	identity.UserID = user.ID
+	return r.Insert(identity)
+}
+
+func newTestHandler(t *testing.T) (*echo.Echo, *oauthtest.Server) {
+	t.Helper()
+	server := oauthtest.NewServer()
+	t.Cleanup(server.Close)
+	server.SetClaims(map[string]interface{}{
+		"sub":            "sub-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+	})
+
+	users := &fakeUserRepo{users: make(map[int64]*entity.User)}
+	identities := &fakeIdentityRepo{users: users}
+	uc := usecase.NewIdentityUseCase(identities, users, map[string]*config.OAuthProvider{
+		"test": server.Provider("http://app.test/v2/auth/test/callback"),
+	})
+	handler := NewIdentityHttpHandler(uc, fakeUserUseCase{}, frontendURL)
+
+	e := echo.New()
+	e.GET("/v2/auth/:provider/login", handler.Login)
+	e.GET("/v2/auth/:provider/callback", handler.Callback)
+	return e, server
+}
+
+func serve(e *echo.Echo, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// startLogin hits the login endpoint and lets the provider approve it,
+// returning the state cookie and the provider's callback URL.
+func startLogin(t *testing.T, e *echo.Echo, server *oauthtest.Server) (*http.Cookie, *url.URL) {
+	t.Helper()
+	rec := serve(e, "/v2/auth/test/login")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want 302", rec.Code)
+	}
+	stateCookie := findCookie(rec, stateCookieName)
+	if stateCookie == nil || !stateCookie.HttpOnly {
+		t.Fatal("login must set an HttpOnly state cookie")
+	}
+
+	callback, err := server.Authorize(rec.Header().Get(echo.HeaderLocation))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	return stateCookie, callback
+}
+
+func TestCallbackCompletesLogin(t *testing.T) {
+	e, server := newTestHandler(t)
+	stateCookie, callback := startLogin(t, e, server)
+
+	rec := serve(e, "/v2/auth/test/callback?"+callback.RawQuery, stateCookie)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, want 302", rec.Code)
+	}
+	if location := rec.Header().Get(echo.HeaderLocation); location != frontendURL+"/" {
+		t.Errorf("redirect = %q, want %q", location, frontendURL+"/")
+	}
+	auth := findCookie(rec, middleware2.AuthCookieName)
+	if auth == nil || auth.Value != "session-token" {
+		t.Error("callback must set the auth cookie")
+	}
+	if cleared := findCookie(rec, stateCookieName); cleared == nil || cleared.Value != "" {
+		t.Error("callback must clear the state cookie")
+	}
+}
+
+func TestCallbackRejectsMismatchedState(t *testing.T) {
+	e, server := newTestHandler(t)
+	stateCookie, callback := startLogin(t, e, server)
+
+	query := callback.Query()
+	query.Set("state", "forged")
+	rec := serve(e, "/v2/auth/test/callback?"+query.Encode(), stateCookie)
+
+	if location := rec.Header().Get(echo.HeaderLocation); location != frontendURL+"/login?error=invalid_state" {
+		t.Errorf("redirect = %q, want invalid_state", location)
+	}
+	if findCookie(rec, middleware2.AuthCookieName) != nil {
+		t.Error("no session may be issued for a forged state")
+	}
+}
+
+func TestCallbackRequiresStateCookie(t *testing.T) {
+	e, server := newTestHandler(t)
+	_, callback := startLogin(t, e, server)
+
+	rec := serve(e, "/v2/auth/test/callback?"+callback.RawQuery)
+
+	if location := rec.Header().Get(echo.HeaderLocation); location != frontendURL+"/login?error=missing_state" {
+		t.Errorf("redirect = %q, want missing_state", location)
+	}
+}
+
+func TestCallbackRejectsVerifierFromAnotherLogin(t *testing.T) {
+	e, server := newTestHandler(t)
+	otherCookie, _ := startLogin(t, e, server)
+	_, callback := startLogin(t, e, server)
+
+	// The attacker's cookie carries a valid state/verifier pair, but not the
+	// verifier the provider's code was issued for.
+	query := callback.Query()
+	otherState, _, _ := strings.Cut(otherCookie.Value, ".")
+	query.Set("state", otherState)
+	rec := serve(e, "/v2/auth/test/callback?"+query.Encode(), otherCookie)
+
+	if location := rec.Header().Get(echo.HeaderLocation); location != frontendURL+"/login?error=login_failed" {
+		t.Errorf("redirect = %q, want login_failed", location)
+	}
+}
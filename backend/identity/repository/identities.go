@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+)
+
+type IdentityRepository interface {
+	Insert(identity *entity.UserIdentity) error
+	GetByProviderSubject(provider, subject string) (*entity.UserIdentity, error)
+	GetAllForUser(userID int64) ([]*entity.UserIdentity, error)
+	Delete(id, userID int64) error
+	InsertWithUser(user *entity.User, identity *entity.UserIdentity) error
+	InsertWithTakeover(user *entity.User, identity *entity.UserIdentity) error
+}
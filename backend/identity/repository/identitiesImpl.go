@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	"gorm.io/gorm"
+)
+
+type identityRepository struct {
+	DB database.Database
+}
+
+func NewIdentityRepository(db database.Database) IdentityRepository {
+	return &identityRepository{DB: db}
+}
+
+func (r *identityRepository) Insert(identity *entity.UserIdentity) error {
+	return r.DB.GetDb().Create(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	result := r.DB.GetDb().Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) GetAllForUser(userID int64) ([]*entity.UserIdentity, error) {
+	var identities []*entity.UserIdentity
+	result := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&identities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return identities, nil
+}
+
+func (r *identityRepository) Delete(id, userID int64) error {
+	result := r.DB.GetDb().Where("id = ? AND user_id = ?", id, userID).Delete(&entity.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// InsertWithUser creates a new account together with its first identity.
+func (r *identityRepository) InsertWithUser(user *entity.User, identity *entity.UserIdentity) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// InsertWithTakeover links identity to an account that was never activated
+// and hands the account to the provider-verified owner of its email: the
+// user row is saved as given, and every token and identity left by whoever
+// registered it is removed.
+func (r *identityRepository) InsertWithTakeover(user *entity.User, identity *entity.UserIdentity) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("SkillLevels").Save(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&entity.Token{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&entity.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
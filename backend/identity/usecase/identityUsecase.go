@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"golang.org/x/net/context"
+)
+
+type IdentityUseCase interface {
+	Providers() []string
+	BeginLogin(provider string) (authURL, state, verifier string, err error)
+	CompleteLogin(ctx context.Context, provider, code, verifier string) (*entity.User, error)
+	GetIdentities(userID int64) ([]*entity.UserIdentity, error)
+	Unlink(userID, identityID int64) error
+}
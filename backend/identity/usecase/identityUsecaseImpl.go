@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/identity/repository"
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/oauth"
+	"DiplomaV2/backend/internal/validator"
+	userRepository "DiplomaV2/backend/user/repository"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown login provider")
+	ErrEmailNotVerified = errors.New("the provider did not return a verified email")
+)
+
+var usernameUnsafeRX = regexp.MustCompile(`[^a-z0-9_]+`)
+
+const defaultProfileImage = "https://storage.googleapis.com/teamfinderimages/default_photo.png"
+
+type identityUseCaseImpl struct {
+	repo      repository.IdentityRepository
+	userRepo  userRepository.UserRepository
+	providers map[string]*oauth.Provider
+}
+
+func NewIdentityUseCase(repo repository.IdentityRepository, userRepo userRepository.UserRepository, providers map[string]*config.OAuthProvider) IdentityUseCase {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	configured := make(map[string]*oauth.Provider, len(providers))
+	for name, p := range providers {
+		configured[name] = &oauth.Provider{
+			Name:         name,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			AuthURL:      p.AuthURL,
+			TokenURL:     p.TokenURL,
+			UserInfoURL:  p.UserInfoURL,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			TrustEmail:   p.TrustEmail,
+			Client:       client,
+		}
+	}
+
+	return &identityUseCaseImpl{
+		repo:      repo,
+		userRepo:  userRepo,
+		providers: configured,
+	}
+}
+
+func (i *identityUseCaseImpl) Providers() []string {
+	names := make([]string, 0, len(i.providers))
+	for name := range i.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (i *identityUseCaseImpl) BeginLogin(provider string) (string, string, string, error) {
+	p, ok := i.providers[provider]
+	if !ok {
+		return "", "", "", ErrUnknownProvider
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		return "", "", "", err
+	}
+	verifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return p.AuthCodeURL(state, verifier), state, verifier, nil
+}
+
+// CompleteLogin resolves the provider account to a local user: an existing
+// link wins, then an account with the same verified email is linked (or
+// taken over if it was never activated), and otherwise a new, already
+// activated account is created.
+func (i *identityUseCaseImpl) CompleteLogin(ctx context.Context, provider, code, verifier string) (*entity.User, error) {
+	p, ok := i.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	accessToken, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := p.UserInfo(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := i.repo.GetByProviderSubject(provider, info.Subject)
+	if err == nil {
+		return i.userRepo.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !info.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	identity = &entity.UserIdentity{
+		Provider: provider,
+		Subject:  info.Subject,
+		Email:    info.Email,
+	}
+
+	user, err := i.userRepo.GetByEmail(info.Email)
+	switch {
+	case err == nil:
+		return i.link(user, identity)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return i.register(info, identity)
+	default:
+		return nil, err
+	}
+}
+
+func (i *identityUseCaseImpl) GetIdentities(userID int64) ([]*entity.UserIdentity, error) {
+	return i.repo.GetAllForUser(userID)
+}
+
+func (i *identityUseCaseImpl) Unlink(userID, identityID int64) error {
+	return i.repo.Delete(identityID, userID)
+}
+
+func (i *identityUseCaseImpl) link(user *entity.User, identity *entity.UserIdentity) (*entity.User, error) {
+	if user.Activated {
+		identity.UserID = user.ID
+		if err := i.repo.Insert(identity); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Nobody has proven they own the address of an unactivated account, so
+	// whoever registered it may not be its owner. The provider has verified
+	// the address, so the account is taken over instead of shared: the
+	// password is replaced and two-factor, tokens and identities are dropped.
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Password.Set(password); err != nil {
+		return nil, err
+	}
+	user.Activated = true
+	user.PendingEmail = ""
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	user.Version++
+
+	if err := i.repo.InsertWithTakeover(user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (i *identityUseCaseImpl) register(info *oauth.UserInfo, identity *entity.UserIdentity) (*entity.User, error) {
+	username, err := i.availableUsername(info)
+	if err != nil {
+		return nil, err
+	}
+
+	name := info.Name
+	if name == "" {
+		name = username
+	}
+
+	profileImage := info.Picture
+	if profileImage == "" {
+		profileImage = defaultProfileImage
+	}
+
+	user := &entity.User{
+		Name:         name,
+		Username:     username,
+		Email:        info.Email,
+		ProfileImage: profileImage,
+		Activated:    true,
+	}
+
+	// The account can only be accessed through the provider until the user
+	// sets a password via the forgot-password flow.
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	if err := user.Password.Set(password); err != nil {
+		return nil, err
+	}
+
+	if err := i.repo.InsertWithUser(user, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (i *identityUseCaseImpl) availableUsername(info *oauth.UserInfo) (string, error) {
+	base := info.Username
+	if base == "" {
+		base = strings.Split(info.Email, "@")[0]
+	}
+	base = usernameUnsafeRX.ReplaceAllString(strings.ToLower(base), "_")
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	base = strings.Trim(base, "_")
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for n := 1; n <= 100; n++ {
+		taken, err := i.userRepo.UsernameTaken(candidate, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken && !validator.IsReservedUsername(candidate) {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, n)
+	}
+	return "", errors.New("could not find a free username")
+}
+
+func randomPassword() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes)[:40], nil
+}
@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/oauth"
+	"DiplomaV2/backend/internal/oauth/oauthtest"
+	userRepository "DiplomaV2/backend/user/repository"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+const callbackURL = "http://app.test/v2/auth/test/callback"
+
+type fakeUserRepo struct {
+	userRepository.UserRepository
+	users  map[int64]*entity.User
+	nextID int64
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: make(map[int64]*entity.User)}
+}
+
+func (r *fakeUserRepo) add(user *entity.User) *entity.User {
+	r.nextID++
+	user.ID = r.nextID
+	r.users[user.ID] = user
+	return user
+}
+
+func (r *fakeUserRepo) GetByID(id int64) (*entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*entity.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) UsernameTaken(username string, exceptUserID int64) (bool, error) {
+	for _, user := range r.users {
+		if user.ID != exceptUserID && strings.EqualFold(user.Username, username) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeUserRepo) Update(user *entity.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+type fakeIdentityRepo struct {
+	users      *fakeUserRepo
+	identities []*entity.UserIdentity
+	takeovers  int
+}
+
+func (r *fakeIdentityRepo) Insert(identity *entity.UserIdentity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) GetByProviderSubject(provider, subject string) (*entity.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) GetAllForUser(userID int64) ([]*entity.UserIdentity, error) {
+	var identities []*entity.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeIdentityRepo) Delete(id, userID int64) error {
+	return nil
+}
+
+func (r *fakeIdentityRepo) InsertWithUser(user *entity.User, identity *entity.UserIdentity) error {
+	r.users.add(user)
+	identity.UserID = user.ID
+	return r.Insert(identity)
+}
+
+func (r *fakeIdentityRepo) InsertWithTakeover(user *entity.User, identity *entity.UserIdentity) error {
+	r.takeovers++
+	kept := r.identities[:0]
+	for _, existing := range r.identities {
+		if existing.UserID != user.ID {
+			kept = append(kept, existing)
+		}
+	}
+	r.identities = kept
+	r.users.users[user.ID] = user
+	identity.UserID = user.ID
+	return r.Insert(identity)
+}
+
+func newTestUseCase(t *testing.T) (IdentityUseCase, *fakeIdentityRepo, *oauthtest.Server) {
+	t.Helper()
+	server := oauthtest.NewServer()
+	t.Cleanup(server.Close)
+
+	users := newFakeUserRepo()
+	identities := &fakeIdentityRepo{users: users}
+	uc := NewIdentityUseCase(identities, users, map[string]*config.OAuthProvider{
+		"test": server.Provider(callbackURL),
+	})
+	return uc, identities, server
+}
+
+// login runs the browser side of the flow and hands the callback parameters
+// to CompleteLogin the way the handler does.
+func login(t *testing.T, uc IdentityUseCase, server *oauthtest.Server) (*entity.User, error) {
+	t.Helper()
+	authURL, state, verifier, err := uc.BeginLogin("test")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	callback, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if got := callback.Query().Get("state"); got != state {
+		t.Fatalf("callback state = %q, want %q", got, state)
+	}
+	return uc.CompleteLogin(context.Background(), "test", callback.Query().Get("code"), verifier)
+}
+
+func claims(subject, email string) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                subject,
+		"email":              email,
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "Jane.Doe",
+	}
+}
+
+func TestBeginLoginSendsStateAndChallenge(t *testing.T) {
+	uc, _, _ := newTestUseCase(t)
+
+	authURL, state, verifier, err := uc.BeginLogin("test")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	if state == "" || verifier == "" {
+		t.Fatal("expected a state and a verifier")
+	}
+	if strings.Contains(authURL, verifier) {
+		t.Error("auth URL must not contain the PKCE verifier")
+	}
+	if !strings.Contains(authURL, "code_challenge="+oauth.Challenge(verifier)) {
+		t.Errorf("auth URL %q does not carry the S256 challenge", authURL)
+	}
+	if !strings.Contains(authURL, "state="+state) {
+		t.Errorf("auth URL %q does not carry the state", authURL)
+	}
+
+	if _, _, _, err := uc.BeginLogin("unknown"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unknown provider: err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestCompleteLoginRejectsWrongVerifier(t *testing.T) {
+	uc, identities, server := newTestUseCase(t)
+	server.SetClaims(claims("sub-1", "jane@example.com"))
+
+	authURL, _, _, err := uc.BeginLogin("test")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	callback, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	otherVerifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = uc.CompleteLogin(context.Background(), "test", callback.Query().Get("code"), otherVerifier)
+	if !errors.Is(err, oauth.ErrExchangeFailed) {
+		t.Fatalf("err = %v, want ErrExchangeFailed", err)
+	}
+	if len(identities.identities) != 0 {
+		t.Error("no identity should be created when the exchange fails")
+	}
+}
+
+func TestCompleteLoginRegistersNewUser(t *testing.T) {
+	uc, identities, server := newTestUseCase(t)
+	server.SetClaims(claims("sub-1", "jane@example.com"))
+
+	user, err := login(t, uc, server)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if !user.Activated {
+		t.Error("registered user should be activated")
+	}
+	if user.Username != "jane_doe" {
+		t.Errorf("username = %q, want jane_doe", user.Username)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != user.ID {
+		t.Fatalf("identities = %+v, want one linked to user %d", identities.identities, user.ID)
+	}
+
+	again, err := login(t, uc, server)
+	if err != nil {
+		t.Fatalf("second CompleteLogin: %v", err)
+	}
+	if again.ID != user.ID || len(identities.identities) != 1 {
+		t.Error("second login should reuse the existing link")
+	}
+}
+
+func TestCompleteLoginLinksActivatedAccount(t *testing.T) {
+	uc, identities, server := newTestUseCase(t)
+	existing := identities.users.add(&entity.User{Username: "jane", Email: "jane@example.com", Activated: true})
+	if err := existing.Password.Set("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	server.SetClaims(claims("sub-1", "Jane@Example.com"))
+
+	user, err := login(t, uc, server)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if user.ID != existing.ID {
+		t.Fatalf("linked user %d, want %d", user.ID, existing.ID)
+	}
+	if identities.takeovers != 0 {
+		t.Error("an activated account must be linked, not taken over")
+	}
+	if ok, _ := user.Password.Matches("correct horse battery"); !ok {
+		t.Error("linking must keep the password")
+	}
+}
+
+func TestCompleteLoginTakesOverUnactivatedAccount(t *testing.T) {
+	uc, identities, server := newTestUseCase(t)
+	squatted := identities.users.add(&entity.User{
+		Username:    "jane",
+		Email:       "jane@example.com",
+		TOTPEnabled: true,
+		TOTPSecret:  "SECRET",
+	})
+	if err := squatted.Password.Set("squatter password"); err != nil {
+		t.Fatal(err)
+	}
+	_ = identities.Insert(&entity.UserIdentity{UserID: squatted.ID, Provider: "other", Subject: "squatter"})
+	server.SetClaims(claims("sub-1", "jane@example.com"))
+
+	user, err := login(t, uc, server)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if user.ID != squatted.ID {
+		t.Fatalf("user %d, want %d", user.ID, squatted.ID)
+	}
+	if identities.takeovers != 1 {
+		t.Fatal("an unactivated account must be taken over")
+	}
+	if !user.Activated {
+		t.Error("account should be activated")
+	}
+	if ok, _ := user.Password.Matches("squatter password"); ok {
+		t.Error("the registrant's password must stop working")
+	}
+	if user.TOTPEnabled || user.TOTPSecret != "" {
+		t.Error("two-factor set up by the registrant must be removed")
+	}
+	if _, err := identities.GetByProviderSubject("other", "squatter"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Error("identities linked by the registrant must be removed")
+	}
+}
+
+func TestCompleteLoginRequiresVerifiedEmail(t *testing.T) {
+	uc, identities, server := newTestUseCase(t)
+	identities.users.add(&entity.User{Username: "jane", Email: "jane@example.com", Activated: true})
+	unverified := claims("sub-1", "jane@example.com")
+	unverified["email_verified"] = false
+	server.SetClaims(unverified)
+
+	if _, err := login(t, uc, server); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("err = %v, want ErrEmailNotVerified", err)
+	}
+	if len(identities.identities) != 0 {
+		t.Error("no identity should be linked for an unverified email")
+	}
+}
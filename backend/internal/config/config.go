@@ -3,18 +3,108 @@ package config
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type (
 	Config struct {
-		Server *Server
-		Db     *Db
+		Server     *Server
+		Db         *Db
+		Moderation *Moderation
+		RateLimit  *RateLimit
+		Lockout    *Lockout
+		OAuth      map[string]*OAuthProvider
+		Tokens     *Tokens
+		Accounts   *Accounts
+		Posts      *Posts
+		Searches   *Searches
+		Webhooks   *Webhooks
+		// Verification configures the bots handles are verified with. A
+		// platform without a bot token can't be verified.
+		Verification *Verification
 	}
 
 	Server struct {
-		Port int
+		Port        int
+		FrontendURL string
+		PublicURL   string
+		// TrustedProxies are the CIDR ranges of the reverse proxies whose
+		// X-Forwarded-For is used as the client IP. Without any, the
+		// connection's address is used and forwarding headers are ignored.
+		TrustedProxies []string
+	}
+
+	OAuthProvider struct {
+		ClientID     string
+		ClientSecret string
+		AuthURL      string
+		TokenURL     string
+		UserInfoURL  string
+		RedirectURL  string
+		Scopes       []string
+		TrustEmail   bool
+	}
+
+	Tokens struct {
+		ActivationTTL time.Duration
+	}
+
+	Accounts struct {
+		DeletionGracePeriod time.Duration
+		PurgeInterval       time.Duration
+	}
+
+	Posts struct {
+		ExpiryCheckInterval time.Duration
+		ExpiryNoticeBefore  time.Duration
+		FeedSize            int
+		// ViewFlushInterval is how often the buffered post views are written.
+		ViewFlushInterval time.Duration
+		// ViewDedupeSize caps the viewers remembered to count each once a day.
+		ViewDedupeSize int
+	}
+
+	Searches struct {
+		DigestInterval    time.Duration
+		MaxPerUser        int
+		UnsubscribeSecret string
+	}
+
+	Webhooks struct {
+		MaxPerUser    int
+		Timeout       time.Duration
+		MaxAttempts   int
+		RetryBackoff  time.Duration
+		RetryInterval time.Duration
+	}
+
+	Verification struct {
+		CodeTTL             time.Duration
+		TelegramBotToken    string
+		TelegramBotUsername string
+		DiscordBotToken     string
+		DiscordChannelID    string
+	}
+
+	Moderation struct {
+		HideThreshold  int
+		ReportsPerHour int
+	}
+
+	RateLimit struct {
+		Store         string
+		IPLimit       int
+		IPWindow      time.Duration
+		AccountLimit  int
+		AccountWindow time.Duration
+	}
+
+	Lockout struct {
+		MaxAttempts int
+		Duration    time.Duration
+		MaxDuration time.Duration
 	}
 
 	Db struct {
@@ -41,6 +131,40 @@ func GetConfig() *Config {
 		viper.AutomaticEnv()
 		viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+		viper.SetDefault("server.frontendURL", "http://localhost:5173")
+		viper.SetDefault("server.publicURL", "http://localhost:4000")
+		viper.SetDefault("tokens.activationTTL", 72*time.Hour)
+		viper.SetDefault("accounts.deletionGracePeriod", 30*24*time.Hour)
+		viper.SetDefault("accounts.purgeInterval", time.Hour)
+		viper.SetDefault("posts.expiryCheckInterval", time.Hour)
+		viper.SetDefault("posts.expiryNoticeBefore", 72*time.Hour)
+		viper.SetDefault("posts.feedSize", 1000)
+		viper.SetDefault("posts.viewFlushInterval", time.Minute)
+		viper.SetDefault("posts.viewDedupeSize", 100_000)
+		viper.SetDefault("searches.digestInterval", 10*time.Minute)
+		viper.SetDefault("searches.maxPerUser", 20)
+		viper.SetDefault("searches.unsubscribeSecret", "")
+		viper.SetDefault("webhooks.maxPerUser", 10)
+		viper.SetDefault("webhooks.timeout", 10*time.Second)
+		viper.SetDefault("webhooks.maxAttempts", 6)
+		viper.SetDefault("webhooks.retryBackoff", 30*time.Second)
+		viper.SetDefault("webhooks.retryInterval", 30*time.Second)
+		viper.SetDefault("verification.codeTTL", 30*time.Minute)
+		viper.SetDefault("verification.telegramBotToken", "")
+		viper.SetDefault("verification.telegramBotUsername", "")
+		viper.SetDefault("verification.discordBotToken", "")
+		viper.SetDefault("verification.discordChannelID", "")
+		viper.SetDefault("moderation.hideThreshold", 3)
+		viper.SetDefault("moderation.reportsPerHour", 10)
+		viper.SetDefault("rateLimit.store", "memory")
+		viper.SetDefault("rateLimit.ipLimit", 20)
+		viper.SetDefault("rateLimit.ipWindow", time.Minute)
+		viper.SetDefault("rateLimit.accountLimit", 10)
+		viper.SetDefault("rateLimit.accountWindow", time.Hour)
+		viper.SetDefault("lockout.maxAttempts", 5)
+		viper.SetDefault("lockout.duration", time.Minute)
+		viper.SetDefault("lockout.maxDuration", time.Hour)
+
 		if err := viper.ReadInConfig(); err != nil {
 			panic(err)
 		}
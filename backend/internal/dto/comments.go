@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"time"
+)
+
+type Comment struct {
+	ID        int64      `json:"id"`
+	PostID    int64      `json:"postId"`
+	ParentID  *int64     `json:"parentId"`
+	Author    *Author    `json:"author"`
+	Body      string     `json:"body"`
+	Deleted   bool       `json:"deleted"`
+	CreatedAt time.Time  `json:"createdAt"`
+	EditedAt  *time.Time `json:"editedAt"`
+	Replies   []Comment  `json:"replies"`
+}
+
+// NewComment maps comment and its replies. A deleted comment keeps its
+// place in the thread but loses its body and author.
+func NewComment(comment *entity.Comment) Comment {
+	response := Comment{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		Deleted:   comment.IsDeleted(),
+		CreatedAt: comment.CreatedAt,
+		EditedAt:  comment.EditedAt,
+		Replies:   NewComments(comment.Replies),
+	}
+	if !comment.IsDeleted() {
+		response.Body = comment.Body
+		if comment.Author.ID != 0 {
+			author := NewAuthor(&comment.Author)
+			response.Author = &author
+		}
+	}
+	return response
+}
+
+func NewComments(comments []*entity.Comment) []Comment {
+	responses := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, NewComment(comment))
+	}
+	return responses
+}
@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// secrets are the values leakyUser carries that no response may contain.
+var secrets = []string{"jane@example.com", "pending@example.com", "TOTPSECRET", "token-plaintext", "$2a$12$"}
+
+// forbiddenKeys are the json keys of the fields holding them.
+var forbiddenKeys = []string{"email", "pendingEmail", "password", "hash", "tokens", "totpSecret", "plaintext"}
+
+func leakyUser() *entity.User {
+	user := &entity.User{
+		ID:           1,
+		Name:         "Jane",
+		Username:     "jane",
+		Email:        "jane@example.com",
+		PendingEmail: "pending@example.com",
+		TOTPSecret:   "TOTPSECRET",
+		Tokens:       []entity.Token{{Plaintext: "token-plaintext", Hash: []byte("token-hash")}},
+		Privacy: entity.ProfilePrivacy{
+			Telegram: entity.VisibilityPublic,
+			Discord:  entity.VisibilityPublic,
+			Skills:   entity.VisibilityPublic,
+		},
+	}
+	user.Password.Hash = []byte("$2a$12$abcdefghijklmnopqrstuv")
+	return user
+}
+
+// assertNoLeaks fails if response, once marshaled, has any of the secrets
+// or forbidden keys except those in allowed.
+func assertNoLeaks(t *testing.T, response interface{}, allowed ...string) {
+	t.Helper()
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(body), secret) && !contains(allowed, secret) {
+			t.Errorf("response leaks %q: %s", secret, body)
+		}
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	walkKeys(decoded, func(key string) {
+		for _, forbidden := range forbiddenKeys {
+			if strings.EqualFold(key, forbidden) && !contains(allowed, key) {
+				t.Errorf("response has the %q key: %s", key, body)
+			}
+		}
+	})
+}
+
+func walkKeys(value interface{}, visit func(key string)) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			visit(key)
+			walkKeys(nested, visit)
+		}
+	case []interface{}:
+		for _, nested := range value {
+			walkKeys(nested, visit)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProfileDoesNotLeak(t *testing.T) {
+	user := leakyUser()
+	assertNoLeaks(t, NewProfile(user, 0))
+	assertNoLeaks(t, NewProfile(user, user.ID))
+	assertNoLeaks(t, NewProfiles([]*entity.User{user}, 2))
+}
+
+func TestAccountOnlyAddsOwnEmail(t *testing.T) {
+	assertNoLeaks(t, NewAccount(leakyUser()), "email", "jane@example.com")
+}
+
+func TestAuthorDoesNotLeak(t *testing.T) {
+	assertNoLeaks(t, NewAuthor(leakyUser()))
+}
+
+func TestPostDoesNotLeakAuthor(t *testing.T) {
+	post := &entity.Post{ID: 1, Name: "Team", AuthorID: 1, Author: *leakyUser(), Status: entity.PostStatusOpen}
+
+	response := NewPost(post)
+	if response.Author == nil || response.Author.Username != "jane" {
+		t.Fatalf("author = %+v, want jane", response.Author)
+	}
+	assertNoLeaks(t, response)
+	assertNoLeaks(t, NewPosts([]*entity.Post{post}))
+}
+
+func TestCommentDoesNotLeakAuthor(t *testing.T) {
+	reply := &entity.Comment{ID: 2, PostID: 1, Author: *leakyUser(), Body: "reply"}
+	comment := &entity.Comment{ID: 1, PostID: 1, Author: *leakyUser(), Body: "root", Replies: []*entity.Comment{reply}}
+
+	response := NewComment(comment)
+	if response.Author == nil || len(response.Replies) != 1 || response.Replies[0].Author == nil {
+		t.Fatalf("comment = %+v, want authors on the comment and its reply", response)
+	}
+	assertNoLeaks(t, response)
+}
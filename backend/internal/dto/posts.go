@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"time"
+)
+
+type Post struct {
+	ID                int64              `json:"id"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	Name              string             `json:"name"`
+	Description       string             `json:"description"`
+	AuthorID          int64              `json:"authorId"`
+	Author            *Author            `json:"author,omitempty"`
+	Type              string             `json:"type"`
+	Skills            []string           `json:"skills"`
+	SkillRequirements []entity.PostSkill `json:"skillRequirements"`
+	OpenSlots         int                `json:"openSlots"`
+	Roles             []string           `json:"roles"`
+	WorkMode          string             `json:"workMode"`
+	City              string             `json:"city"`
+	Commitment        string             `json:"commitment"`
+	Stage             string             `json:"stage"`
+	RepoURL           string             `json:"repoUrl"`
+	Status            string             `json:"status"`
+	ExpiresAt         *time.Time         `json:"expiresAt"`
+	IsSaved           bool               `json:"isSaved"`
+	SaveCount         *int64             `json:"saveCount,omitempty"`
+	Stats             *entity.PostStats  `json:"stats,omitempty"`
+	CommentCount      int64              `json:"commentCount"`
+}
+
+// NewPost maps post to its response. The author and stats are only
+// included when they were loaded.
+func NewPost(post *entity.Post) Post {
+	response := Post{
+		ID:                post.ID,
+		CreatedAt:         post.CreatedAt,
+		Name:              post.Name,
+		Description:       post.Description,
+		AuthorID:          post.AuthorID,
+		Type:              post.Type,
+		Skills:            post.Skills,
+		SkillRequirements: post.SkillRequirements,
+		OpenSlots:         post.OpenSlots,
+		Roles:             post.Roles,
+		WorkMode:          post.WorkMode,
+		City:              post.City,
+		Commitment:        post.Commitment,
+		Stage:             post.Stage,
+		RepoURL:           post.RepoURL,
+		Status:            post.Status,
+		ExpiresAt:         post.ExpiresAt,
+		IsSaved:           post.IsSaved,
+		SaveCount:         post.SaveCount,
+		Stats:             post.Stats,
+		CommentCount:      post.CommentCount,
+	}
+	if post.Author.ID != 0 {
+		author := NewAuthor(&post.Author)
+		response.Author = &author
+	}
+	return response
+}
+
+func NewPosts(posts []*entity.Post) []Post {
+	responses := make([]Post, 0, len(posts))
+	for _, post := range posts {
+		responses = append(responses, NewPost(post))
+	}
+	return responses
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"DiplomaV2/backend/internal/entity"
+)
+
+// Profile is a user as shown on their profile, without the email and
+// account details. Fields the viewer isn't allowed to see are left out.
+type Profile struct {
+	ID               int64              `json:"id"`
+	Name             string             `json:"name"`
+	Surname          string             `json:"surname"`
+	Username         string             `json:"username"`
+	Telegram         string             `json:"telegram,omitempty"`
+	TelegramVerified bool               `json:"telegramVerified"`
+	Discord          string             `json:"discord,omitempty"`
+	DiscordVerified  bool               `json:"discordVerified"`
+	Skills           []string           `json:"skills,omitempty"`
+	SkillLevels      []entity.UserSkill `json:"skillLevels,omitempty"`
+	ProfileImage     string             `json:"profileImage"`
+}
+
+// NewProfile shapes user for viewerID, 0 for anonymous requests. Every user
+// endpoint goes through it, so the privacy settings apply the same way
+// wherever a profile is shown.
+func NewProfile(user *entity.User, viewerID int64) Profile {
+	profile := Profile{
+		ID:           user.ID,
+		Name:         user.Name,
+		Surname:      user.Surname,
+		Username:     user.Username,
+		ProfileImage: user.ProfileImage,
+	}
+	if entity.Visible(user.Privacy.Telegram, user.ID, viewerID) {
+		profile.Telegram = user.Telegram
+		profile.TelegramVerified = user.TelegramVerified
+	}
+	if entity.Visible(user.Privacy.Discord, user.ID, viewerID) {
+		profile.Discord = user.Discord
+		profile.DiscordVerified = user.DiscordVerified
+	}
+	if entity.Visible(user.Privacy.Skills, user.ID, viewerID) {
+		profile.Skills = user.Skills
+		profile.SkillLevels = user.SkillLevels
+	}
+	return profile
+}
+
+func NewProfiles(users []*entity.User, viewerID int64) []Profile {
+	profiles := make([]Profile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, NewProfile(user, viewerID))
+	}
+	return profiles
+}
+
+// Account is the signed in user's own profile with the email and privacy
+// settings. It is only ever sent to the user it describes.
+type Account struct {
+	Profile
+	Email     string                `json:"email"`
+	Activated bool                  `json:"activated"`
+	Privacy   entity.ProfilePrivacy `json:"privacy"`
+}
+
+func NewAccount(user *entity.User) Account {
+	return Account{
+		Profile:   NewProfile(user, user.ID),
+		Email:     user.Email,
+		Activated: user.Activated,
+		Privacy:   user.Privacy,
+	}
+}
+
+// Author is the compact public profile shown next to a user's content.
+type Author struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	Name         string `json:"name"`
+	ProfileImage string `json:"profileImage"`
+}
+
+func NewAuthor(user *entity.User) Author {
+	return Author{
+		ID:           user.ID,
+		Username:     user.Username,
+		Name:         user.Name,
+		ProfileImage: user.ProfileImage,
+	}
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// Comment is a public question or answer on a post. A reply points at its
+// parent and at the top-level comment of its thread, so the replies of a
+// page of threads are loaded with a single query.
+type Comment struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt time.Time  `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	EditedAt  *time.Time `json:"editedAt"`
+	PostID    int64      `gorm:"not null;index" json:"postId"`
+	Post      Post       `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE;" json:"-"`
+	// AuthorID is nil once the author's account is purged. Their comments
+	// stay, deleted, so the replies under them keep their thread.
+	AuthorID *int64 `gorm:"index" json:"authorId"`
+	Author   User   `gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL;" json:"-"`
+	ParentID *int64 `gorm:"index" json:"parentId"`
+	ThreadID *int64 `gorm:"index" json:"-"`
+	Body     string `gorm:"not null" json:"body"`
+	// DeletedAt isn't a gorm.DeletedAt: a deleted comment is still listed,
+	// without its body and author, so its replies keep their place.
+	DeletedAt *time.Time `gorm:"index" json:"-"`
+	Replies   []*Comment `gorm:"-" json:"-"`
+}
+
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// WrittenBy reports whether userID wrote the comment.
+func (c *Comment) WrittenBy(userID int64) bool {
+	return c.AuthorID != nil && *c.AuthorID == userID
+}
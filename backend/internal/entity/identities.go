@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// UserIdentity links a user to an account at an external OAuth2/OIDC provider.
+type UserIdentity struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt time.Time `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	UserID    int64     `gorm:"not null;index" json:"-"`
+	User      User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_identity_provider_subject" json:"-"`
+	Email     string    `json:"email"`
+}
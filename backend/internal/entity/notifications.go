@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// Only post.matching and post.comment are sent today. Applications and chat
+// don't exist yet; their types are reserved so preferences can already be
+// stored for them.
+const (
+	NotificationApplicationReceived = "application.received"
+	NotificationApplicationDecided  = "application.decided"
+	NotificationNewMatchingPost     = "post.matching"
+	NotificationPostComment         = "post.comment"
+	NotificationChatMention         = "chat.mention"
+)
+
+var NotificationTypes = []string{
+	NotificationApplicationReceived,
+	NotificationApplicationDecided,
+	NotificationNewMatchingPost,
+	NotificationPostComment,
+	NotificationChatMention,
+}
+
+const (
+	ChannelInApp = "in-app"
+	ChannelEmail = "email"
+)
+
+type Notification struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt time.Time  `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	UserID    int64      `gorm:"not null;index" json:"-"`
+	User      User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Type      string     `gorm:"not null" json:"type"`
+	Title     string     `gorm:"not null" json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link"`
+	ReadAt    *time.Time `json:"readAt"`
+}
+
+// NotificationPreference stores a user's choice for one notification type.
+// Types without a row are delivered on every channel.
+type NotificationPreference struct {
+	UserID int64  `gorm:"primaryKey" json:"-"`
+	Type   string `gorm:"primaryKey" json:"type"`
+	InApp  bool   `gorm:"not null" json:"inApp"`
+	Email  bool   `gorm:"not null" json:"email"`
+	User   User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+}
@@ -5,14 +5,141 @@ import (
 	"time"
 )
 
+const (
+	PostStatusDraft    = "draft"
+	PostStatusOpen     = "open"
+	PostStatusClosed   = "closed"
+	PostStatusArchived = "archived"
+)
+
+var PostStatuses = []string{
+	PostStatusDraft,
+	PostStatusOpen,
+	PostStatusClosed,
+	PostStatusArchived,
+}
+
+// PostType is an entry of the post type registry served at /v2/posts/types.
+type PostType struct {
+	Value       string `json:"value"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+var PostTypes = []PostType{
+	{Value: "team finding", Label: "Team Finding", Description: "Looking for a team to join"},
+	{Value: "user finding", Label: "User Finding", Description: "Looking for people to join a team"},
+}
+
+func PostTypeValues() []string {
+	values := make([]string, len(PostTypes))
+	for i, postType := range PostTypes {
+		values[i] = postType.Value
+	}
+	return values
+}
+
+const (
+	WorkModeRemote = "remote"
+	WorkModeOnsite = "onsite"
+	WorkModeHybrid = "hybrid"
+)
+
+var WorkModes = []string{WorkModeRemote, WorkModeOnsite, WorkModeHybrid}
+
+var Commitments = []string{"hobby", "part-time", "full-time"}
+
+var ProjectStages = []string{"idea", "prototype", "mvp", "launched"}
+
+var PostRoles = []string{
+	"backend",
+	"frontend",
+	"fullstack",
+	"mobile",
+	"designer",
+	"devops",
+	"qa",
+	"data",
+	"ml",
+	"product",
+	"other",
+}
+
 type Post struct {
-	ID          int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
-	CreatedAt   time.Time      `gorm:"not null;default:current_timestamp" json:"createdAt"`
-	Name        string         `gorm:"not null" json:"name"`
-	Description string         `json:"description"`
-	AuthorID    int64          `gorm:"not null" json:"authorId"`
-	Author      User           `gorm:"foreignKey:AuthorID;references:ID;constraint:OnDelete:CASCADE;" json:"author"`
-	Type        string         `gorm:"not null" json:"type"`
-	Skills      pq.StringArray `gorm:"type:text[]" json:"skills"`
-	Version     int            `gorm:"not null;default:1" json:"-"`
+	ID                int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt         time.Time      `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	Name              string         `gorm:"not null" json:"name"`
+	Description       string         `json:"description"`
+	AuthorID          int64          `gorm:"not null" json:"authorId"`
+	Author            User           `gorm:"foreignKey:AuthorID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Type              string         `gorm:"not null" json:"type"`
+	Skills            pq.StringArray `gorm:"type:text[]" json:"skills"`
+	SkillRequirements []PostSkill    `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE;" json:"skillRequirements"`
+	OpenSlots         int            `gorm:"not null;default:0" json:"openSlots"`
+	Roles             pq.StringArray `gorm:"type:text[]" json:"roles"`
+	WorkMode          string         `gorm:"not null;default:remote" json:"workMode"`
+	City              string         `json:"city"`
+	Commitment        string         `json:"commitment"`
+	Stage             string         `json:"stage"`
+	RepoURL           string         `json:"repoUrl"`
+	Status            string         `gorm:"not null;default:open;index" json:"status"`
+	PublishedAt       *time.Time     `gorm:"index" json:"publishedAt"`
+	ExpiresAt         *time.Time     `json:"expiresAt"`
+	ExpiryNotifiedAt  *time.Time     `json:"-"`
+	Hidden            bool           `gorm:"not null;default:false" json:"-"`
+	IsSaved           bool           `gorm:"-" json:"isSaved"`
+	SaveCount         *int64         `gorm:"-" json:"saveCount,omitempty"`
+	Stats             *PostStats     `gorm:"-" json:"-"`
+	CommentCount      int64          `gorm:"->;-:migration" json:"-"`
+	Version           int            `gorm:"not null;default:1" json:"-"`
+}
+
+// VisibleTo reports whether viewerID (0 when anonymous) may see the post.
+// Hidden posts and posts whose author is deleted are visible to nobody,
+// drafts only to their author. Author must be loaded; a soft-deleted author
+// is loaded as the zero User.
+func (p *Post) VisibleTo(viewerID int64) bool {
+	if p.Hidden || p.Author.ID == 0 {
+		return false
+	}
+	return p.Status != PostStatusDraft || p.AuthorID == viewerID
+}
+
+// PostStats are the public counters of a post, loaded on request.
+type PostStats struct {
+	Views int64 `json:"views"`
+	Saves int64 `json:"saves"`
+}
+
+// PostView counts the distinct viewers of a post on a day (UTC).
+type PostView struct {
+	PostID int64     `gorm:"primaryKey" json:"-"`
+	Day    time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views  int64     `gorm:"not null;default:0" json:"views"`
+	Post   Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// PostAnalytics is what the author of a post sees about its reach. Posts
+// don't take applications yet, so there is no applications series; it is
+// left out rather than reported as zero until they do.
+type PostAnalytics struct {
+	PostID int64           `json:"postId"`
+	Views  int64           `json:"views"`
+	Saves  int64           `json:"saves"`
+	Days   []PostDayCounts `json:"days"`
+}
+
+type PostDayCounts struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+	Saves int64  `json:"saves"`
+}
+
+// SavedPost is a post bookmarked by a user.
+type SavedPost struct {
+	UserID    int64     `gorm:"primaryKey" json:"-"`
+	PostID    int64     `gorm:"primaryKey;index" json:"postId"`
+	CreatedAt time.Time `gorm:"not null;default:current_timestamp" json:"savedAt"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Post      Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE;" json:"-"`
 }
@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
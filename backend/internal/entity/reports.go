@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+const (
+	ReportReasonSpam       = "spam"
+	ReportReasonOffensive  = "offensive"
+	ReportReasonHarassment = "harassment"
+	ReportReasonMisleading = "misleading"
+	ReportReasonOther      = "other"
+)
+
+var ReportReasons = []string{
+	ReportReasonSpam,
+	ReportReasonOffensive,
+	ReportReasonHarassment,
+	ReportReasonMisleading,
+	ReportReasonOther,
+}
+
+type Report struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt    time.Time  `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	ReporterID   int64      `gorm:"not null;index" json:"reporterId"`
+	Reporter     User       `gorm:"foreignKey:ReporterID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	TargetPostID *int64     `gorm:"index" json:"targetPostId,omitempty"`
+	TargetPost   *Post      `gorm:"foreignKey:TargetPostID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	TargetUserID *int64     `gorm:"index" json:"targetUserId,omitempty"`
+	TargetUser   *User      `gorm:"foreignKey:TargetUserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Reason       string     `gorm:"not null" json:"reason"`
+	Details      string     `json:"details"`
+	Status       string     `gorm:"not null;default:pending;index" json:"status"`
+	ResolvedByID *int64     `json:"resolvedById,omitempty"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"`
+}
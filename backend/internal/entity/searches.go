@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	SearchFrequencyInstant = "instant"
+	SearchFrequencyDaily   = "daily"
+	SearchFrequencyWeekly  = "weekly"
+)
+
+var SearchFrequencies = []string{
+	SearchFrequencyInstant,
+	SearchFrequencyDaily,
+	SearchFrequencyWeekly,
+}
+
+// SavedSearch is a named set of GetFilteredPosts parameters. New posts
+// matching it are mailed to the owner as a digest at the chosen frequency.
+type SavedSearch struct {
+	ID           int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt    time.Time      `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	UserID       int64          `gorm:"not null;index" json:"-"`
+	User         User           `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Name         string         `gorm:"not null" json:"name"`
+	PostName     string         `json:"postName"`
+	Description  string         `json:"description"`
+	Type         string         `json:"type"`
+	Skills       pq.StringArray `gorm:"type:text[]" json:"skills"`
+	SkillLevels  pq.StringArray `gorm:"type:text[]" json:"skillLevels"`
+	Roles        pq.StringArray `gorm:"type:text[]" json:"roles"`
+	WorkMode     string         `json:"workMode"`
+	City         string         `json:"city"`
+	Commitment   string         `json:"commitment"`
+	Stage        string         `json:"stage"`
+	OpenSlots    int            `gorm:"not null;default:0" json:"openSlots"`
+	Frequency    string         `gorm:"not null;default:daily" json:"frequency"`
+	EmailEnabled bool           `gorm:"not null;default:true" json:"emailEnabled"`
+	LastRunAt    time.Time      `gorm:"not null;default:current_timestamp" json:"lastRunAt"`
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	SkillCategoryLanguage  = "language"
+	SkillCategoryFramework = "framework"
+	SkillCategoryDatabase  = "database"
+	SkillCategoryTool      = "tool"
+	SkillCategoryDesign    = "design"
+	SkillCategoryOther     = "other"
+)
+
+var SkillCategories = []string{
+	SkillCategoryLanguage,
+	SkillCategoryFramework,
+	SkillCategoryDatabase,
+	SkillCategoryTool,
+	SkillCategoryDesign,
+	SkillCategoryOther,
+}
+
+// Skill is the canonical spelling of a skill. Users and posts keep storing
+// skill names in their text arrays; Aliases are lowercase alternative
+// spellings that are rewritten to Name on input.
+type Skill struct {
+	ID        int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt time.Time      `gorm:"not null;default:current_timestamp" json:"-"`
+	Name      string         `gorm:"type:citext;unique;not null" json:"name"`
+	Category  string         `gorm:"not null;default:other" json:"category"`
+	Aliases   pq.StringArray `gorm:"type:text[]" json:"aliases"`
+	PostCount int64          `gorm:"->;-:migration" json:"postCount"`
+	UserCount int64          `gorm:"->;-:migration" json:"userCount"`
+}
+
+const (
+	SkillLevelBeginner     = "beginner"
+	SkillLevelIntermediate = "intermediate"
+	SkillLevelAdvanced     = "advanced"
+	SkillLevelExpert       = "expert"
+)
+
+// SkillLevels is ordered from the lowest to the highest level.
+var SkillLevels = []string{
+	SkillLevelBeginner,
+	SkillLevelIntermediate,
+	SkillLevelAdvanced,
+	SkillLevelExpert,
+}
+
+// UserSkill carries the proficiency behind an entry of User.Skills.
+type UserSkill struct {
+	UserID int64  `gorm:"primaryKey" json:"-"`
+	Skill  string `gorm:"primaryKey;type:citext" json:"skill"`
+	Level  string `gorm:"not null;default:beginner" json:"level"`
+	Years  int    `gorm:"not null;default:0" json:"years"`
+}
+
+// PostSkill describes how a post needs one of its skills. An empty MinLevel
+// accepts any level.
+type PostSkill struct {
+	PostID   int64  `gorm:"primaryKey" json:"-"`
+	Skill    string `gorm:"primaryKey;type:citext" json:"skill"`
+	Required bool   `gorm:"not null;default:true" json:"required"`
+	MinLevel string `json:"minLevel"`
+}
+
+// SkillLevelFilter is a "skill:level" pair read from a query string.
+type SkillLevelFilter struct {
+	Skill string
+	Level string
+}
+
+// SkillLevelsAtLeast returns level and every level above it.
+func SkillLevelsAtLeast(level string) []string {
+	for i, l := range SkillLevels {
+		if l == level {
+			return SkillLevels[i:]
+		}
+	}
+	return nil
+}
+
+// SkillLevelsAtMost returns level and every level below it.
+func SkillLevelsAtMost(level string) []string {
+	for i, l := range SkillLevels {
+		if l == level {
+			return SkillLevels[:i+1]
+		}
+	}
+	return nil
+}
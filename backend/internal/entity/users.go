@@ -4,30 +4,143 @@ import (
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"time"
 )
 
+// User is sent to clients through the dto package. The json tags keep
+// secrets and the email out should one be serialized directly anyway.
 type User struct {
-	ID           int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
-	CreatedAt    time.Time      `gorm:"not null;default:current_timestamp" json:"created_at"`
-	Name         string         `gorm:"not null" json:"name"`
-	Surname      string         `json:"surname"`
-	Username     string         `gorm:"unique;not null" json:"username"`
-	Telegram     string         `json:"telegram"`
-	Discord      string         `json:"discord"`
-	Email        string         `gorm:"type:citext;unique;not null" json:"email"`
-	Skills       pq.StringArray `gorm:"type:text[]" json:"skills"`
-	Password     password       `gorm:"embedded;embeddedPrefix:password_" json:"-"`
-	ProfileImage string         `json:"profileImage"`
-	Activated    bool           `gorm:"default:false;not null" json:"activated"`
-	Version      int            `gorm:"not null;default:1" json:"-"`
-	Posts        []Post         `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE;" json:"posts"`
-	Tokens       []Token        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"tokens"`
+	ID        int64     `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt time.Time `gorm:"not null;default:current_timestamp" json:"created_at"`
+	Name      string    `gorm:"not null" json:"name"`
+	Surname   string    `json:"surname"`
+	Username  string    `gorm:"type:citext;unique;not null" json:"username"`
+	Telegram  string    `json:"telegram"`
+	Discord   string    `json:"discord"`
+	// The verified flags are cleared whenever the handle changes.
+	TelegramVerified bool           `gorm:"not null;default:false" json:"telegramVerified"`
+	DiscordVerified  bool           `gorm:"not null;default:false" json:"discordVerified"`
+	Email            string         `gorm:"type:citext;unique;not null" json:"-"`
+	PendingEmail     string         `gorm:"type:citext" json:"-"`
+	Skills           pq.StringArray `gorm:"type:text[]" json:"skills"`
+	SkillLevels      []UserSkill    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"skillLevels"`
+	Password         password       `gorm:"embedded;embeddedPrefix:password_" json:"-"`
+	ProfileImage     string         `json:"profileImage"`
+	Privacy          ProfilePrivacy `gorm:"embedded;embeddedPrefix:privacy_" json:"privacy"`
+	Activated        bool           `gorm:"default:false;not null" json:"activated"`
+	Role             string         `gorm:"not null;default:user" json:"-"`
+	FailedLogins     int            `gorm:"not null;default:0" json:"-"`
+	LockedUntil      *time.Time     `json:"-"`
+	TOTPSecret       string         `json:"-"`
+	TOTPEnabled      bool           `gorm:"not null;default:false" json:"-"`
+	TOTPLastStep     int64          `gorm:"not null;default:0" json:"-"`
+	Version          int            `gorm:"not null;default:1" json:"-"`
+	// SessionsRevokedAt invalidates every session token issued up to then.
+	// It is set when the account is deleted and survives a restore.
+	SessionsRevokedAt *time.Time     `json:"-"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	Posts             []Post         `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE;" json:"-"`
+	Tokens            []Token        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+const (
+	VisibilityPublic  = "public"
+	VisibilityMembers = "members"
+	VisibilityTeam    = "team"
+	VisibilityHidden  = "hidden"
+)
+
+var Visibilities = []string{
+	VisibilityPublic,
+	VisibilityMembers,
+	VisibilityTeam,
+	VisibilityHidden,
+}
+
+// ProfilePrivacy says who sees the contact details and skills on a profile.
+// There are no teams yet, so a field visible to team members is only shown
+// to its owner for now, like a hidden one.
+type ProfilePrivacy struct {
+	Telegram string `gorm:"not null;default:members" json:"telegram"`
+	Discord  string `gorm:"not null;default:members" json:"discord"`
+	Skills   string `gorm:"not null;default:members" json:"skills"`
+	// HiddenFromSearch keeps the profile out of the user search used by HR.
+	// The profile itself stays reachable by its link.
+	HiddenFromSearch bool `gorm:"not null;default:false" json:"hiddenFromSearch"`
+}
+
+// Visible reports whether a field with the given visibility on ownerID's
+// profile is shown to viewerID. viewerID is 0 for anonymous requests.
+func Visible(visibility string, ownerID, viewerID int64) bool {
+	if viewerID != 0 && viewerID == ownerID {
+		return true
+	}
+	switch visibility {
+	case VisibilityPublic:
+		return true
+	case VisibilityMembers, "":
+		return viewerID != 0
+	default:
+		return false
+	}
+}
+
+// UsernameHistory keeps a user's previous usernames so old profile links
+// redirect to the current one. A previous username stays reserved for its
+// owner.
+type UsernameHistory struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement:true" json:"-"`
+	UserID    int64     `gorm:"not null;index" json:"-"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Username  string    `gorm:"type:citext;not null;index" json:"username"`
+	ChangedAt time.Time `gorm:"not null;default:current_timestamp" json:"changedAt"`
+}
+
+const (
+	PlatformTelegram = "telegram"
+	PlatformDiscord  = "discord"
+)
+
+var Platforms = []string{
+	PlatformTelegram,
+	PlatformDiscord,
+}
+
+// HandleVerification is a pending check that a user owns the handle they
+// entered for a platform. The code isn't secret: the user posts it publicly.
+type HandleVerification struct {
+	UserID    int64     `gorm:"primaryKey" json:"-"`
+	Platform  string    `gorm:"primaryKey" json:"platform"`
+	Handle    string    `gorm:"not null" json:"handle"`
+	Code      string    `gorm:"not null" json:"code"`
+	ExpiresAt time.Time `gorm:"not null" json:"expiresAt"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// Handle returns the user's handle on platform.
+func (u *User) Handle(platform string) string {
+	switch platform {
+	case PlatformTelegram:
+		return u.Telegram
+	case PlatformDiscord:
+		return u.Discord
+	}
+	return ""
 }
 
 type Token struct {
 	ID        uint      `gorm:"primaryKey"`
-	Plaintext string    `gorm:"-"`
+	Plaintext string    `gorm:"-" json:"-"`
 	Hash      []byte    `gorm:"not null" json:"-"`
 	UserID    int64     `gorm:"foreignKey:user_id;not null" json:"-"`
 	Expiry    time.Time `gorm:"not null" json:"expiry"`
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// Webhook events use the names of the internal events they mirror. User
+// events are only available to admins.
+const (
+	WebhookPostCreated    = "post.created"
+	WebhookPostUpdated    = "post.updated"
+	WebhookPostDeleted    = "post.deleted"
+	WebhookUserRegistered = "user.registered"
+	WebhookUserActivated  = "user.activated"
+)
+
+var WebhookEvents = []string{
+	WebhookPostCreated,
+	WebhookPostUpdated,
+	WebhookPostDeleted,
+	WebhookUserRegistered,
+	WebhookUserActivated,
+}
+
+var WebhookUserEvents = []string{
+	WebhookUserRegistered,
+	WebhookUserActivated,
+}
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is a URL that receives signed POST requests for the events it is
+// subscribed to. The secret is only shown when the webhook is created.
+type Webhook struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt   time.Time      `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	UserID      int64          `gorm:"not null;index" json:"-"`
+	User        User           `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	URL         string         `gorm:"not null" json:"url"`
+	Secret      string         `gorm:"not null" json:"-"`
+	Events      pq.StringArray `gorm:"type:text[];not null" json:"events"`
+	Description string         `json:"description"`
+	Active      bool           `gorm:"not null;default:true" json:"active"`
+}
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its
+// last attempt. Pending deliveries are retried at NextAttemptAt.
+type WebhookDelivery struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement:true" json:"id"`
+	CreatedAt     time.Time  `gorm:"not null;default:current_timestamp" json:"createdAt"`
+	WebhookID     int64      `gorm:"not null;index" json:"webhookId"`
+	Webhook       Webhook    `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE;" json:"-"`
+	Event         string     `gorm:"not null" json:"event"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"not null;default:pending;index" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseCode  int        `json:"responseCode"`
+	ResponseBody  string     `json:"responseBody"`
+	Error         string     `json:"error"`
+	DurationMs    int64      `json:"durationMs"`
+	NextAttemptAt *time.Time `gorm:"index" json:"nextAttemptAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+	// RedeliveryOf points at the delivery this one was copied from.
+	RedeliveryOf *int64 `json:"redeliveryOf"`
+}
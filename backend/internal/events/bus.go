@@ -0,0 +1,88 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+type subscriber struct {
+	name   string
+	async  bool
+	handle func(Event) error
+}
+
+// Bus delivers published events to the subscribers of their name.
+// Synchronous subscribers run on the publishing goroutine in the order they
+// subscribed; asynchronous ones each run on their own goroutine. A failing
+// or panicking subscriber is logged and doesn't affect the others or the
+// publisher.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+	logger      echo.Logger
+}
+
+func New(logger echo.Logger) *Bus {
+	return &Bus{
+		subscribers: make(map[string][]subscriber),
+		logger:      logger,
+	}
+}
+
+// Subscribe registers a synchronous subscriber for events of type T.
+func Subscribe[T Event](b *Bus, handler func(T) error) {
+	subscribe(b, false, handler)
+}
+
+// SubscribeAsync registers a subscriber for events of type T that runs
+// outside the publishing request, e.g. for sending emails.
+func SubscribeAsync[T Event](b *Bus, handler func(T) error) {
+	subscribe(b, true, handler)
+}
+
+func subscribe[T Event](b *Bus, async bool, handler func(T) error) {
+	var zero T
+	name := zero.EventName()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[name] = append(b.subscribers[name], subscriber{
+		name:  name,
+		async: async,
+		handle: func(event Event) error {
+			typed, ok := event.(T)
+			if !ok {
+				return fmt.Errorf("unexpected payload %T", event)
+			}
+			return handler(typed)
+		},
+	})
+}
+
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers[event.EventName()]
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		if s.async {
+			go b.deliver(s, event)
+			continue
+		}
+		b.deliver(s, event)
+	}
+}
+
+func (b *Bus) deliver(s subscriber, event Event) {
+	defer func() {
+		if err := recover(); err != nil {
+			b.logger.Errorf("subscriber of %s panicked: %v", s.name, err)
+		}
+	}()
+
+	if err := s.handle(event); err != nil {
+		b.logger.Errorf("subscriber of %s failed: %v", s.name, err)
+	}
+}
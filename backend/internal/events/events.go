@@ -0,0 +1,108 @@
+package events
+
+import (
+	"DiplomaV2/backend/internal/entity"
+)
+
+const (
+	UserRegisteredEvent         = "user.registered"
+	UserActivatedEvent          = "user.activated"
+	ActivationRequestedEvent    = "user.activation_requested"
+	PasswordResetRequestedEvent = "user.password_reset_requested"
+	PasswordChangedEvent        = "user.password_changed"
+	EmailChangeRequestedEvent   = "user.email_change_requested"
+	EmailChangeAttemptedEvent   = "user.email_change_attempted"
+	RegistrationAttemptedEvent  = "user.registration_attempted"
+	PostCreatedEvent            = "post.created"
+	PostUpdatedEvent            = "post.updated"
+	PostDeletedEvent            = "post.deleted"
+	CommentCreatedEvent         = "comment.created"
+)
+
+// Event is anything published on the bus. Subscribers are matched by name.
+type Event interface {
+	EventName() string
+}
+
+// UserRegistered carries the new user's activation token, so the welcome
+// email can include the activation link.
+type UserRegistered struct {
+	User  entity.User
+	Token entity.Token
+}
+
+type UserActivated struct {
+	User entity.User
+}
+
+// ActivationRequested is published when a new activation token replaces
+// the previous ones.
+type ActivationRequested struct {
+	User  entity.User
+	Token entity.Token
+}
+
+type PasswordResetRequested struct {
+	User  entity.User
+	Token string
+}
+
+// PasswordChanged is published both for a change from the settings page
+// and for a reset through the forgot password flow.
+type PasswordChanged struct {
+	User  entity.User
+	Reset bool
+}
+
+type EmailChangeRequested struct {
+	User     entity.User
+	NewEmail string
+	Token    string
+}
+
+// EmailChangeAttempted is published when User asks to change their email to
+// the one of Owner's account. Owner is told instead of a confirmation being
+// sent.
+type EmailChangeAttempted struct {
+	User  entity.User
+	Owner entity.User
+}
+
+// RegistrationAttempted is published when someone registers with the email
+// of an existing account. Its owner is told instead of the registrant.
+type RegistrationAttempted struct {
+	User entity.User
+}
+
+// The post events carry a snapshot of the post taken when it was published.
+type PostCreated struct {
+	Post entity.Post
+}
+
+type PostUpdated struct {
+	Post entity.Post
+}
+
+type PostDeleted struct {
+	Post entity.Post
+}
+
+// CommentCreated carries the commented post, so subscribers know its
+// author without loading it again.
+type CommentCreated struct {
+	Comment entity.Comment
+	Post    entity.Post
+}
+
+func (UserRegistered) EventName() string         { return UserRegisteredEvent }
+func (UserActivated) EventName() string          { return UserActivatedEvent }
+func (ActivationRequested) EventName() string    { return ActivationRequestedEvent }
+func (PasswordResetRequested) EventName() string { return PasswordResetRequestedEvent }
+func (PasswordChanged) EventName() string        { return PasswordChangedEvent }
+func (EmailChangeRequested) EventName() string   { return EmailChangeRequestedEvent }
+func (EmailChangeAttempted) EventName() string   { return EmailChangeAttemptedEvent }
+func (RegistrationAttempted) EventName() string  { return RegistrationAttemptedEvent }
+func (PostCreated) EventName() string            { return PostCreatedEvent }
+func (PostUpdated) EventName() string            { return PostUpdatedEvent }
+func (PostDeleted) EventName() string            { return PostDeletedEvent }
+func (CommentCreated) EventName() string         { return CommentCreatedEvent }
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"DiplomaV2/backend/internal/entity"
 	"DiplomaV2/backend/internal/validator"
 	"cloud.google.com/go/storage"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func ReadString(qs url.Values, key string, defaultValue string) string {
@@ -29,6 +31,21 @@ func ReadCSV(qs url.Values, key string, defaultValue []string) []string {
 	return strings.Split(csv, ",")
 }
 
+// ReadSkillLevels parses "golang:advanced,python:beginner" into level filters.
+func ReadSkillLevels(qs url.Values, key string, v *validator.Validator) []entity.SkillLevelFilter {
+	var filters []entity.SkillLevelFilter
+	for _, pair := range ReadCSV(qs, key, []string{}) {
+		skill, level, ok := strings.Cut(pair, ":")
+		skill = strings.TrimSpace(skill)
+		if !ok || skill == "" || !validator.PermittedValue(level, entity.SkillLevels...) {
+			v.AddError(key, "must be a list of skill:level pairs")
+			return nil
+		}
+		filters = append(filters, entity.SkillLevelFilter{Skill: skill, Level: level})
+	}
+	return filters
+}
+
 func ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
 	s := qs.Get(key)
 	if s == "" {
@@ -42,6 +59,25 @@ func ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator
 	return i
 }
 
+// HumanDuration renders a token lifetime for email copy, e.g. "3 days" or "45 minutes".
+func HumanDuration(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d/time.Minute), "minute")
+	}
+}
+
 func UploadFileToGCS(ctx context.Context, client *storage.Client, bucketName, objectName string, src io.Reader) error {
 	wc := client.Bucket(bucketName).Object(objectName).NewWriter(ctx)
 	if _, err := io.Copy(wc, src); err != nil {
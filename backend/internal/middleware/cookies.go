@@ -0,0 +1,42 @@
+package middleware2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AuthCookieName       = "jwt"
+	MFAPendingCookieName = "mfa_pending"
+	mfaPendingCookiePath = "/v2/users/login"
+)
+
+func SetAuthCookie(c echo.Context, token string) {
+	c.SetCookie(newCookie(AuthCookieName, token, "/", time.Now().Add(24*time.Hour)))
+}
+
+func ClearAuthCookie(c echo.Context) {
+	c.SetCookie(newCookie(AuthCookieName, "", "/", time.Unix(0, 0)))
+}
+
+func SetMFAPendingCookie(c echo.Context, token string) {
+	c.SetCookie(newCookie(MFAPendingCookieName, token, mfaPendingCookiePath, time.Now().Add(5*time.Minute)))
+}
+
+func ClearMFAPendingCookie(c echo.Context) {
+	c.SetCookie(newCookie(MFAPendingCookieName, "", mfaPendingCookiePath, time.Unix(0, 0)))
+}
+
+func newCookie(name, value, path string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Expires:  expires,
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	}
+}
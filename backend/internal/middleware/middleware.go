@@ -1,6 +1,7 @@
 package middleware2
 
 import (
+	"DiplomaV2/backend/internal/entity"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,6 +13,24 @@ import (
 
 var JWTSecretKey = []byte(os.Getenv("JWT_SECRET"))
 
+// SessionValidator reports whether a session token issued to userID at
+// issuedAt is still valid, e.g. because the account was deleted since. The
+// server sets it at startup; while it is nil only the signature is checked.
+var SessionValidator func(userID int64, issuedAt time.Time) (bool, error)
+
+// RoleLookup returns the current role of userID. AdminMiddleware uses it so
+// a demoted admin loses access before their session token expires. The
+// server sets it at startup; while it is nil the role claim is trusted.
+var RoleLookup func(userID int64) (string, error)
+
+func sessionValid(claims jwt.MapClaims, userID int64) (bool, error) {
+	if SessionValidator == nil {
+		return true, nil
+	}
+	iat, _ := claims["iat"].(float64)
+	return SessionValidator(userID, time.Unix(int64(iat), 0))
+}
+
 func LoginMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		cookie, err := c.Cookie("jwt")
@@ -32,6 +51,12 @@ func LoginMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+			// Tokens issued for other purposes (e.g. a pending two-factor login)
+			// carry a different audience and must not grant access.
+			if !claims.VerifyAudience("TeamFinder", true) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
+			}
+
 			if float64(time.Now().Unix()) > claims["exp"].(float64) {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Token has expired"})
 			}
@@ -41,10 +66,78 @@ func LoginMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token subject"})
 			}
 
+			valid, err := sessionValid(claims, int64(userID))
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Failed to check session"})
+			}
+			if !valid {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Session has been revoked"})
+			}
+
+			role, _ := claims["role"].(string)
+
 			c.Set("userID", int64(userID))
+			c.Set("userRole", role)
 			return next(c)
 		} else {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"message": "Invalid token"})
 		}
 	}
 }
+
+// AdminMiddleware must be chained after LoginMiddleware. The role is read
+// again from the database, the one in the token may be outdated.
+func AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		role, _ := c.Get("userRole").(string)
+		if RoleLookup != nil {
+			userID, _ := c.Get("userID").(int64)
+			current, err := RoleLookup(userID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"message": "Failed to check role"})
+			}
+			role = current
+			c.Set("userRole", role)
+		}
+		if role != entity.RoleAdmin {
+			return c.JSON(http.StatusForbidden, map[string]string{"message": "Admin access required"})
+		}
+		return next(c)
+	}
+}
+
+// OptionalLoginMiddleware sets userID and userRole when the request carries a
+// valid session and lets anonymous requests through unchanged.
+func OptionalLoginMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cookie, err := c.Cookie("jwt")
+		if err != nil {
+			return next(c)
+		}
+
+		token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method")
+			}
+			return JWTSecretKey, nil
+		})
+		if err != nil || !token.Valid {
+			return next(c)
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || !claims.VerifyAudience("TeamFinder", true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+			return next(c)
+		}
+
+		if userID, ok := claims["sub"].(float64); ok {
+			if valid, err := sessionValid(claims, int64(userID)); err != nil || !valid {
+				return next(c)
+			}
+			role, _ := claims["role"].(string)
+			c.Set("userID", int64(userID))
+			c.Set("userRole", role)
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware2
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveAdmin(t *testing.T, tokenRole string) int {
+	t.Helper()
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v2/moderation/reports", nil), rec)
+	c.Set("userID", int64(1))
+	c.Set("userRole", tokenRole)
+
+	handler := AdminMiddleware(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code
+}
+
+func TestAdminMiddlewareChecksCurrentRole(t *testing.T) {
+	roles := map[int64]string{1: entity.RoleUser}
+	RoleLookup = func(userID int64) (string, error) { return roles[userID], nil }
+	t.Cleanup(func() { RoleLookup = nil })
+
+	if code := serveAdmin(t, entity.RoleAdmin); code != http.StatusForbidden {
+		t.Errorf("demoted admin: status = %d, want 403", code)
+	}
+
+	roles[1] = entity.RoleAdmin
+	if code := serveAdmin(t, entity.RoleUser); code != http.StatusOK {
+		t.Errorf("promoted user: status = %d, want 200", code)
+	}
+}
@@ -0,0 +1,33 @@
+package middleware2
+
+import (
+	"DiplomaV2/backend/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimitByIP throttles requests per client IP. The prefix keeps the buckets
+// of different endpoints apart when they share a limiter.
+func RateLimitByIP(limiter ratelimit.Limiter, prefix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			allowed, wait, err := limiter.Allow(prefix + ":ip:" + c.RealIP())
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Rate limiter unavailable"})
+			}
+			if !allowed {
+				return TooManyRequests(c, wait)
+			}
+			return next(c)
+		}
+	}
+}
+
+func TooManyRequests(c echo.Context, wait time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many requests, try again later"})
+}
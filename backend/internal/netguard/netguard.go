@@ -0,0 +1,131 @@
+// Package netguard keeps outgoing requests to user-supplied URLs, such as
+// webhook deliveries, away from the server's own network.
+package netguard
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// blockedRanges are the special-purpose ranges net.IP has no predicate for.
+var blockedRanges = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which can reach IPv4 private ranges
+)
+
+// IsPublic reports whether ip may be connected to on behalf of a user:
+// loopback, private, link-local, unspecified, multicast and other
+// special-purpose addresses are not.
+func IsPublic(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, blocked := range blockedRanges {
+		if blocked.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublicHost reports whether host may be public without resolving it: IP
+// literals must be public and localhost names are rejected. Names are
+// checked by CheckURL and again when connecting.
+func IsPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublic(ip)
+	}
+	return true
+}
+
+// CheckURL resolves the host of rawURL and fails with ErrForbiddenAddress if
+// any of its addresses isn't public.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if !IsPublicHost(host) {
+		return ErrForbiddenAddress
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer.Control that refuses connections to addresses
+// that aren't public. It runs after DNS resolution, so a name that resolved
+// to a public address when it was checked can't be rebound to an internal one.
+func Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(net.ParseIP(host)) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that only connects to public addresses,
+// ignores proxy settings and doesn't follow redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: Control,
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: NoRedirects,
+	}
+}
+
+// NoRedirects makes a client return redirect responses as they are.
+func NoRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
@@ -0,0 +1,118 @@
+package netguard
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	tests := []struct {
+		host   string
+		public bool
+	}{
+		{"example.com", true},
+		{"localhost", false},
+		{"LOCALHOST.", false},
+		{"api.localhost", false},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"93.184.216.34", true},
+	}
+	for _, tt := range tests {
+		if got := IsPublicHost(tt.host); got != tt.public {
+			t.Errorf("IsPublicHost(%q) = %v, want %v", tt.host, got, tt.public)
+		}
+	}
+}
+
+func TestCheckURLRejectsInternalHosts(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://localhost/hook",
+	} {
+		if err := CheckURL(context.Background(), rawURL); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("CheckURL(%q) = %v, want ErrForbiddenAddress", rawURL, err)
+		}
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("err = %v, want ErrForbiddenAddress", err)
+	}
+	if hit {
+		t.Error("the request must not reach the server")
+	}
+}
+
+func TestNoRedirects(t *testing.T) {
+	followed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: NoRedirects}
+	res, err := client.Get(server.URL + "/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound {
+		t.Errorf("status = %d, want 302", res.StatusCode)
+	}
+	if followed {
+		t.Error("the redirect must not be followed")
+	}
+}
@@ -0,0 +1,152 @@
+// Package oauthtest runs a minimal OAuth2 / OIDC provider for tests.
+package oauthtest
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/oauth"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-secret"
+)
+
+// Server issues codes on /authorize, checks the PKCE verifier on /token and
+// returns Claims on /userinfo.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	claims     map[string]interface{}
+	challenges map[string]string
+	tokens     map[string]bool
+}
+
+func NewServer() *Server {
+	s := &Server{
+		challenges: make(map[string]string),
+		tokens:     make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userInfo)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetClaims sets what /userinfo returns for every access token issued.
+func (s *Server) SetClaims(claims map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = claims
+}
+
+// Provider returns the configuration pointing at this server.
+func (s *Server) Provider(redirectURL string) *config.OAuthProvider {
+	return &config.OAuthProvider{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/token",
+		UserInfoURL:  s.URL + "/userinfo",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// Authorize follows authURL like a browser whose user approved the login and
+// returns the callback URL the provider redirects to.
+func (s *Server) Authorize(authURL string) (*url.URL, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return resp.Location()
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != ClientID || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomHex()
+	s.mu.Lock()
+	s.challenges[code] = q.Get("code_challenge")
+	s.mu.Unlock()
+
+	callback, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	params := callback.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	callback.RawQuery = params.Encode()
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_secret") != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	challenge, ok := s.challenges[code]
+	delete(s.challenges, code)
+	s.mu.Unlock()
+
+	if !ok || oauth.Challenge(r.PostForm.Get("code_verifier")) != challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken := randomHex()
+	s.mu.Lock()
+	s.tokens[accessToken] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": accessToken, "token_type": "Bearer"})
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.tokens[accessToken] {
+		http.Error(w, "invalid_token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.claims)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func randomHex() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	limit     int
+	window    time.Duration
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter(limit int, window time.Duration) Limiter {
+	return &memoryLimiter{
+		buckets:   make(map[string]*bucket),
+		limit:     limit,
+		window:    window,
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *memoryLimiter) Allow(key string) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(m.limit), lastSeen: now}
+		m.buckets[key] = b
+	}
+
+	tokens, allowed, wait := refill(b.tokens, now.Sub(b.lastSeen), m.limit, m.window)
+	b.tokens = tokens
+	b.lastSeen = now
+	return allowed, wait, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again.
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.window {
+		return
+	}
+	for key, b := range m.buckets {
+		if now.Sub(b.lastSeen) >= m.window {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
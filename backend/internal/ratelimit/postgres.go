@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// postgresLimiter keeps the buckets in the rate_limit_buckets table so that
+// several API instances share the same limits.
+type postgresLimiter struct {
+	DB     database.Database
+	limit  int
+	window time.Duration
+}
+
+func NewPostgresLimiter(db database.Database, limit int, window time.Duration) Limiter {
+	return &postgresLimiter{
+		DB:     db,
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (p *postgresLimiter) Allow(key string) (bool, time.Duration, error) {
+	var (
+		allowed bool
+		wait    time.Duration
+	)
+
+	err := p.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&entity.RateLimitBucket{Key: key, Tokens: float64(p.limit), UpdatedAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var b entity.RateLimitBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).Take(&b).Error
+		if err != nil {
+			return err
+		}
+
+		b.Tokens, allowed, wait = refill(b.Tokens, now.Sub(b.UpdatedAt), p.limit, p.window)
+		b.UpdatedAt = now
+		return tx.Save(&b).Error
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	return allowed, wait, nil
+}
+
+// PurgeIdleBuckets deletes the buckets left untouched for longer than idle.
+// A bucket idle for a whole window is full again, just like a missing one,
+// so idle must be the longest window of the limiters sharing the table.
+func PurgeIdleBuckets(db database.Database, idle time.Duration) error {
+	return db.GetDb().Where("updated_at < ?", time.Now().Add(-idle)).Delete(&entity.RateLimitBucket{}).Error
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"time"
+)
+
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Limiter is a token bucket keyed by an arbitrary string (IP, email, user ID).
+// Every bucket holds up to limit tokens and is refilled at limit tokens per window.
+type Limiter interface {
+	// Allow takes a token for key. When no token is left it reports how long
+	// the caller has to wait before the next one becomes available.
+	Allow(key string) (bool, time.Duration, error)
+}
+
+func New(store string, db database.Database, limit int, window time.Duration) Limiter {
+	if store == StorePostgres {
+		return NewPostgresLimiter(db, limit, window)
+	}
+	return NewMemoryLimiter(limit, window)
+}
+
+// refill returns the bucket content after elapsed time and whether a token was taken.
+func refill(tokens float64, elapsed time.Duration, limit int, window time.Duration) (float64, bool, time.Duration) {
+	rate := float64(limit) / window.Seconds()
+	tokens += elapsed.Seconds() * rate
+	if tokens > float64(limit) {
+		tokens = float64(limit)
+	}
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / rate * float64(time.Second))
+		return tokens, false, wait
+	}
+	return tokens - 1, true, 0
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		wantTokens float64
+		allowed    bool
+		wantWait   time.Duration
+	}{
+		{"full bucket", 5, 0, 4, true, 0},
+		{"last token", 1, 0, 0, true, 0},
+		{"empty bucket", 0, 0, 0, false, 12 * time.Second},
+		{"partly refilled", 0, 6 * time.Second, 0.5, false, 6 * time.Second},
+		{"refilled token", 0, 12 * time.Second, 0, true, 0},
+		{"capped at limit", 2, time.Hour, 4, true, 0},
+	}
+	for _, tt := range tests {
+		// 5 tokens per minute: one every 12 seconds.
+		tokens, allowed, wait := refill(tt.tokens, tt.elapsed, 5, time.Minute)
+		if math.Abs(tokens-tt.wantTokens) > 1e-9 || allowed != tt.allowed || (wait-tt.wantWait).Abs() > time.Millisecond {
+			t.Errorf("%s: refill = %v, %v, %v; want %v, %v, %v", tt.name, tokens, allowed, wait, tt.wantTokens, tt.allowed, tt.wantWait)
+		}
+	}
+}
+
+func TestMemoryLimiterAllow(t *testing.T) {
+	limiter := NewMemoryLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := limiter.Allow("ip:1"); !allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	allowed, wait, _ := limiter.Allow("ip:1")
+	if allowed || wait <= 0 {
+		t.Fatalf("third request: allowed = %v, wait = %v; want a wait", allowed, wait)
+	}
+	if allowed, _, _ := limiter.Allow("ip:2"); !allowed {
+		t.Error("other keys have their own bucket")
+	}
+}
+
+func TestMemoryLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter := NewMemoryLimiter(2, time.Minute).(*memoryLimiter)
+	limiter.Allow("idle")
+	limiter.Allow("busy")
+
+	now := time.Now()
+	limiter.buckets["idle"].lastSeen = now.Add(-2 * time.Minute)
+	limiter.lastSweep = now.Add(-2 * time.Minute)
+	limiter.sweep(now)
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("a bucket idle for a whole window should be dropped")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("a recently used bucket must be kept")
+	}
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"golang.org/x/net/context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Every runs job on a fixed interval until ctx is cancelled. A failing or
+// panicking run is logged and does not stop later runs.
+func Every(ctx context.Context, logger echo.Logger, name string, interval time.Duration, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run(ctx, logger, name, job)
+			}
+		}
+	}()
+}
+
+func run(ctx context.Context, logger echo.Logger, name string, job func(ctx context.Context) error) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Errorf("job %s panicked: %v", name, err)
+		}
+	}()
+
+	if err := job(ctx); err != nil {
+		logger.Errorf("job %s failed: %v", name, err)
+	}
+}
@@ -0,0 +1,61 @@
+package sse
+
+import "sync"
+
+// subscriberBuffer is how many events a slow client may fall behind before
+// new events are dropped for it.
+const subscriberBuffer = 16
+
+// Hub fans events out to the streams open for a key, e.g. a user ID.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[int64]map[chan Event]struct{})}
+}
+
+// Subscribe opens a stream for key. The returned function must be called
+// when the client goes away.
+func (h *Hub) Subscribe(key int64) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[chan Event]struct{})
+	}
+	h.subscribers[key][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[key][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[key], ch)
+		if len(h.subscribers[key]) == 0 {
+			delete(h.subscribers, key)
+		}
+		close(ch)
+	}
+}
+
+// Publish sends event to every stream of key without blocking.
+func (h *Hub) Publish(key int64, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[key] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (h *Hub) Connected(key int64) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[key]) > 0
+}
@@ -0,0 +1,79 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// Event is one server-sent event. Data is sent as JSON.
+type Event struct {
+	ID   string
+	Type string
+	Data interface{}
+}
+
+// Write sends a single event and flushes it to the client.
+func Write(res *echo.Response, event Event) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(res, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
+	if event.Type != "" {
+		if _, err := fmt.Fprintf(res, "event: %s\n", event.Type); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(res, "data: %s\n\n", payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+// Start writes the stream headers. It must be called before Write.
+func Start(c echo.Context) {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+}
+
+// Serve forwards events to the client until it disconnects or the channel
+// is closed, sending a comment line every heartbeat to keep proxies from
+// closing an idle connection.
+func Serve(c echo.Context, events <-chan Event, heartbeat time.Duration) error {
+	res := c.Response()
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := Write(res, event); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
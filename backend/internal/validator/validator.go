@@ -2,11 +2,22 @@ package validator
 
 import (
 	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/netguard"
+	"net/url"
 	"regexp"
+	"strings"
 )
 
 var (
-	EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+	TOTPCodeRX = regexp.MustCompile(`^[0-9]{6}$`)
+	// Usernames are used in profile URLs, so they're limited to URL-safe
+	// characters and start with a letter or digit.
+	UsernameRX = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,29}$`)
+	// Telegram usernames are 5-32 characters and start with a letter.
+	TelegramRX = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{4,31}$`)
+	// Discord usernames are 2-32 lowercase characters without repeated dots.
+	DiscordRX = regexp.MustCompile(`^[a-z0-9_.]{2,32}$`)
+	EmailRX   = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 )
 
 type Validator struct {
@@ -51,9 +62,32 @@ func ValidateEmail(v *Validator, email string) {
 	v.Check(Matches(email, EmailRX), "email", "must be a valid email address")
 }
 
+// reservedUsernames would clash with routes or be mistaken for staff.
+var reservedUsernames = []string{
+	"admin", "administrator", "moderator", "moderation", "root", "system", "support", "help",
+	"api", "v2", "me", "my", "settings", "profile", "login", "logout", "register", "registration",
+	"activate", "posts", "users", "by-username", "teamfinder", "null", "undefined",
+}
+
+func IsReservedUsername(username string) bool {
+	return PermittedValue(strings.ToLower(username), reservedUsernames...)
+}
+
 func ValidateUsername(v *Validator, username string) {
 	v.Check(username != "", "username", "must be provided")
-	v.Check(len(username) <= 500, "username", "must not be more than 500 bytes long")
+	v.Check(len(username) >= 3 && len(username) <= 30, "username", "must be between 3 and 30 characters long")
+	v.Check(Matches(username, UsernameRX), "username", "must only contain letters, digits, underscores and hyphens, and start with a letter or digit")
+	v.Check(!IsReservedUsername(username), "username", "is reserved")
+}
+
+// ValidateTelegram checks a handle without the leading @. It's optional.
+func ValidateTelegram(v *Validator, handle string) {
+	v.Check(handle == "" || Matches(handle, TelegramRX), "telegram", "must be a valid Telegram username")
+}
+
+// ValidateDiscord checks a lowercased handle. It's optional.
+func ValidateDiscord(v *Validator, handle string) {
+	v.Check(handle == "" || (Matches(handle, DiscordRX) && !strings.Contains(handle, "..")), "discord", "must be a valid Discord username")
 }
 
 func ValidatePasswordPlaintext(v *Validator, password string) {
@@ -76,7 +110,140 @@ func ValidateUser(v *Validator, user *entity.User) {
 		panic("missing password hash for user")
 	}
 }
+func ValidateProfilePrivacy(v *Validator, privacy *entity.ProfilePrivacy) {
+	v.Check(PermittedValue(privacy.Telegram, entity.Visibilities...), "telegram", "must be public, members, team or hidden")
+	v.Check(PermittedValue(privacy.Discord, entity.Visibilities...), "discord", "must be public, members, team or hidden")
+	v.Check(PermittedValue(privacy.Skills, entity.Visibilities...), "skills", "must be public, members, team or hidden")
+}
+
+func ValidateTOTPCode(v *Validator, code string) {
+	v.Check(code != "", "code", "must be provided")
+	v.Check(Matches(code, TOTPCodeRX), "code", "must be a 6 digit code")
+}
+
 func ValidateTokenPlaintext(v *Validator, tokenPlaintext string) {
 	v.Check(tokenPlaintext != "", "token", "must be provided")
 	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
 }
+
+func ValidateReport(v *Validator, report *entity.Report) {
+	v.Check(report.Reason != "", "reason", "must be provided")
+	v.Check(PermittedValue(report.Reason, entity.ReportReasons...), "reason", "must be one of the listed report reasons")
+	v.Check(len(report.Details) <= 1000, "details", "must not be more than 1000 bytes long")
+	v.Check(report.Reason != entity.ReportReasonOther || report.Details != "", "details", "must be provided when reason is other")
+}
+
+func ValidateSkill(v *Validator, skill *entity.Skill) {
+	v.Check(skill.Name != "", "name", "must be provided")
+	v.Check(len(skill.Name) <= 50, "name", "must not be more than 50 bytes long")
+	v.Check(PermittedValue(skill.Category, entity.SkillCategories...), "category", "must be one of the listed skill categories")
+	v.Check(len(skill.Aliases) <= 20, "aliases", "must not contain more than 20 entries")
+}
+
+func ValidateSkillLevels(v *Validator, levels []entity.UserSkill) {
+	v.Check(len(levels) <= 50, "skillLevels", "must not contain more than 50 entries")
+	for _, level := range levels {
+		v.Check(level.Skill != "", "skillLevels", "skill must be provided")
+		v.Check(PermittedValue(level.Level, entity.SkillLevels...), "skillLevels", "level must be one of beginner, intermediate, advanced, expert")
+		v.Check(level.Years >= 0 && level.Years <= 60, "skillLevels", "years must be between 0 and 60")
+	}
+}
+
+func ValidateSkillRequirements(v *Validator, requirements []entity.PostSkill) {
+	v.Check(len(requirements) <= 20, "skillRequirements", "must not contain more than 20 entries")
+	for _, requirement := range requirements {
+		v.Check(requirement.Skill != "", "skillRequirements", "skill must be provided")
+		v.Check(requirement.MinLevel == "" || PermittedValue(requirement.MinLevel, entity.SkillLevels...), "skillRequirements", "minLevel must be one of beginner, intermediate, advanced, expert")
+	}
+}
+
+func ValidatePost(v *Validator, post *entity.Post) {
+	v.Check(post.Name != "", "name", "must be provided")
+	v.Check(len(post.Name) <= 150, "name", "must not be more than 150 bytes long")
+	v.Check(post.Description != "", "description", "must be provided")
+	v.Check(len(post.Description) <= 10000, "description", "must not be more than 10000 bytes long")
+	v.Check(post.Type != "", "type", "must be provided")
+	v.Check(PermittedValue(post.Type, entity.PostTypeValues()...), "type", "must be one of the registered post types")
+	v.Check(len(post.Skills) <= 20, "skills", "must not contain more than 20 skills")
+	for _, skill := range post.Skills {
+		v.Check(skill != "" && len(skill) <= 50, "skills", "must only contain skills between 1 and 50 bytes long")
+	}
+
+	ValidateSkillRequirements(v, post.SkillRequirements)
+	ValidatePostDetails(v, post)
+}
+
+func ValidatePostDetails(v *Validator, post *entity.Post) {
+	v.Check(post.OpenSlots >= 0 && post.OpenSlots <= 50, "openSlots", "must be between 0 and 50")
+	v.Check(len(post.Roles) <= 10, "roles", "must not contain more than 10 entries")
+	for _, role := range post.Roles {
+		v.Check(PermittedValue(role, entity.PostRoles...), "roles", "must only contain the listed roles")
+	}
+	v.Check(PermittedValue(post.WorkMode, entity.WorkModes...), "workMode", "must be remote, onsite or hybrid")
+	v.Check(post.WorkMode == entity.WorkModeRemote || post.City != "", "city", "must be provided for onsite and hybrid posts")
+	v.Check(len(post.City) <= 100, "city", "must not be more than 100 bytes long")
+	v.Check(post.Commitment == "" || PermittedValue(post.Commitment, entity.Commitments...), "commitment", "must be hobby, part-time or full-time")
+	v.Check(post.Stage == "" || PermittedValue(post.Stage, entity.ProjectStages...), "stage", "must be idea, prototype, mvp or launched")
+	if post.RepoURL != "" {
+		v.Check(len(post.RepoURL) <= 500, "repoUrl", "must not be more than 500 bytes long")
+		v.Check(IsHTTPURL(post.RepoURL), "repoUrl", "must be a valid http or https URL")
+	}
+}
+
+func IsHTTPURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isPublicURL rejects URLs whose host is a local name or a non-public IP
+// literal. Names are resolved and checked when the webhook is saved.
+func isPublicURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && netguard.IsPublicHost(u.Hostname())
+}
+
+func ValidateSavedSearch(v *Validator, search *entity.SavedSearch) {
+	v.Check(search.Name != "", "name", "must be provided")
+	v.Check(len(search.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(PermittedValue(search.Frequency, entity.SearchFrequencies...), "frequency", "must be instant, daily or weekly")
+	v.Check(search.Type == "" || PermittedValue(search.Type, entity.PostTypeValues()...), "type", "must be one of the registered post types")
+	v.Check(len(search.Skills) <= 20, "skills", "must not contain more than 20 skills")
+	v.Check(len(search.SkillLevels) <= 20, "skillLevels", "must not contain more than 20 entries")
+	for _, pair := range search.SkillLevels {
+		skill, level, ok := strings.Cut(pair, ":")
+		v.Check(ok && skill != "" && PermittedValue(level, entity.SkillLevels...), "skillLevels", "must be a list of skill:level pairs")
+	}
+	for _, role := range search.Roles {
+		v.Check(PermittedValue(role, entity.PostRoles...), "roles", "must only contain the listed roles")
+	}
+	v.Check(search.WorkMode == "" || PermittedValue(search.WorkMode, entity.WorkModes...), "workMode", "must be remote, onsite or hybrid")
+	v.Check(search.Commitment == "" || PermittedValue(search.Commitment, entity.Commitments...), "commitment", "must be hobby, part-time or full-time")
+	v.Check(search.Stage == "" || PermittedValue(search.Stage, entity.ProjectStages...), "stage", "must be idea, prototype, mvp or launched")
+	v.Check(search.OpenSlots >= 0, "openSlots", "must not be negative")
+}
+
+func ValidateNotificationPreferences(v *Validator, preferences []*entity.NotificationPreference) {
+	v.Check(len(preferences) > 0, "preferences", "must be provided")
+	for _, preference := range preferences {
+		v.Check(PermittedValue(preference.Type, entity.NotificationTypes...), "preferences", "type must be one of the notification types")
+	}
+}
+
+func ValidateComment(v *Validator, comment *entity.Comment) {
+	v.Check(strings.TrimSpace(comment.Body) != "", "body", "must be provided")
+	v.Check(len(comment.Body) <= 2000, "body", "must not be more than 2000 bytes long")
+}
+
+func ValidateWebhook(v *Validator, webhook *entity.Webhook) {
+	v.Check(webhook.URL != "", "url", "must be provided")
+	v.Check(len(webhook.URL) <= 500, "url", "must not be more than 500 bytes long")
+	v.Check(webhook.URL == "" || IsHTTPURL(webhook.URL), "url", "must be a valid http or https URL")
+	v.Check(webhook.URL == "" || isPublicURL(webhook.URL), "url", "must not point to a local or private address")
+	v.Check(len(webhook.Events) > 0, "events", "must contain at least one event")
+	for _, event := range webhook.Events {
+		v.Check(PermittedValue(event, entity.WebhookEvents...), "events", "must only contain the listed webhook events")
+	}
+	v.Check(len(webhook.Description) <= 200, "description", "must not be more than 200 bytes long")
+	v.Check(webhook.Secret == "" || len(webhook.Secret) >= 16, "secret", "must be at least 16 bytes long")
+	v.Check(len(webhook.Secret) <= 200, "secret", "must not be more than 200 bytes long")
+}
@@ -0,0 +1,51 @@
+package verify
+
+import (
+	"fmt"
+	"golang.org/x/net/context"
+	"net/http"
+)
+
+// Discord looks for the code among the recent messages of a verification
+// channel on our server, read with a bot token.
+type Discord struct {
+	client    *http.Client
+	token     string
+	channelID string
+	apiURL    string
+}
+
+func NewDiscord(client *http.Client, token, channelID string) *Discord {
+	return &Discord{
+		client:    client,
+		token:     token,
+		channelID: channelID,
+		apiURL:    "https://discord.com/api/v10",
+	}
+}
+
+func (d *Discord) Verify(ctx context.Context, handle, code string) (bool, error) {
+	var messages []struct {
+		Content string `json:"content"`
+		Author  struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+
+	url := fmt.Sprintf("%s/channels/%s/messages?limit=100", d.apiURL, d.channelID)
+	header := http.Header{"Authorization": {"Bot " + d.token}}
+	if err := getJSON(ctx, d.client, url, header, &messages); err != nil {
+		return false, err
+	}
+
+	for _, message := range messages {
+		if matches(message.Author.Username, message.Content, handle, code) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *Discord) Instructions(code string) string {
+	return fmt.Sprintf("Post %s in the #verification channel of the TeamFinder Discord server", code)
+}
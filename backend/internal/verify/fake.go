@@ -0,0 +1,40 @@
+package verify
+
+import (
+	"golang.org/x/net/context"
+	"sync"
+)
+
+// Fake is an in-memory Verifier. Post stands in for the user posting the
+// code on the platform.
+type Fake struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func NewFake() *Fake {
+	return &Fake{messages: make(map[string][]string)}
+}
+
+func (f *Fake) Post(handle, text string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[handle] = append(f.messages[handle], text)
+}
+
+func (f *Fake) Verify(_ context.Context, handle, code string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for author, texts := range f.messages {
+		for _, text := range texts {
+			if matches(author, text, handle, code) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (f *Fake) Instructions(code string) string {
+	return "Post " + code + " to the fake verifier"
+}
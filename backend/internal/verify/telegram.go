@@ -0,0 +1,144 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// telegramPageSize is the most updates getUpdates returns at once.
+	telegramPageSize = 100
+	// telegramMessageTTL matches how long Telegram keeps unconfirmed updates.
+	telegramMessageTTL = 24 * time.Hour
+)
+
+// Telegram looks for the code among the recent messages sent to our bot.
+// Fetched updates are confirmed by advancing the getUpdates offset, so the
+// backlog can't grow past a page and hide new messages; the messages are kept
+// here instead. Only one instance may poll a bot token, as Telegram hands
+// every update to whichever poller confirms it first.
+type Telegram struct {
+	client      *http.Client
+	token       string
+	botUsername string
+	apiURL      string
+
+	mu       sync.Mutex
+	offset   int64
+	messages []telegramMessage
+}
+
+type telegramMessage struct {
+	author string
+	text   string
+	sentAt time.Time
+}
+
+func NewTelegram(client *http.Client, token, botUsername string) *Telegram {
+	return &Telegram{
+		client:      client,
+		token:       token,
+		botUsername: botUsername,
+		apiURL:      "https://api.telegram.org",
+	}
+}
+
+func (t *Telegram) Verify(ctx context.Context, handle, code string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.poll(ctx); err != nil {
+		return false, err
+	}
+
+	for _, message := range t.messages {
+		if matches(message.author, message.text, handle, code) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// poll fetches the updates after the last confirmed one, page by page, and
+// drops messages older than Telegram would have kept them.
+func (t *Telegram) poll(ctx context.Context) error {
+	for {
+		var response struct {
+			OK     bool `json:"ok"`
+			Result []struct {
+				UpdateID int64 `json:"update_id"`
+				Message  *struct {
+					Text string `json:"text"`
+					Date int64  `json:"date"`
+					From struct {
+						Username string `json:"username"`
+					} `json:"from"`
+				} `json:"message"`
+			} `json:"result"`
+		}
+
+		url := fmt.Sprintf("%s/bot%s/getUpdates?offset=%d&limit=%d&allowed_updates=%%5B%%22message%%22%%5D",
+			t.apiURL, t.token, t.offset, telegramPageSize)
+		if err := getJSON(ctx, t.client, url, nil, &response); err != nil {
+			return err
+		}
+		if !response.OK {
+			return fmt.Errorf("telegram: getUpdates failed")
+		}
+
+		for _, update := range response.Result {
+			if update.UpdateID >= t.offset {
+				t.offset = update.UpdateID + 1
+			}
+			if update.Message != nil {
+				t.messages = append(t.messages, telegramMessage{
+					author: update.Message.From.Username,
+					text:   update.Message.Text,
+					sentAt: time.Unix(update.Message.Date, 0),
+				})
+			}
+		}
+		if len(response.Result) < telegramPageSize {
+			break
+		}
+	}
+
+	cutoff := time.Now().Add(-telegramMessageTTL)
+	kept := t.messages[:0]
+	for _, message := range t.messages {
+		if message.sentAt.After(cutoff) {
+			kept = append(kept, message)
+		}
+	}
+	t.messages = kept
+	return nil
+}
+
+func (t *Telegram) Instructions(code string) string {
+	return fmt.Sprintf("Send %s to @%s on Telegram from the account you want to verify", code, t.botUsername)
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, req.URL.Host)
+	}
+	return json.NewDecoder(res.Body).Decode(dst)
+}
@@ -0,0 +1,105 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  struct {
+		Text string `json:"text"`
+		Date int64  `json:"date"`
+		From struct {
+			Username string `json:"username"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+// telegramAPI serves getUpdates the way Telegram does: updates before the
+// offset are forgotten and at most limit are returned.
+type telegramAPI struct {
+	updates []telegramUpdate
+}
+
+func (api *telegramAPI) send(username, text string) {
+	var update telegramUpdate
+	update.UpdateID = int64(len(api.updates) + 1)
+	update.Message.Text = text
+	update.Message.Date = time.Now().Unix()
+	update.Message.From.Username = username
+	api.updates = append(api.updates, update)
+}
+
+func (api *telegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = telegramPageSize
+	}
+
+	kept := api.updates[:0]
+	for _, update := range api.updates {
+		if update.UpdateID >= offset {
+			kept = append(kept, update)
+		}
+	}
+	api.updates = kept
+
+	result := api.updates
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func newTestTelegram(t *testing.T, api *telegramAPI) *Telegram {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	telegram := NewTelegram(server.Client(), "token", "bot")
+	telegram.apiURL = server.URL
+	return telegram
+}
+
+func TestTelegramFindsCodeBehindFullPage(t *testing.T) {
+	api := &telegramAPI{}
+	for i := 0; i < telegramPageSize+20; i++ {
+		api.send("spammer", fmt.Sprintf("spam %d", i))
+	}
+	api.send("jane", "my code is tf-abc")
+	telegram := newTestTelegram(t, api)
+
+	ok, err := telegram.Verify(context.Background(), "@jane", "TF-ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("the code should be found past the first page of updates")
+	}
+	// The last page is confirmed by the next poll.
+	if len(api.updates) >= telegramPageSize {
+		t.Errorf("%d updates left unconfirmed, want less than a page", len(api.updates))
+	}
+}
+
+func TestTelegramKeepsConfirmedMessages(t *testing.T) {
+	api := &telegramAPI{}
+	api.send("jane", "TF-ABC")
+	telegram := newTestTelegram(t, api)
+
+	if ok, err := telegram.Verify(context.Background(), "bob", "TF-XYZ"); err != nil || ok {
+		t.Fatalf("Verify(bob) = %v, %v; want false, nil", ok, err)
+	}
+	// The update was confirmed by the first poll, so only the buffered
+	// message can satisfy this check.
+	if ok, err := telegram.Verify(context.Background(), "jane", "TF-ABC"); err != nil || !ok {
+		t.Fatalf("Verify(jane) = %v, %v; want true, nil", ok, err)
+	}
+}
@@ -0,0 +1,34 @@
+package verify
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"golang.org/x/net/context"
+	"strings"
+)
+
+// Verifier checks that the owner of a handle has posted a verification
+// code on the platform, e.g. by sending it to our bot.
+type Verifier interface {
+	Verify(ctx context.Context, handle, code string) (bool, error)
+	// Instructions tells the user where to post the code.
+	Instructions(code string) string
+}
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateCode returns a short one-time code that is easy to copy into a
+// chat message.
+func GenerateCode() (string, error) {
+	randomBytes := make([]byte, 5)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return "TF-" + encoding.EncodeToString(randomBytes), nil
+}
+
+// matches reports whether a message from author carries code for handle.
+func matches(author, text, handle, code string) bool {
+	return strings.EqualFold(strings.TrimPrefix(author, "@"), strings.TrimPrefix(handle, "@")) &&
+		strings.Contains(strings.ToUpper(text), strings.ToUpper(code))
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type NotificationHandler interface {
+	GetNotifications(c echo.Context) error
+	MarkRead(c echo.Context) error
+	MarkAllRead(c echo.Context) error
+	GetPreferences(c echo.Context) error
+	UpdatePreferences(c echo.Context) error
+	Stream(c echo.Context) error
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/sse"
+	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/notification/repository"
+	"DiplomaV2/backend/notification/usecase"
+	postsFilter "DiplomaV2/backend/post"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamHeartbeat keeps idle notification streams open behind proxies.
+const streamHeartbeat = 30 * time.Second
+
+type notificationHttpHandler struct {
+	notificationUseCase usecase.NotificationUseCase
+}
+
+func (n *notificationHttpHandler) GetNotifications(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	unreadOnly := helpers.ReadString(qs, "unread", "false") == "true"
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 20, v),
+		Sort:         helpers.ReadString(qs, "sort", "-created_at"),
+		SortSafeList: []string{"created_at", "-created_at"},
+	}
+
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	notifications, metadata, err := n.notificationUseCase.GetNotifications(userID, unreadOnly, filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	unread, err := n.notificationUseCase.CountUnread(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type Response struct {
+		Notifications []*entity.Notification `json:"notifications"`
+		Unread        int64                  `json:"unread"`
+		Metadata      postsFilter.Metadata   `json:"metadata"`
+	}
+
+	return c.JSON(http.StatusOK, Response{Notifications: notifications, Unread: unread, Metadata: metadata})
+}
+
+func (n *notificationHttpHandler) MarkRead(c echo.Context) error {
+	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid notification id"})
+	}
+
+	userID := c.Get("userID").(int64)
+
+	if err := n.notificationUseCase.MarkRead(userID, notificationID); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Notification marked as read"})
+}
+
+func (n *notificationHttpHandler) MarkAllRead(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	updated, err := n.notificationUseCase.MarkAllRead(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"updated": updated})
+}
+
+func (n *notificationHttpHandler) GetPreferences(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	preferences, err := n.notificationUseCase.GetPreferences(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"preferences": preferences})
+}
+
+func (n *notificationHttpHandler) UpdatePreferences(c echo.Context) error {
+	var input struct {
+		Preferences []*entity.NotificationPreference `json:"preferences"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	v := validator.New()
+	if validator.ValidateNotificationPreferences(v, input.Preferences); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	userID := c.Get("userID").(int64)
+
+	if err := n.notificationUseCase.UpdatePreferences(userID, input.Preferences); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return n.GetPreferences(c)
+}
+
+// Stream pushes new in-app notifications to the client as server-sent events
+// for as long as the connection stays open.
+func (n *notificationHttpHandler) Stream(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	events, unsubscribe := n.notificationUseCase.Subscribe(userID)
+	defer unsubscribe()
+
+	unread, err := n.notificationUseCase.CountUnread(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	sse.Start(c)
+	if err := sse.Write(c.Response(), sse.Event{Type: "unread", Data: map[string]int64{"unread": unread}}); err != nil {
+		return nil
+	}
+	return sse.Serve(c, events, streamHeartbeat)
+}
+
+func NewNotificationHttpHandler(notificationUseCase usecase.NotificationUseCase) NotificationHandler {
+	return &notificationHttpHandler{
+		notificationUseCase: notificationUseCase,
+	}
+}
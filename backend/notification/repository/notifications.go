@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+)
+
+type NotificationRepository interface {
+	Insert(notification *entity.Notification) error
+	GetForUser(userID int64, unreadOnly bool, filters postsFilter.Filters) ([]*entity.Notification, postsFilter.Metadata, error)
+	CountUnread(userID int64) (int64, error)
+	MarkRead(userID, notificationID int64) error
+	MarkAllRead(userID int64) (int64, error)
+	GetPreferences(userID int64) ([]*entity.NotificationPreference, error)
+	SavePreferences(userID int64, preferences []*entity.NotificationPreference) error
+}
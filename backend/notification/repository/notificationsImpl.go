@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"time"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type notificationRepository struct {
+	DB database.Database
+}
+
+func NewNotificationRepository(db database.Database) NotificationRepository {
+	return &notificationRepository{DB: db}
+}
+
+func (r *notificationRepository) Insert(notification *entity.Notification) error {
+	return r.DB.GetDb().Omit("User").Create(notification).Error
+}
+
+func (r *notificationRepository) GetForUser(userID int64, unreadOnly bool, filters postsFilter.Filters) ([]*entity.Notification, postsFilter.Metadata, error) {
+	var notifications []*entity.Notification
+	query := r.DB.GetDb().Model(&entity.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var totalRecords int64
+	countQuery := *query
+	if err := countQuery.Count(&totalRecords).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s", filters.SortColumn(), filters.SortDirection())).Order("id DESC")
+	query = query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize)
+
+	if err := query.Find(&notifications).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	metadata := calculateMetadata(int(totalRecords), filters.Page, filters.PageSize)
+	return notifications, metadata, nil
+}
+
+func (r *notificationRepository) CountUnread(userID int64) (int64, error) {
+	var count int64
+	err := r.DB.GetDb().Model(&entity.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(userID, notificationID int64) error {
+	var notification entity.Notification
+	err := r.DB.GetDb().Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotificationNotFound
+		}
+		return err
+	}
+
+	if notification.ReadAt != nil {
+		return nil
+	}
+	return r.DB.GetDb().Model(&notification).Update("read_at", time.Now()).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID int64) (int64, error) {
+	result := r.DB.GetDb().Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) GetPreferences(userID int64) ([]*entity.NotificationPreference, error) {
+	var preferences []*entity.NotificationPreference
+	if err := r.DB.GetDb().Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func (r *notificationRepository) SavePreferences(userID int64, preferences []*entity.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	for _, preference := range preferences {
+		preference.UserID = userID
+	}
+	return r.DB.GetDb().Omit("User").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email"}),
+	}).Create(&preferences).Error
+}
+
+func calculateMetadata(totalRecords, page, pageSize int) postsFilter.Metadata {
+	if totalRecords == 0 {
+		return postsFilter.Metadata{}
+	}
+	return postsFilter.Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
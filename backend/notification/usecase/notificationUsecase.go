@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/sse"
+	postsFilter "DiplomaV2/backend/post"
+)
+
+type NotificationUseCase interface {
+	Notify(notification *entity.Notification, channels ...string) error
+	Wants(userID int64, notificationType, channel string) (bool, error)
+	GetNotifications(userID int64, unreadOnly bool, filters postsFilter.Filters) ([]*entity.Notification, postsFilter.Metadata, error)
+	CountUnread(userID int64) (int64, error)
+	MarkRead(userID, notificationID int64) error
+	MarkAllRead(userID int64) (int64, error)
+	GetPreferences(userID int64) ([]*entity.NotificationPreference, error)
+	UpdatePreferences(userID int64, preferences []*entity.NotificationPreference) error
+	Subscribe(userID int64) (<-chan sse.Event, func())
+}
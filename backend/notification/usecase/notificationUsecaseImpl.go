@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/mailer"
+	"DiplomaV2/backend/internal/sse"
+	"DiplomaV2/backend/notification/repository"
+	postsFilter "DiplomaV2/backend/post"
+	userRepository "DiplomaV2/backend/user/repository"
+	"strconv"
+)
+
+type notificationUseCaseImpl struct {
+	repo        repository.NotificationRepository
+	userRepo    userRepository.UserRepository
+	mailer      mailer.Mailer
+	hub         *sse.Hub
+	frontendURL string
+}
+
+func NewNotificationUseCase(repo repository.NotificationRepository, userRepo userRepository.UserRepository, mailer mailer.Mailer, hub *sse.Hub, frontendURL string) NotificationUseCase {
+	return &notificationUseCaseImpl{
+		repo:        repo,
+		userRepo:    userRepo,
+		mailer:      mailer,
+		hub:         hub,
+		frontendURL: frontendURL,
+	}
+}
+
+// Notify delivers the notification on the given channels, or on every
+// channel when none are given, skipping the ones the user turned off.
+// In-app notifications are stored and pushed to the user's open streams.
+func (n *notificationUseCaseImpl) Notify(notification *entity.Notification, channels ...string) error {
+	if len(channels) == 0 {
+		channels = []string{entity.ChannelInApp, entity.ChannelEmail}
+	}
+
+	preference, err := n.preference(notification.UserID, notification.Type)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		switch {
+		case channel == entity.ChannelInApp && preference.InApp:
+			if err := n.repo.Insert(notification); err != nil {
+				return err
+			}
+			n.hub.Publish(notification.UserID, sse.Event{
+				ID:   strconv.FormatInt(notification.ID, 10),
+				Type: "notification",
+				Data: notification,
+			})
+		case channel == entity.ChannelEmail && preference.Email:
+			user, err := n.userRepo.GetByID(notification.UserID)
+			if err != nil {
+				return err
+			}
+			data := map[string]interface{}{
+				"title": notification.Title,
+				"body":  notification.Body,
+				"link":  n.frontendURL + notification.Link,
+			}
+			if err := n.mailer.Send(user.Email, "notification.tmpl", data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *notificationUseCaseImpl) Wants(userID int64, notificationType, channel string) (bool, error) {
+	preference, err := n.preference(userID, notificationType)
+	if err != nil {
+		return false, err
+	}
+	if channel == entity.ChannelEmail {
+		return preference.Email, nil
+	}
+	return preference.InApp, nil
+}
+
+func (n *notificationUseCaseImpl) GetNotifications(userID int64, unreadOnly bool, filters postsFilter.Filters) ([]*entity.Notification, postsFilter.Metadata, error) {
+	return n.repo.GetForUser(userID, unreadOnly, filters)
+}
+
+func (n *notificationUseCaseImpl) CountUnread(userID int64) (int64, error) {
+	return n.repo.CountUnread(userID)
+}
+
+func (n *notificationUseCaseImpl) MarkRead(userID, notificationID int64) error {
+	return n.repo.MarkRead(userID, notificationID)
+}
+
+func (n *notificationUseCaseImpl) MarkAllRead(userID int64) (int64, error) {
+	return n.repo.MarkAllRead(userID)
+}
+
+// GetPreferences returns a preference for every notification type, filling
+// in the defaults for the types the user never changed.
+func (n *notificationUseCaseImpl) GetPreferences(userID int64) ([]*entity.NotificationPreference, error) {
+	stored, err := n.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]*entity.NotificationPreference, len(stored))
+	for _, preference := range stored {
+		byType[preference.Type] = preference
+	}
+
+	preferences := make([]*entity.NotificationPreference, 0, len(entity.NotificationTypes))
+	for _, notificationType := range entity.NotificationTypes {
+		if preference, ok := byType[notificationType]; ok {
+			preferences = append(preferences, preference)
+			continue
+		}
+		preferences = append(preferences, defaultPreference(userID, notificationType))
+	}
+	return preferences, nil
+}
+
+func (n *notificationUseCaseImpl) UpdatePreferences(userID int64, preferences []*entity.NotificationPreference) error {
+	return n.repo.SavePreferences(userID, preferences)
+}
+
+func (n *notificationUseCaseImpl) Subscribe(userID int64) (<-chan sse.Event, func()) {
+	return n.hub.Subscribe(userID)
+}
+
+func (n *notificationUseCaseImpl) preference(userID int64, notificationType string) (*entity.NotificationPreference, error) {
+	preferences, err := n.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, preference := range preferences {
+		if preference.Type == notificationType {
+			return preference, nil
+		}
+	}
+	return defaultPreference(userID, notificationType), nil
+}
+
+func defaultPreference(userID int64, notificationType string) *entity.NotificationPreference {
+	return &entity.NotificationPreference{UserID: userID, Type: notificationType, InApp: true, Email: true}
+}
@@ -51,3 +51,33 @@ func (f Filters) limit() int {
 func (f Filters) offset() int {
 	return (f.Page - 1) * f.PageSize
 }
+
+const (
+	IncludeAuthor = "author"
+	IncludeStats  = "stats"
+)
+
+var IncludeSafeList = []string{IncludeAuthor, IncludeStats}
+
+// Includes are the optional expansions of a post listing, read from the
+// include query parameter.
+type Includes struct {
+	Author bool
+	// Stats are only filled in for the viewer's own posts.
+	Stats bool
+}
+
+func ReadIncludes(v *validator.Validator, values []string) Includes {
+	var includes Includes
+	for _, value := range values {
+		switch value {
+		case IncludeAuthor:
+			includes.Author = true
+		case IncludeStats:
+			includes.Stats = true
+		default:
+			v.AddError("include", "must only contain "+strings.Join(IncludeSafeList, ", "))
+		}
+	}
+	return includes
+}
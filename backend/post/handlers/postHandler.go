@@ -5,8 +5,17 @@ import "github.com/labstack/echo/v4"
 type PostHandler interface {
 	CreatePost(c echo.Context) error
 	GetPostById(c echo.Context) error
+	GetPostStats(c echo.Context) error
 	GetFilteredPosts(c echo.Context) error
+	StreamPosts(c echo.Context) error
 	GetMyPosts(c echo.Context) error
+	GetPostTypes(c echo.Context) error
+	GetSavedPosts(c echo.Context) error
+	SavePost(c echo.Context) error
+	UnsavePost(c echo.Context) error
 	UpdatePost(c echo.Context) error
 	DeletePost(c echo.Context) error
+	PublishPost(c echo.Context) error
+	ClosePost(c echo.Context) error
+	ReopenPost(c echo.Context) error
 }
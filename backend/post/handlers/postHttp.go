@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"DiplomaV2/backend/internal/dto"
 	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
 	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/sse"
 	"DiplomaV2/backend/internal/validator"
 	postsFilter "DiplomaV2/backend/post"
+	"DiplomaV2/backend/post/repository"
 	"DiplomaV2/backend/post/usecase"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type postHttpHandler struct {
@@ -25,6 +32,8 @@ func (p *postHttpHandler) GetMyPosts(c echo.Context) error {
 		Author      string
 		PostType    string
 		Skills      []string
+		SkillLevels []entity.SkillLevelFilter
+		Status      string
 		postsFilter.Filters
 	}
 
@@ -37,11 +46,15 @@ func (p *postHttpHandler) GetMyPosts(c echo.Context) error {
 	input.PostType = helpers.ReadString(qs, "type", "")
 	input.Author = helpers.ReadString(qs, "author", userIDString)
 	input.Skills = helpers.ReadCSV(qs, "skills", []string{})
+	input.SkillLevels = helpers.ReadSkillLevels(qs, "skillLevels", v)
+	details := readPostDetailFilters(qs, v)
+	input.Status = helpers.ReadString(qs, "status", "")
 
 	input.Filters.Page = helpers.ReadInt(qs, "page", 1, v)
 	input.Filters.PageSize = helpers.ReadInt(qs, "pageSize", 10, v)
 	input.Filters.Sort = helpers.ReadString(qs, "sort", "created_at")
-	input.Filters.SortSafeList = []string{"name", "created_at", "-name", "-created_at"}
+	input.Filters.SortSafeList = postSortSafeList
+	includes := readIncludes(qs, v)
 
 	if !v.Valid() {
 		return c.JSON(http.StatusBadRequest, v.Errors)
@@ -51,26 +64,48 @@ func (p *postHttpHandler) GetMyPosts(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, v.Errors)
 	}
 
+	if input.Status != "" {
+		v.Check(validator.PermittedValue(input.Status, entity.PostStatuses...), "status", "invalid status value")
+	}
+
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
 	post := entity.Post{
 		Name:        input.Name,
 		Description: input.Description,
 		Type:        input.PostType,
 		AuthorID:    userID,
 		Skills:      input.Skills,
+		Status:      input.Status,
 	}
 
-	posts, metadata, err := p.postUseCase.GetFilteredPosts(&post, input.Filters)
+	post.OpenSlots = details.OpenSlots
+	post.Roles = details.Roles
+	post.WorkMode = details.WorkMode
+	post.City = details.City
+	post.Commitment = details.Commitment
+	post.Stage = details.Stage
+
+	posts, metadata, err := p.postUseCase.GetFilteredPosts(&post, input.SkillLevels, input.Filters, userID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
 	}
+	if err := p.annotate(c, posts); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+	if err := p.postUseCase.ExpandPosts(posts, includes, userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
 
 	type Response struct {
-		Posts    []*entity.Post       `json:"posts"`
+		Posts    []dto.Post           `json:"posts"`
 		Metadata postsFilter.Metadata `json:"metadata"`
 	}
 
 	response := Response{
-		Posts:    posts,
+		Posts:    dto.NewPosts(posts),
 		Metadata: metadata,
 	}
 
@@ -78,64 +113,108 @@ func (p *postHttpHandler) GetMyPosts(c echo.Context) error {
 }
 
 func (p *postHttpHandler) GetFilteredPosts(c echo.Context) error {
-	var input struct {
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		AuthorID    int64    `json:"author_id"`
-		PostType    string   `json:"type"`
-		Skills      []string `json:"skills"`
-		postsFilter.Filters
-	}
-
 	v := validator.New()
 
 	qs := c.Request().URL.Query()
 
-	input.Name = helpers.ReadString(qs, "name", "")
-	input.Description = helpers.ReadString(qs, "description", "")
-	input.PostType = helpers.ReadString(qs, "type", "")
-	input.AuthorID = int64(helpers.ReadInt(qs, "author", 0, v))
-	input.Skills = helpers.ReadCSV(qs, "skills", []string{})
+	post, levels := readPublicPostFilter(qs, v)
 
-	input.Filters.Page = helpers.ReadInt(qs, "page", 1, v)
-	input.Filters.PageSize = helpers.ReadInt(qs, "pageSize", 10, v)
-	input.Filters.Sort = helpers.ReadString(qs, "sort", "created_at")
-	input.Filters.SortSafeList = []string{"name", "created_at", "-name", "-created_at"}
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 10, v),
+		Sort:         helpers.ReadString(qs, "sort", "created_at"),
+		SortSafeList: postSortSafeList,
+	}
+	includes := readIncludes(qs, v)
 
 	if !v.Valid() {
 		return c.JSON(http.StatusBadRequest, v.Errors)
 	}
 
-	if postsFilter.ValidateFilters(v, input.Filters); !v.Valid() {
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
 		return c.JSON(http.StatusBadRequest, v.Errors)
 	}
 
-	post := entity.Post{
-		Name:        input.Name,
-		Description: input.Description,
-		AuthorID:    input.AuthorID,
-		Type:        input.PostType,
-		Skills:      input.Skills,
-	}
-
-	posts, metadata, err := p.postUseCase.GetFilteredPosts(&post, input.Filters)
+	posts, metadata, err := p.postUseCase.GetFilteredPosts(&post, levels, filters, 0)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
 	}
+	viewerID, _ := c.Get("userID").(int64)
+	if err := p.annotate(c, posts); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+	if err := p.postUseCase.ExpandPosts(posts, includes, viewerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
 
 	type Response struct {
-		Posts    []*entity.Post       `json:"posts"`
+		Posts    []dto.Post           `json:"posts"`
 		Metadata postsFilter.Metadata `json:"metadata"`
 	}
 
 	response := Response{
-		Posts:    posts,
+		Posts:    dto.NewPosts(posts),
 		Metadata: metadata,
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// StreamPosts pushes post changes matching the GetFilteredPosts parameters
+// as server-sent events. A client reconnecting with Last-Event-ID gets the
+// events it missed, or a reset event when they are no longer kept and it
+// should reload the list.
+func (p *postHttpHandler) StreamPosts(c echo.Context) error {
+	v := validator.New()
+
+	qs := c.Request().URL.Query()
+
+	post, levels := readPublicPostFilter(qs, v)
+
+	lastEventID := int64(0)
+	if header := c.Request().Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		v.Check(err == nil && id >= 0, "Last-Event-ID", "must be a valid event id")
+		lastEventID = id
+	}
+
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	subscription, err := p.postUseCase.SubscribeFeed(&post, levels, lastEventID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+	defer subscription.Cancel()
+
+	sse.Start(c)
+	if !subscription.Resumed {
+		if err := sse.Write(c.Response(), sse.Event{Type: "reset", Data: map[string]string{"message": "reload the post list"}}); err != nil {
+			return nil
+		}
+	}
+	for _, event := range subscription.Backlog {
+		if err := sse.Write(c.Response(), feedEvent(event)); err != nil {
+			return nil
+		}
+	}
+
+	stream := make(chan sse.Event)
+	go func() {
+		defer close(stream)
+		for event := range subscription.Events {
+			select {
+			case stream <- feedEvent(event):
+			case <-c.Request().Context().Done():
+				return
+			}
+		}
+	}()
+
+	return sse.Serve(c, stream, streamHeartbeat)
+}
+
 func (p *postHttpHandler) DeletePost(c echo.Context) error {
 	userID := c.Get("userID").(int64)
 	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -164,33 +243,111 @@ func (p *postHttpHandler) GetPostById(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"message": "Invalid post id"})
 	}
-	post, err := p.postUseCase.GetPostById(postID)
+	viewerID, _ := c.Get("userID").(int64)
+	post, err := p.postUseCase.GetVisiblePost(postID, viewerID)
 	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"message": "Post not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
 	}
-	return c.JSON(http.StatusOK, post)
+	if err := p.annotate(c, []*entity.Post{post}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+	// RealIP only believes forwarding headers from the trusted proxies, so
+	// anonymous viewers can't pass as new ones by sending their own.
+	p.postUseCase.RecordView(post, viewerID, c.RealIP())
+	return c.JSON(http.StatusOK, dto.NewPost(post))
+}
+
+// GetPostStats shows the author of a post its views and saves per day.
+func (p *postHttpHandler) GetPostStats(c echo.Context) error {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+	}
+
+	v := validator.New()
+	days := helpers.ReadInt(c.Request().URL.Query(), "days", 30, v)
+	v.Check(days > 0 && days <= 365, "days", "must be between 1 and 365")
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	userID := c.Get("userID").(int64)
+
+	stats, err := p.postUseCase.GetPostStats(postID, userID, days)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPostNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, usecase.ErrorFailedPostValidation):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, stats)
 }
 
 func (p *postHttpHandler) CreatePost(c echo.Context) error {
 	userID := c.Get("userID").(int64)
 
 	var input struct {
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		PostType    string   `json:"type"`
-		Skills      []string `json:"skills"`
+		Name         string             `json:"name"`
+		Description  string             `json:"description"`
+		PostType     string             `json:"type"`
+		Skills       []string           `json:"skills"`
+		Requirements []entity.PostSkill `json:"skillRequirements"`
+		OpenSlots    *int               `json:"openSlots"`
+		Roles        []string           `json:"roles"`
+		WorkMode     string             `json:"workMode"`
+		City         string             `json:"city"`
+		Commitment   string             `json:"commitment"`
+		Stage        string             `json:"stage"`
+		RepoURL      string             `json:"repoUrl"`
+		Draft        bool               `json:"draft"`
+		ExpiresAt    *time.Time         `json:"expiresAt"`
 	}
 
 	if err := c.Bind(&input); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
+	status := entity.PostStatusOpen
+	if input.Draft {
+		status = entity.PostStatusDraft
+	}
+
 	post := entity.Post{
-		Name:        input.Name,
-		Description: input.Description,
-		Type:        strings.ToLower(input.PostType),
-		Skills:      input.Skills,
-		AuthorID:    userID,
+		Name:              strings.TrimSpace(input.Name),
+		Description:       strings.TrimSpace(input.Description),
+		Type:              strings.ToLower(strings.TrimSpace(input.PostType)),
+		Skills:            input.Skills,
+		SkillRequirements: input.Requirements,
+		OpenSlots:         1,
+		Roles:             input.Roles,
+		WorkMode:          input.WorkMode,
+		City:              strings.TrimSpace(input.City),
+		Commitment:        input.Commitment,
+		Stage:             input.Stage,
+		RepoURL:           strings.TrimSpace(input.RepoURL),
+		AuthorID:          userID,
+		Status:            status,
+		ExpiresAt:         input.ExpiresAt,
+	}
+	if input.OpenSlots != nil {
+		post.OpenSlots = *input.OpenSlots
+	}
+	if post.WorkMode == "" {
+		post.WorkMode = entity.WorkModeRemote
+	}
+
+	v := validator.New()
+	validator.ValidatePost(v, &post)
+	if validateExpiresAt(v, input.ExpiresAt); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
 	}
 
 	err := p.postUseCase.CreatePost(&post)
@@ -202,11 +359,22 @@ func (p *postHttpHandler) CreatePost(c echo.Context) error {
 }
 
 func (p *postHttpHandler) UpdatePost(c echo.Context) error {
+	// The structured fields are optional so older clients that only send
+	// name, description, type and skills don't wipe them.
 	var input struct {
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		PostType    string   `json:"type"`
-		Skills      []string `json:"skills"`
+		Name         string             `json:"name"`
+		Description  string             `json:"description"`
+		PostType     string             `json:"type"`
+		Skills       []string           `json:"skills"`
+		Requirements []entity.PostSkill `json:"skillRequirements"`
+		OpenSlots    *int               `json:"openSlots"`
+		Roles        *[]string          `json:"roles"`
+		WorkMode     *string            `json:"workMode"`
+		City         *string            `json:"city"`
+		Commitment   *string            `json:"commitment"`
+		Stage        *string            `json:"stage"`
+		RepoURL      *string            `json:"repoUrl"`
+		ExpiresAt    *time.Time         `json:"expiresAt"`
 	}
 
 	if err := c.Bind(&input); err != nil {
@@ -221,11 +389,55 @@ func (p *postHttpHandler) UpdatePost(c echo.Context) error {
 
 	authorID := c.Get("userID").(int64)
 
+	current, err := p.postUseCase.GetPostById(postID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
 	post := entity.Post{
-		Name:        input.Name,
-		Description: input.Description,
-		Type:        strings.ToLower(input.PostType),
-		Skills:      input.Skills,
+		Name:              strings.TrimSpace(input.Name),
+		Description:       strings.TrimSpace(input.Description),
+		Type:              strings.ToLower(strings.TrimSpace(input.PostType)),
+		Skills:            input.Skills,
+		SkillRequirements: input.Requirements,
+		OpenSlots:         current.OpenSlots,
+		Roles:             current.Roles,
+		WorkMode:          current.WorkMode,
+		City:              current.City,
+		Commitment:        current.Commitment,
+		Stage:             current.Stage,
+		RepoURL:           current.RepoURL,
+		ExpiresAt:         input.ExpiresAt,
+	}
+	if input.OpenSlots != nil {
+		post.OpenSlots = *input.OpenSlots
+	}
+	if input.Roles != nil {
+		post.Roles = *input.Roles
+	}
+	if input.WorkMode != nil {
+		post.WorkMode = *input.WorkMode
+	}
+	if input.City != nil {
+		post.City = strings.TrimSpace(*input.City)
+	}
+	if input.Commitment != nil {
+		post.Commitment = *input.Commitment
+	}
+	if input.Stage != nil {
+		post.Stage = *input.Stage
+	}
+	if input.RepoURL != nil {
+		post.RepoURL = strings.TrimSpace(*input.RepoURL)
+	}
+
+	v := validator.New()
+	validator.ValidatePost(v, &post)
+	if validateExpiresAt(v, input.ExpiresAt); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
 	}
 
 	err = p.postUseCase.UpdatePost(postID, authorID, &post)
@@ -236,6 +448,190 @@ func (p *postHttpHandler) UpdatePost(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Post updated successfully"})
 }
 
+func (p *postHttpHandler) GetPostTypes(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{"types": entity.PostTypes})
+}
+
+func (p *postHttpHandler) SavePost(c echo.Context) error {
+	return p.postAction(c, func(postID, userID int64) error {
+		return p.postUseCase.SavePost(userID, postID)
+	}, "Post saved")
+}
+
+func (p *postHttpHandler) UnsavePost(c echo.Context) error {
+	return p.postAction(c, func(postID, userID int64) error {
+		return p.postUseCase.UnsavePost(userID, postID)
+	}, "Post removed from saved")
+}
+
+func (p *postHttpHandler) GetSavedPosts(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 10, v),
+		Sort:         helpers.ReadString(qs, "sort", "-saved_at"),
+		SortSafeList: []string{"saved_at", "created_at", "name", "-saved_at", "-created_at", "-name"},
+	}
+	includes := readIncludes(qs, v)
+
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	posts, metadata, err := p.postUseCase.GetSavedPosts(userID, filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+	if err := p.postUseCase.ExpandPosts(posts, includes, userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	}
+
+	type Response struct {
+		Posts    []dto.Post           `json:"posts"`
+		Metadata postsFilter.Metadata `json:"metadata"`
+	}
+
+	return c.JSON(http.StatusOK, Response{Posts: dto.NewPosts(posts), Metadata: metadata})
+}
+
+// annotate fills the viewer specific fields when the caller is logged in.
+func (p *postHttpHandler) annotate(c echo.Context, posts []*entity.Post) error {
+	userID, ok := c.Get("userID").(int64)
+	if !ok {
+		return nil
+	}
+	return p.postUseCase.AnnotateForViewer(posts, userID)
+}
+
+func (p *postHttpHandler) PublishPost(c echo.Context) error {
+	return p.postAction(c, p.postUseCase.PublishPost, "Post published")
+}
+
+func (p *postHttpHandler) ClosePost(c echo.Context) error {
+	return p.postAction(c, p.postUseCase.ClosePost, "Post closed")
+}
+
+func (p *postHttpHandler) ReopenPost(c echo.Context) error {
+	var input struct {
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	v := validator.New()
+	if validateExpiresAt(v, input.ExpiresAt); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	return p.postAction(c, func(postID, userID int64) error {
+		return p.postUseCase.ReopenPost(postID, userID, input.ExpiresAt)
+	}, "Post reopened")
+}
+
+func (p *postHttpHandler) postAction(c echo.Context, action func(postID, userID int64) error, message string) error {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+	}
+
+	userID := c.Get("userID").(int64)
+
+	err = action(postID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPostNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, usecase.ErrorFailedPostValidation):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		case errors.Is(err, usecase.ErrInvalidStatusTransition), errors.Is(err, usecase.ErrPostExpired):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, usecase.ErrCannotSaveOwnPost):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": message})
+}
+
+// streamHeartbeat keeps idle post streams open behind proxies.
+const streamHeartbeat = 30 * time.Second
+
+var postSortSafeList = []string{
+	"name", "created_at", "open_slots", "expires_at",
+	"-name", "-created_at", "-open_slots", "-expires_at",
+}
+
+// readIncludes reads the include parameter of the post listings. The
+// author is included when the parameter is missing or empty.
+func readIncludes(qs url.Values, v *validator.Validator) postsFilter.Includes {
+	return postsFilter.ReadIncludes(v, helpers.ReadCSV(qs, "include", []string{postsFilter.IncludeAuthor}))
+}
+
+// readPublicPostFilter reads the GetFilteredPosts parameters into an example
+// post. Status defaults to open and drafts can't be asked for: they are only
+// visible to their author through /posts/my.
+func readPublicPostFilter(qs url.Values, v *validator.Validator) (entity.Post, []entity.SkillLevelFilter) {
+	post := readPostDetailFilters(qs, v)
+	post.Name = helpers.ReadString(qs, "name", "")
+	post.Description = helpers.ReadString(qs, "description", "")
+	post.Type = helpers.ReadString(qs, "type", "")
+	post.AuthorID = int64(helpers.ReadInt(qs, "author", 0, v))
+	post.Skills = helpers.ReadCSV(qs, "skills", []string{})
+	post.Status = helpers.ReadString(qs, "status", entity.PostStatusOpen)
+	levels := helpers.ReadSkillLevels(qs, "skillLevels", v)
+
+	v.Check(validator.PermittedValue(post.Status, entity.PostStatusOpen, entity.PostStatusClosed, entity.PostStatusArchived), "status", "invalid status value")
+	return post, levels
+}
+
+// feedEvent turns a feed event into its SSE form. Removed and deleted
+// events only carry the post id.
+func feedEvent(event usecase.FeedEvent) sse.Event {
+	id := strconv.FormatInt(event.ID, 10)
+	if event.Name == events.PostDeletedEvent || event.Name == usecase.PostRemoved {
+		return sse.Event{ID: id, Type: event.Name, Data: map[string]int64{"id": event.Post.ID}}
+	}
+	return sse.Event{ID: id, Type: event.Name, Data: dto.NewPost(&event.Post)}
+}
+
+// readPostDetailFilters reads the structured post fields used as filters and
+// checks the type filter. openSlots is a minimum and roles match any of the
+// listed roles.
+func readPostDetailFilters(qs url.Values, v *validator.Validator) entity.Post {
+	details := entity.Post{
+		OpenSlots:  helpers.ReadInt(qs, "openSlots", 0, v),
+		Roles:      helpers.ReadCSV(qs, "roles", []string{}),
+		WorkMode:   helpers.ReadString(qs, "workMode", ""),
+		City:       helpers.ReadString(qs, "city", ""),
+		Commitment: helpers.ReadString(qs, "commitment", ""),
+		Stage:      helpers.ReadString(qs, "stage", ""),
+	}
+
+	v.Check(qs.Get("type") == "" || validator.PermittedValue(qs.Get("type"), entity.PostTypeValues()...), "type", "must be one of the registered post types")
+	v.Check(details.OpenSlots >= 0, "openSlots", "must not be negative")
+	for _, role := range details.Roles {
+		v.Check(validator.PermittedValue(role, entity.PostRoles...), "roles", "must only contain the listed roles")
+	}
+	v.Check(details.WorkMode == "" || validator.PermittedValue(details.WorkMode, entity.WorkModes...), "workMode", "invalid work mode value")
+	v.Check(details.Commitment == "" || validator.PermittedValue(details.Commitment, entity.Commitments...), "commitment", "invalid commitment value")
+	v.Check(details.Stage == "" || validator.PermittedValue(details.Stage, entity.ProjectStages...), "stage", "invalid stage value")
+	return details
+}
+
+func validateExpiresAt(v *validator.Validator, expiresAt *time.Time) {
+	if expiresAt != nil {
+		v.Check(expiresAt.After(time.Now()), "expiresAt", "must be in the future")
+	}
+}
+
 func NewPostHttpHandler(postUseCase usecase.PostUseCase) PostHandler {
 	return &postHttpHandler{
 		postUseCase: postUseCase,
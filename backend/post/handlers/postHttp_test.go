@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/post/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakePostUseCase struct {
+	usecase.PostUseCase
+	posts map[int64]*entity.Post
+}
+
+func (f *fakePostUseCase) GetVisiblePost(id, viewerID int64) (*entity.Post, error) {
+	post, ok := f.posts[id]
+	if !ok || !post.VisibleTo(viewerID) {
+		return nil, repository.ErrPostNotFound
+	}
+	return post, nil
+}
+
+func (f *fakePostUseCase) AnnotateForViewer([]*entity.Post, int64) error {
+	return nil
+}
+
+func (f *fakePostUseCase) RecordView(*entity.Post, int64, string) {}
+
+func (f *fakePostUseCase) CreatePost(post *entity.Post) error {
+	post.ID = int64(len(f.posts) + 1)
+	post.Author = *leakyAuthor()
+	f.posts[post.ID] = post
+	return nil
+}
+
+func leakyAuthor() *entity.User {
+	author := &entity.User{
+		ID:           1,
+		Name:         "Jane",
+		Username:     "jane",
+		Email:        "jane@example.com",
+		PendingEmail: "pending@example.com",
+		TOTPSecret:   "TOTPSECRET",
+		Tokens:       []entity.Token{{Plaintext: "token-plaintext", Hash: []byte("token-hash")}},
+	}
+	author.Password.Hash = []byte("$2a$12$abcdefghijklmnopqrstuv")
+	return author
+}
+
+// assertNoLeaks fails if body carries anything of the author beyond its
+// public profile.
+func assertNoLeaks(t *testing.T, body string) {
+	t.Helper()
+	for _, leak := range []string{"example.com", "TOTPSECRET", "token-plaintext", "$2a$12$", `"email"`, `"password"`, `"hash"`, `"tokens"`} {
+		if strings.Contains(body, leak) {
+			t.Errorf("response leaks %s: %s", leak, body)
+		}
+	}
+}
+
+func newTestHandler() (PostHandler, *fakePostUseCase) {
+	uc := &fakePostUseCase{posts: map[int64]*entity.Post{
+		1: {ID: 1, Name: "Team", AuthorID: 1, Author: *leakyAuthor(), Type: "team finding", Status: entity.PostStatusOpen},
+	}}
+	return NewPostHttpHandler(uc), uc
+}
+
+func TestGetPostByIdDoesNotLeakAuthor(t *testing.T) {
+	handler, _ := newTestHandler()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/v2/posts/1", nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	if err := handler.GetPostById(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"username":"jane"`) {
+		t.Errorf("response should have the author's public profile: %s", rec.Body)
+	}
+	assertNoLeaks(t, rec.Body.String())
+}
+
+func TestCreatePostDoesNotLeakAuthor(t *testing.T) {
+	handler, uc := newTestHandler()
+
+	e := echo.New()
+	body := `{"name":"Team","description":"Looking for a team","type":"team finding"}`
+	req := httptest.NewRequest(http.MethodPost, "/v2/posts", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", int64(1))
+
+	if err := handler.CreatePost(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if len(uc.posts) != 2 {
+		t.Fatal("the post should be created")
+	}
+	assertNoLeaks(t, rec.Body.String())
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"time"
+)
+
+type PostViewRepository interface {
+	AddViews(views []entity.PostView) error
+	CountViews(postIDs []int64) (map[int64]int64, error)
+	GetDailyViews(postID int64, since time.Time) (map[string]int64, error)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// viewBatchSize keeps a flush of many posts within the parameter limit.
+const viewBatchSize = 500
+
+type postViewRepository struct {
+	DB database.Database
+}
+
+func NewPostViewRepository(db database.Database) PostViewRepository {
+	return &postViewRepository{DB: db}
+}
+
+// AddViews adds the counts to the existing ones of the same post and day.
+// Views of posts deleted in the meantime are dropped.
+func (r *postViewRepository) AddViews(views []entity.PostView) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(views))
+	for _, view := range views {
+		ids = append(ids, view.PostID)
+	}
+	var existing []int64
+	if err := r.DB.GetDb().Model(&entity.Post{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	exists := make(map[int64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	kept := make([]entity.PostView, 0, len(views))
+	for _, view := range views {
+		if exists[view.PostID] {
+			kept = append(kept, view)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return r.DB.GetDb().Omit("Post").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("post_views.views + excluded.views")}),
+	}).CreateInBatches(&kept, viewBatchSize).Error
+}
+
+func (r *postViewRepository) CountViews(postIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64)
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID int64
+		Count  int64
+	}
+	err := r.DB.GetDb().Model(&entity.PostView{}).
+		Select("post_id, SUM(views) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetDailyViews returns the views of a post per day since the given day,
+// keyed by date as 2006-01-02.
+func (r *postViewRepository) GetDailyViews(postID int64, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   string
+		Views int64
+	}
+	err := r.DB.GetDb().Model(&entity.PostView{}).
+		Select("to_char(day, 'YYYY-MM-DD') AS day, views").
+		Where("post_id = ? AND day >= ?", postID, since).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	views := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		views[row.Day] = row.Views
+	}
+	return views, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"DiplomaV2/backend/internal/entity"
 	postsFilter "DiplomaV2/backend/post"
+	"time"
 )
 
 type PostRepository interface {
@@ -10,6 +11,12 @@ type PostRepository interface {
 	GetByID(id int64) (*entity.Post, error)
 	Delete(id int64) error
 	Update(post *entity.Post) error
+	ReplaceSkillRequirements(postID int64, requirements []entity.PostSkill) error
 	DeleteAllForUser(authorID int64) error
-	GetFilteredPosts(post *entity.Post, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error)
+	GetAllByAuthor(authorID int64) ([]*entity.Post, error)
+	LoadAuthors(posts []*entity.Post) error
+	GetExpiringUnnotified(before time.Time) ([]*entity.Post, error)
+	MarkExpiryNotified(id int64) error
+	ArchiveExpired() ([]*entity.Post, error)
+	GetFilteredPosts(post *entity.Post, levels []entity.SkillLevelFilter, filters postsFilter.Filters, ownerID int64) ([]*entity.Post, postsFilter.Metadata, error)
 }
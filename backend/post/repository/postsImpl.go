@@ -8,17 +8,27 @@ import (
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"math"
+	"time"
 )
 
 type postRepository struct {
 	DB database.Database
 }
 
+var (
+	ErrPostNotFound = errors.New("post not found")
+)
+
 func NewPostRepository(db database.Database) PostRepository {
 	return &postRepository{DB: db}
 }
 
+// postColumns selects a post along with its number of comments that
+// aren't deleted, read into Post.CommentCount.
+const postColumns = "posts.*, (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) AS comment_count"
+
 func (r *postRepository) Insert(post *entity.Post) error {
 	result := r.DB.GetDb().Create(post)
 	return result.Error
@@ -26,9 +36,9 @@ func (r *postRepository) Insert(post *entity.Post) error {
 
 func (r *postRepository) GetByID(postID int64) (*entity.Post, error) {
 	var post entity.Post
-	if err := r.DB.GetDb().Where("id = ?", postID).First(&post).Error; err != nil {
+	if err := r.DB.GetDb().Select(postColumns).Preload("SkillRequirements").Preload("Author").Where("id = ?", postID).First(&post).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("post not found")
+			return nil, ErrPostNotFound
 		}
 		return nil, err
 	}
@@ -53,13 +63,28 @@ func (r *postRepository) Delete(id int64) error {
 }
 
 func (r *postRepository) Update(post *entity.Post) error {
-	result := r.DB.GetDb().Save(post)
+	result := r.DB.GetDb().Omit("SkillRequirements", "Author").Save(post)
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
 
+func (r *postRepository) ReplaceSkillRequirements(postID int64, requirements []entity.PostSkill) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", postID).Delete(&entity.PostSkill{}).Error; err != nil {
+			return err
+		}
+		if len(requirements) == 0 {
+			return nil
+		}
+		for i := range requirements {
+			requirements[i].PostID = postID
+		}
+		return tx.Create(&requirements).Error
+	})
+}
+
 func (r *postRepository) DeleteAllForUser(userID int64) error {
 	if userID < 1 {
 		return gorm.ErrRecordNotFound
@@ -73,9 +98,61 @@ func (r *postRepository) DeleteAllForUser(userID int64) error {
 	return nil
 }
 
-func (r *postRepository) GetFilteredPosts(post *entity.Post, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error) {
+func (r *postRepository) GetAllByAuthor(authorID int64) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	if err := r.DB.GetDb().Preload("SkillRequirements").Where("author_id = ?", authorID).Order("created_at").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// LoadAuthors sets the author of every post with a single query, whatever
+// the number of posts. Only the columns of the public author summary are
+// read; authors in their deletion grace period are left empty.
+func (r *postRepository) LoadAuthors(posts []*entity.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.AuthorID)
+	}
+
+	var authors []entity.User
+	err := r.DB.GetDb().Select("id", "username", "name", "profile_image").
+		Where("id IN ?", ids).
+		Find(&authors).Error
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int64]entity.User, len(authors))
+	for _, author := range authors {
+		byID[author.ID] = author
+	}
+	for _, post := range posts {
+		post.Author = byID[post.AuthorID]
+	}
+	return nil
+}
+
+// GetFilteredPosts matches the non-empty fields of post. OpenSlots is a
+// minimum, PublishedAt keeps posts published later and Roles match any of
+// the roles.
+// A level filter keeps the posts asking for that skill at a minimum level
+// the candidate meets. Hidden posts are only listed when ownerID is set and
+// the query is restricted to ownerID's own posts.
+func (r *postRepository) GetFilteredPosts(post *entity.Post, levels []entity.SkillLevelFilter, filters postsFilter.Filters, ownerID int64) ([]*entity.Post, postsFilter.Metadata, error) {
 	var posts []*entity.Post
-	query := r.DB.GetDb().Model(&entity.Post{})
+	// Users in their deletion grace period are excluded by the soft-delete scope.
+	activeAuthors := r.DB.GetDb().Model(&entity.User{}).Select("id")
+	query := r.DB.GetDb().Model(&entity.Post{}).
+		Where("author_id IN (?)", activeAuthors)
+
+	if ownerID == 0 || post.AuthorID != ownerID {
+		query = query.Where("hidden = ?", false)
+	}
 
 	if post.Name != "" {
 		query = query.Where("name ILIKE ?", "%"+post.Name+"%")
@@ -92,6 +169,40 @@ func (r *postRepository) GetFilteredPosts(post *entity.Post, filters postsFilter
 	if len(post.Skills) > 0 {
 		query = query.Where("skills @> ?", pq.Array(post.Skills))
 	}
+	if post.OpenSlots > 0 {
+		query = query.Where("open_slots >= ?", post.OpenSlots)
+	}
+	if len(post.Roles) > 0 {
+		query = query.Where("roles && ?", pq.Array(post.Roles))
+	}
+	if post.WorkMode != "" {
+		query = query.Where("work_mode = ?", post.WorkMode)
+	}
+	if post.City != "" {
+		query = query.Where("city ILIKE ?", post.City)
+	}
+	if post.Commitment != "" {
+		query = query.Where("commitment = ?", post.Commitment)
+	}
+	if post.Stage != "" {
+		query = query.Where("stage = ?", post.Stage)
+	}
+	for _, level := range levels {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM post_skills WHERE post_skills.post_id = posts.id AND post_skills.skill = ? AND (post_skills.min_level = '' OR post_skills.min_level IS NULL OR post_skills.min_level IN ?))",
+			level.Skill, entity.SkillLevelsAtMost(level.Level),
+		)
+	}
+	if post.PublishedAt != nil {
+		query = query.Where("published_at > ?", *post.PublishedAt)
+	}
+	if post.Status != "" {
+		query = query.Where("status = ?", post.Status)
+	}
+	if post.Status == entity.PostStatusOpen {
+		// Expired posts stay open until the archive job runs.
+		query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
 
 	var totalRecords int64
 	countQuery := *query
@@ -105,7 +216,7 @@ func (r *postRepository) GetFilteredPosts(post *entity.Post, filters postsFilter
 
 	query = query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize)
 
-	if err := query.Find(&posts).Error; err != nil {
+	if err := query.Select(postColumns).Preload("SkillRequirements").Find(&posts).Error; err != nil {
 		return nil, postsFilter.Metadata{}, err
 	}
 
@@ -113,6 +224,33 @@ func (r *postRepository) GetFilteredPosts(post *entity.Post, filters postsFilter
 	return posts, metadata, nil
 }
 
+func (r *postRepository) GetExpiringUnnotified(before time.Time) ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := r.DB.GetDb().Preload("Author").
+		Where("status = ? AND expiry_notified_at IS NULL", entity.PostStatusOpen).
+		Where("expires_at > ? AND expires_at <= ?", time.Now(), before).
+		Find(&posts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return posts, nil
+}
+
+func (r *postRepository) MarkExpiryNotified(id int64) error {
+	return r.DB.GetDb().Model(&entity.Post{}).Where("id = ?", id).Update("expiry_notified_at", time.Now()).Error
+}
+
+// ArchiveExpired archives the open and closed posts past their expiry date
+// and returns them as updated.
+func (r *postRepository) ArchiveExpired() ([]*entity.Post, error) {
+	var posts []*entity.Post
+	result := r.DB.GetDb().Model(&posts).
+		Clauses(clause.Returning{}).
+		Where("status IN ? AND expires_at <= ?", []string{entity.PostStatusOpen, entity.PostStatusClosed}, time.Now()).
+		Updates(map[string]interface{}{"status": entity.PostStatusArchived, "version": gorm.Expr("version + 1")})
+	return posts, result.Error
+}
+
 func contains(list []string, value string) bool {
 	for _, v := range list {
 		if v == value {
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"time"
+)
+
+type SavedPostRepository interface {
+	Save(userID, postID int64) error
+	Unsave(userID, postID int64) (bool, error)
+	GetSaved(userID int64, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error)
+	SavedAmong(userID int64, postIDs []int64) (map[int64]bool, error)
+	CountSaves(postIDs []int64) (map[int64]int64, error)
+	GetDailySaves(postID int64, since time.Time) (map[string]int64, error)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"fmt"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type savedPostRepository struct {
+	DB database.Database
+}
+
+func NewSavedPostRepository(db database.Database) SavedPostRepository {
+	return &savedPostRepository{DB: db}
+}
+
+func (r *savedPostRepository) Save(userID, postID int64) error {
+	saved := entity.SavedPost{UserID: userID, PostID: postID}
+	return r.DB.GetDb().Clauses(clause.OnConflict{DoNothing: true}).Omit("User", "Post").Create(&saved).Error
+}
+
+func (r *savedPostRepository) Unsave(userID, postID int64) (bool, error) {
+	result := r.DB.GetDb().Where("user_id = ? AND post_id = ?", userID, postID).Delete(&entity.SavedPost{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetSaved lists the saved posts of a user. Posts hidden by moderation are
+// left out but stay saved.
+func (r *savedPostRepository) GetSaved(userID int64, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error) {
+	var posts []*entity.Post
+	query := r.DB.GetDb().Model(&entity.Post{}).
+		Joins("INNER JOIN saved_posts ON saved_posts.post_id = posts.id").
+		Where("saved_posts.user_id = ?", userID).
+		Where("posts.hidden = ?", false)
+
+	var totalRecords int64
+	countQuery := *query
+	if err := countQuery.Count(&totalRecords).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	if filters.Sort != "" && contains(filters.SortSafeList, filters.Sort) {
+		column := "posts." + filters.SortColumn()
+		if filters.SortColumn() == "saved_at" {
+			column = "saved_posts.created_at"
+		}
+		query = query.Order(fmt.Sprintf("%s %s", column, filters.SortDirection()))
+	}
+
+	query = query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize)
+
+	if err := query.Select(postColumns).Preload("SkillRequirements").Find(&posts).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	metadata := calculateMetadata(int(totalRecords), filters.Page, filters.PageSize)
+	return posts, metadata, nil
+}
+
+func (r *savedPostRepository) SavedAmong(userID int64, postIDs []int64) (map[int64]bool, error) {
+	saved := make(map[int64]bool)
+	if len(postIDs) == 0 {
+		return saved, nil
+	}
+
+	var ids []int64
+	err := r.DB.GetDb().Model(&entity.SavedPost{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		saved[id] = true
+	}
+	return saved, nil
+}
+
+func (r *savedPostRepository) CountSaves(postIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64)
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID int64
+		Count  int64
+	}
+	err := r.DB.GetDb().Model(&entity.SavedPost{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetDailySaves returns how many users saved a post per day (UTC) since the
+// given day, keyed by date as 2006-01-02. Unsaved posts aren't counted.
+func (r *savedPostRepository) GetDailySaves(postID int64, since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err := r.DB.GetDb().Model(&entity.SavedPost{}).
+		Select("to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) AS count").
+		Where("post_id = ? AND created_at >= ?", postID, since).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	saves := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		saves[row.Day] = row.Count
+	}
+	return saves, nil
+}
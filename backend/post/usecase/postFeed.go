@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PostRemoved is sent to a filtered stream when an updated post stops
+// matching its filter, so the client can drop it.
+const PostRemoved = "post.removed"
+
+// feedBuffer is how many events a subscriber may fall behind before it is
+// closed.
+const feedBuffer = 64
+
+// FeedEvent is a post change as kept in the feed log. Post is a snapshot
+// taken when the event was published.
+type FeedEvent struct {
+	ID   int64
+	Name string
+	Post entity.Post
+}
+
+// PostFeed keeps the last post events in memory and fans new ones out to
+// the open streams. Event IDs grow by one, so a client can resume from the
+// last ID it saw as long as that event is still in the log.
+type PostFeed struct {
+	mu          sync.RWMutex
+	log         []FeedEvent
+	size        int
+	lastID      int64
+	subscribers map[chan FeedEvent]struct{}
+}
+
+func NewPostFeed(bus *events.Bus, size int) *PostFeed {
+	feed := &PostFeed{
+		log:         make([]FeedEvent, 0, size),
+		size:        size,
+		subscribers: make(map[chan FeedEvent]struct{}),
+	}
+
+	events.Subscribe(bus, func(event events.PostCreated) error {
+		feed.record(event.EventName(), event.Post)
+		return nil
+	})
+	events.Subscribe(bus, func(event events.PostUpdated) error {
+		feed.record(event.EventName(), event.Post)
+		return nil
+	})
+	events.Subscribe(bus, func(event events.PostDeleted) error {
+		feed.record(event.EventName(), event.Post)
+		return nil
+	})
+	return feed
+}
+
+func (f *PostFeed) record(name string, post entity.Post) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastID++
+	feedEvent := FeedEvent{ID: f.lastID, Name: name, Post: post}
+	if len(f.log) == f.size {
+		copy(f.log, f.log[1:])
+		f.log = f.log[:len(f.log)-1]
+	}
+	f.log = append(f.log, feedEvent)
+
+	// A subscriber that fell a buffer behind is closed rather than silently
+	// skipped; its client reconnects with Last-Event-ID and gets the missed
+	// events from the log.
+	for ch := range f.subscribers {
+		select {
+		case ch <- feedEvent:
+		default:
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the events after lastEventID and a channel of new ones.
+// resumed is false when lastEventID is no longer (or was never) in the log.
+func (f *PostFeed) subscribe(lastEventID int64) (backlog []FeedEvent, resumed bool, ch chan FeedEvent, cancel func()) {
+	ch = make(chan FeedEvent, feedBuffer)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resumed = lastEventID == 0
+	if lastEventID > 0 && lastEventID <= f.lastID && (len(f.log) == 0 || lastEventID >= f.log[0].ID-1) {
+		resumed = true
+		for _, event := range f.log {
+			if event.ID > lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	f.subscribers[ch] = struct{}{}
+	return backlog, resumed, ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[ch]; ok {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// FeedSubscription is an open post stream. Backlog holds the events missed
+// since the Last-Event-ID; when Resumed is false the client should reload
+// the list instead. Cancel must be called once the stream is closed.
+type FeedSubscription struct {
+	Backlog []FeedEvent
+	Resumed bool
+	Events  <-chan FeedEvent
+	Cancel  func()
+}
+
+func (p *postUseCaseImpl) SubscribeFeed(filter *entity.Post, levels []entity.SkillLevelFilter, lastEventID int64) (*FeedSubscription, error) {
+	skills, err := p.skills.Normalize(filter.Skills)
+	if err != nil {
+		return nil, err
+	}
+	filter.Skills = skills
+	for i := range levels {
+		if levels[i].Skill, err = p.skills.Canonical(levels[i].Skill); err != nil {
+			return nil, err
+		}
+	}
+
+	backlog, resumed, ch, cancel := p.feed.subscribe(lastEventID)
+	matching := make([]FeedEvent, 0, len(backlog))
+	for _, event := range backlog {
+		if event, ok := filterFeedEvent(event, filter, levels); ok {
+			matching = append(matching, event)
+		}
+	}
+
+	events := make(chan FeedEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		for event := range ch {
+			event, ok := filterFeedEvent(event, filter, levels)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return &FeedSubscription{
+		Backlog: matching,
+		Resumed: resumed,
+		Events:  events,
+		Cancel: func() {
+			once.Do(func() {
+				close(done)
+				cancel()
+			})
+		},
+	}, nil
+}
+
+// filterFeedEvent decides what a filtered stream sees of an event. An
+// updated post that no longer matches is sent as PostRemoved, since the
+// client may be showing it.
+func filterFeedEvent(event FeedEvent, filter *entity.Post, levels []entity.SkillLevelFilter) (FeedEvent, bool) {
+	switch event.Name {
+	case events.PostDeletedEvent:
+		return event, true
+	case events.PostUpdatedEvent:
+		if !postMatches(&event.Post, filter, levels) {
+			event.Name = PostRemoved
+		}
+		return event, true
+	default:
+		return event, postMatches(&event.Post, filter, levels)
+	}
+}
+
+// postMatches mirrors the filters of PostRepository.GetFilteredPosts for a
+// single post.
+func postMatches(post, filter *entity.Post, levels []entity.SkillLevelFilter) bool {
+	if post.Hidden || post.Status == entity.PostStatusDraft {
+		return false
+	}
+	if filter.Name != "" && !containsFoldSubstring(post.Name, filter.Name) {
+		return false
+	}
+	if filter.Description != "" && !containsFoldSubstring(post.Description, filter.Description) {
+		return false
+	}
+	if filter.AuthorID != 0 && post.AuthorID != filter.AuthorID {
+		return false
+	}
+	if filter.Type != "" && post.Type != filter.Type {
+		return false
+	}
+	for _, skill := range filter.Skills {
+		if !containsFold(post.Skills, skill) {
+			return false
+		}
+	}
+	if filter.OpenSlots > 0 && post.OpenSlots < filter.OpenSlots {
+		return false
+	}
+	if len(filter.Roles) > 0 {
+		overlap := false
+		for _, role := range filter.Roles {
+			if containsFold(post.Roles, role) {
+				overlap = true
+			}
+		}
+		if !overlap {
+			return false
+		}
+	}
+	if filter.WorkMode != "" && post.WorkMode != filter.WorkMode {
+		return false
+	}
+	if filter.City != "" && !strings.EqualFold(post.City, filter.City) {
+		return false
+	}
+	if filter.Commitment != "" && post.Commitment != filter.Commitment {
+		return false
+	}
+	if filter.Stage != "" && post.Stage != filter.Stage {
+		return false
+	}
+	for _, level := range levels {
+		if !meetsLevel(post.SkillRequirements, level) {
+			return false
+		}
+	}
+	if filter.Status != "" && post.Status != filter.Status {
+		return false
+	}
+	if post.Status == entity.PostStatusOpen && post.ExpiresAt != nil && !post.ExpiresAt.After(time.Now()) {
+		return false
+	}
+	return true
+}
+
+func meetsLevel(requirements []entity.PostSkill, level entity.SkillLevelFilter) bool {
+	for _, requirement := range requirements {
+		if !strings.EqualFold(requirement.Skill, level.Skill) {
+			continue
+		}
+		if requirement.MinLevel == "" || containsFold(entity.SkillLevelsAtMost(level.Level), requirement.MinLevel) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFoldSubstring(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"testing"
+)
+
+func newTestFeed(size, published int) (*PostFeed, *events.Bus) {
+	bus := events.New(echo.New().Logger)
+	feed := NewPostFeed(bus, size)
+	for i := 1; i <= published; i++ {
+		bus.Publish(events.PostCreated{Post: entity.Post{ID: int64(i)}})
+	}
+	return feed, bus
+}
+
+func eventIDs(backlog []FeedEvent) []int64 {
+	ids := make([]int64, len(backlog))
+	for i, event := range backlog {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestFeedResumesFromTheLog(t *testing.T) {
+	// The log only keeps events 3 to 5.
+	feed, _ := newTestFeed(3, 5)
+
+	tests := []struct {
+		lastEventID int64
+		wantResumed bool
+		wantBacklog []int64
+	}{
+		{lastEventID: 0, wantResumed: true, wantBacklog: nil},
+		{lastEventID: 5, wantResumed: true, wantBacklog: nil},
+		{lastEventID: 3, wantResumed: true, wantBacklog: []int64{4, 5}},
+		{lastEventID: 2, wantResumed: true, wantBacklog: []int64{3, 4, 5}},
+		{lastEventID: 1, wantResumed: false, wantBacklog: nil},
+		{lastEventID: 6, wantResumed: false, wantBacklog: nil},
+	}
+	for _, tt := range tests {
+		backlog, resumed, _, cancel := feed.subscribe(tt.lastEventID)
+		cancel()
+		if resumed != tt.wantResumed {
+			t.Errorf("Last-Event-ID %d: resumed = %v, want %v", tt.lastEventID, resumed, tt.wantResumed)
+		}
+		if got := eventIDs(backlog); fmt.Sprint(got) != fmt.Sprint(tt.wantBacklog) {
+			t.Errorf("Last-Event-ID %d: backlog = %v, want %v", tt.lastEventID, got, tt.wantBacklog)
+		}
+	}
+}
+
+func TestFeedClosesSubscriberThatFallsBehind(t *testing.T) {
+	feed, bus := newTestFeed(2*feedBuffer, 0)
+	_, _, ch, cancel := feed.subscribe(0)
+	defer cancel()
+
+	for i := 1; i <= feedBuffer+1; i++ {
+		bus.Publish(events.PostCreated{Post: entity.Post{ID: int64(i)}})
+	}
+
+	var lastSeen int64
+	for event := range ch {
+		lastSeen = event.ID
+	}
+	if lastSeen != feedBuffer {
+		t.Fatalf("got events up to %d before the close, want %d", lastSeen, feedBuffer)
+	}
+
+	// Reconnecting with the last ID seen gets the dropped event.
+	backlog, resumed, _, cancel := feed.subscribe(lastSeen)
+	cancel()
+	if !resumed || len(backlog) != 1 || backlog[0].ID != feedBuffer+1 {
+		t.Fatalf("resumed = %v, backlog = %v, want event %d", resumed, eventIDs(backlog), feedBuffer+1)
+	}
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"strconv"
+	"time"
+)
+
+// RecordView counts a view of a published post. Signed in viewers are told
+// apart by their id and anonymous ones by their IP address; authors viewing
+// their own post aren't counted.
+func (p *postUseCaseImpl) RecordView(post *entity.Post, viewerID int64, ip string) {
+	if post.Status == entity.PostStatusDraft || post.AuthorID == viewerID {
+		return
+	}
+
+	viewer := "ip:" + ip
+	if viewerID != 0 {
+		viewer = "user:" + strconv.FormatInt(viewerID, 10)
+	}
+	p.views.Record(post.ID, viewer)
+}
+
+// GetPostStats returns the views and saves of the author's post over the
+// last days, today included. Views are as of the last flush.
+func (p *postUseCaseImpl) GetPostStats(postID, userID int64, days int) (*entity.PostAnalytics, error) {
+	thePost, err := p.getOwned(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	dailyViews, err := p.viewRepo.GetDailyViews(thePost.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	dailySaves, err := p.savedRepo.GetDailySaves(thePost.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	views, err := p.viewRepo.CountViews([]int64{thePost.ID})
+	if err != nil {
+		return nil, err
+	}
+	saves, err := p.savedRepo.CountSaves([]int64{thePost.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &entity.PostAnalytics{
+		PostID: thePost.ID,
+		Views:  views[thePost.ID],
+		Saves:  saves[thePost.ID],
+		Days:   make([]entity.PostDayCounts, 0, days),
+	}
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		analytics.Days = append(analytics.Days, entity.PostDayCounts{
+			Date:  date,
+			Views: dailyViews[date],
+			Saves: dailySaves[date],
+		})
+	}
+	return analytics, nil
+}
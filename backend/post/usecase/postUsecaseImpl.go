@@ -1,23 +1,52 @@
 package usecase
 
 import (
+	"DiplomaV2/backend/internal/config"
 	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"DiplomaV2/backend/internal/mailer"
 	"DiplomaV2/backend/post"
 	"DiplomaV2/backend/post/repository"
+	skillUseCase "DiplomaV2/backend/skill/usecase"
+	stderrors "errors"
+	"fmt"
 	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"strings"
+	"time"
 )
 
 type postUseCaseImpl struct {
-	Repo repository.PostRepository
+	Repo        repository.PostRepository
+	savedRepo   repository.SavedPostRepository
+	mailer      mailer.Mailer
+	conf        *config.Posts
+	frontendURL string
+	skills      skillUseCase.SkillUseCase
+	bus         *events.Bus
+	feed        *PostFeed
+	viewRepo    repository.PostViewRepository
+	views       *ViewCounter
 }
 
 var (
-	ErrorFailedPostValidation = errors.New("Post doesn't belong to this user")
+	ErrorFailedPostValidation  = errors.New("Post doesn't belong to this user")
+	ErrInvalidStatusTransition = errors.New("post can't be moved to this status")
+	ErrPostExpired             = errors.New("post has expired, set a new expiry date to reopen it")
 )
 
-func NewPostUseCase(repository repository.PostRepository) PostUseCase {
+func NewPostUseCase(repository repository.PostRepository, savedRepo repository.SavedPostRepository, mailer mailer.Mailer, conf *config.Posts, frontendURL string, skills skillUseCase.SkillUseCase, bus *events.Bus, feed *PostFeed, viewRepo repository.PostViewRepository, views *ViewCounter) PostUseCase {
 	return &postUseCaseImpl{
-		Repo: repository,
+		Repo:        repository,
+		savedRepo:   savedRepo,
+		mailer:      mailer,
+		conf:        conf,
+		frontendURL: frontendURL,
+		skills:      skills,
+		bus:         bus,
+		feed:        feed,
+		viewRepo:    viewRepo,
+		views:       views,
 	}
 }
 
@@ -29,19 +58,50 @@ func (p *postUseCaseImpl) GetPostById(id int64) (*entity.Post, error) {
 	return thePost, nil
 }
 
+// GetVisiblePost returns repository.ErrPostNotFound for posts viewerID may
+// not see, so their existence isn't revealed.
+func (p *postUseCaseImpl) GetVisiblePost(id, viewerID int64) (*entity.Post, error) {
+	thePost, err := p.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !thePost.VisibleTo(viewerID) {
+		return nil, repository.ErrPostNotFound
+	}
+	return thePost, nil
+}
+
 func (p *postUseCaseImpl) CreatePost(post *entity.Post) error {
+	if post.Status == "" {
+		post.Status = entity.PostStatusOpen
+	}
+	if post.Status == entity.PostStatusOpen {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+	if err := p.applySkills(post, post); err != nil {
+		return err
+	}
+
 	err := p.Repo.Insert(post)
 	if err != nil {
 		return err
 	}
+	p.bus.Publish(events.PostCreated{Post: *post})
 	return nil
 }
 
 func (p *postUseCaseImpl) DeletePost(id int64) error {
-	err := p.Repo.Delete(id)
+	thePost, err := p.Repo.GetByID(id)
 	if err != nil {
 		return err
 	}
+
+	err = p.Repo.Delete(id)
+	if err != nil {
+		return err
+	}
+	p.bus.Publish(events.PostDeleted{Post: *thePost})
 	return nil
 }
 
@@ -58,7 +118,20 @@ func (p *postUseCaseImpl) UpdatePost(postID, userID int64, updatedPost *entity.P
 	thePost.Name = updatedPost.Name
 	thePost.Description = updatedPost.Description
 	thePost.Type = updatedPost.Type
-	thePost.Skills = updatedPost.Skills
+	thePost.OpenSlots = updatedPost.OpenSlots
+	thePost.Roles = updatedPost.Roles
+	thePost.WorkMode = updatedPost.WorkMode
+	thePost.City = updatedPost.City
+	thePost.Commitment = updatedPost.Commitment
+	thePost.Stage = updatedPost.Stage
+	thePost.RepoURL = updatedPost.RepoURL
+	if err := p.applySkills(thePost, updatedPost); err != nil {
+		return err
+	}
+	if updatedPost.ExpiresAt != nil {
+		thePost.ExpiresAt = updatedPost.ExpiresAt
+		thePost.ExpiryNotifiedAt = nil
+	}
 	thePost.Version += 1
 
 	err = p.Repo.Update(thePost)
@@ -66,13 +139,248 @@ func (p *postUseCaseImpl) UpdatePost(postID, userID int64, updatedPost *entity.P
 		return err
 	}
 
+	if err := p.Repo.ReplaceSkillRequirements(thePost.ID, thePost.SkillRequirements); err != nil {
+		return err
+	}
+	p.bus.Publish(events.PostUpdated{Post: *thePost})
 	return nil
 }
 
-func (p *postUseCaseImpl) GetFilteredPosts(post *entity.Post, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error) {
-	filteredPosts, metadata, err := p.Repo.GetFilteredPosts(post, filters)
+// GetFilteredPosts lists the posts matching post. ownerID is set by the
+// author's own posts page, the only listing that includes hidden posts.
+func (p *postUseCaseImpl) GetFilteredPosts(post *entity.Post, levels []entity.SkillLevelFilter, filters postsFilter.Filters, ownerID int64) ([]*entity.Post, postsFilter.Metadata, error) {
+	skills, err := p.skills.Normalize(post.Skills)
+	if err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+	post.Skills = skills
+	for i := range levels {
+		if levels[i].Skill, err = p.skills.Canonical(levels[i].Skill); err != nil {
+			return nil, postsFilter.Metadata{}, err
+		}
+	}
+
+	filteredPosts, metadata, err := p.Repo.GetFilteredPosts(post, levels, filters, ownerID)
 	if err != nil {
 		return nil, metadata, err
 	}
 	return filteredPosts, metadata, nil
 }
+
+// ExpandPosts loads the requested expansions of a page of posts. They cost
+// the same number of queries whatever the page size. Stats are only filled
+// in for the posts of viewerID, like GetPostStats.
+func (p *postUseCaseImpl) ExpandPosts(posts []*entity.Post, includes postsFilter.Includes, viewerID int64) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	if includes.Author {
+		if err := p.Repo.LoadAuthors(posts); err != nil {
+			return err
+		}
+	}
+
+	if includes.Stats && viewerID != 0 {
+		var own []*entity.Post
+		ids := make([]int64, 0, len(posts))
+		for _, thePost := range posts {
+			if thePost.AuthorID == viewerID {
+				own = append(own, thePost)
+				ids = append(ids, thePost.ID)
+			}
+		}
+		if len(own) == 0 {
+			return nil
+		}
+
+		views, err := p.viewRepo.CountViews(ids)
+		if err != nil {
+			return err
+		}
+		saves, err := p.savedRepo.CountSaves(ids)
+		if err != nil {
+			return err
+		}
+		for _, thePost := range own {
+			thePost.Stats = &entity.PostStats{Views: views[thePost.ID], Saves: saves[thePost.ID]}
+		}
+	}
+	return nil
+}
+
+func (p *postUseCaseImpl) PublishPost(postID, userID int64) error {
+	return p.transition(postID, userID, entity.PostStatusOpen, entity.PostStatusDraft)
+}
+
+func (p *postUseCaseImpl) ClosePost(postID, userID int64) error {
+	return p.transition(postID, userID, entity.PostStatusClosed, entity.PostStatusOpen)
+}
+
+// ReopenPost brings back a closed or archived post. An expired post needs a
+// new expiry date in the future.
+func (p *postUseCaseImpl) ReopenPost(postID, userID int64, expiresAt *time.Time) error {
+	thePost, err := p.getOwned(postID, userID)
+	if err != nil {
+		return err
+	}
+
+	if thePost.Status != entity.PostStatusClosed && thePost.Status != entity.PostStatusArchived {
+		return ErrInvalidStatusTransition
+	}
+
+	if expiresAt != nil {
+		thePost.ExpiresAt = expiresAt
+		thePost.ExpiryNotifiedAt = nil
+	}
+	if thePost.ExpiresAt != nil && !thePost.ExpiresAt.After(time.Now()) {
+		return ErrPostExpired
+	}
+
+	thePost.Status = entity.PostStatusOpen
+	thePost.Version += 1
+	if err := p.Repo.Update(thePost); err != nil {
+		return err
+	}
+	p.bus.Publish(events.PostUpdated{Post: *thePost})
+	return nil
+}
+
+// ArchiveExpiredPosts warns authors about posts expiring soon and archives
+// the ones that are past their expiry date.
+func (p *postUseCaseImpl) ArchiveExpiredPosts(ctx context.Context) error {
+	expiring, err := p.Repo.GetExpiringUnnotified(time.Now().Add(p.conf.ExpiryNoticeBefore))
+	if err != nil {
+		return err
+	}
+
+	// A failed notice is retried on the next run and must not hold back the
+	// other notices or the archiving; the failures are reported together.
+	var failed []error
+	for _, thePost := range expiring {
+		if ctx.Err() != nil {
+			failed = append(failed, ctx.Err())
+			break
+		}
+		if err := p.notifyExpiring(thePost); err != nil {
+			failed = append(failed, fmt.Errorf("expiry notice for post %d: %w", thePost.ID, err))
+		}
+	}
+
+	archived, err := p.Repo.ArchiveExpired()
+	if err != nil {
+		failed = append(failed, err)
+	}
+	for _, thePost := range archived {
+		p.bus.Publish(events.PostUpdated{Post: *thePost})
+	}
+	return stderrors.Join(failed...)
+}
+
+// notifyExpiring mails the author that the post expires soon. Authors without
+// an email, e.g. deleted ones, are skipped but the post is still marked so it
+// isn't picked up again.
+func (p *postUseCaseImpl) notifyExpiring(thePost *entity.Post) error {
+	if thePost.Author.Email != "" {
+		data := map[string]interface{}{
+			"postName":  thePost.Name,
+			"expiresAt": thePost.ExpiresAt.Format("January 2, 2006 15:04 MST"),
+			"postLink":  fmt.Sprintf("%s/manage-post/%d", p.frontendURL, thePost.ID),
+		}
+		if err := p.mailer.Send(thePost.Author.Email, "post_expiring.tmpl", data); err != nil {
+			return err
+		}
+	}
+	return p.Repo.MarkExpiryNotified(thePost.ID)
+}
+
+// applySkills copies the skills of update onto post. When requirements are
+// sent they define the flat list; otherwise the flat list is used as is and
+// the requirements of removed skills are dropped.
+func (p *postUseCaseImpl) applySkills(post, update *entity.Post) error {
+	if update.SkillRequirements == nil {
+		skills, err := p.skills.Normalize(update.Skills)
+		if err != nil {
+			return err
+		}
+
+		kept := make([]entity.PostSkill, 0, len(post.SkillRequirements))
+		for _, requirement := range post.SkillRequirements {
+			if containsFold(skills, requirement.Skill) {
+				kept = append(kept, requirement)
+			}
+		}
+		post.Skills = skills
+		post.SkillRequirements = kept
+		return nil
+	}
+
+	skills := make([]string, 0, len(update.SkillRequirements))
+	requirements := make([]entity.PostSkill, 0, len(update.SkillRequirements))
+	for _, requirement := range update.SkillRequirements {
+		name, err := p.skills.Canonical(requirement.Skill)
+		if err != nil {
+			return err
+		}
+		if containsFold(skills, name) {
+			continue
+		}
+		requirement.Skill = name
+		skills = append(skills, name)
+		requirements = append(requirements, requirement)
+	}
+	post.Skills = skills
+	post.SkillRequirements = requirements
+	return nil
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *postUseCaseImpl) transition(postID, userID int64, to string, from ...string) error {
+	thePost, err := p.getOwned(postID, userID)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, status := range from {
+		if thePost.Status == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return ErrInvalidStatusTransition
+	}
+
+	thePost.Status = to
+	// Saved searches pick posts up by when they were first published.
+	if to == entity.PostStatusOpen && thePost.PublishedAt == nil {
+		now := time.Now()
+		thePost.PublishedAt = &now
+	}
+	thePost.Version += 1
+	if err := p.Repo.Update(thePost); err != nil {
+		return err
+	}
+	p.bus.Publish(events.PostUpdated{Post: *thePost})
+	return nil
+}
+
+func (p *postUseCaseImpl) getOwned(postID, userID int64) (*entity.Post, error) {
+	thePost, err := p.Repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if thePost.AuthorID != userID {
+		return nil, ErrorFailedPostValidation
+	}
+	return thePost, nil
+}
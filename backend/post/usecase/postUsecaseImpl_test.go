@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"DiplomaV2/backend/internal/mailer"
+	postsFilter "DiplomaV2/backend/post"
+	"DiplomaV2/backend/post/repository"
+	skillUseCase "DiplomaV2/backend/skill/usecase"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingDriver is a database/sql driver that answers the post listing
+// queries with canned rows and counts the queries it is sent.
+type countingDriver struct {
+	mu       sync.Mutex
+	queries  []string
+	pageSize int
+	authorID int64
+}
+
+func (d *countingDriver) Open(string) (driver.Conn, error) {
+	return &countingConn{driver: d}, nil
+}
+
+func (d *countingDriver) reset(pageSize int, authorID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = nil
+	d.pageSize = pageSize
+	d.authorID = authorID
+}
+
+func (d *countingDriver) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queries)
+}
+
+type countingConn struct {
+	driver *countingDriver
+}
+
+func (c *countingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("countingConn: prepared statements aren't supported")
+}
+
+func (c *countingConn) Close() error { return nil }
+
+func (c *countingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("countingConn: transactions aren't supported")
+}
+
+func (c *countingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	d := c.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, query)
+
+	switch {
+	case strings.Contains(query, "comment_count"):
+		rows := &cannedRows{columns: []string{"id", "author_id", "name", "status", "comment_count"}}
+		for i := 1; i <= d.pageSize; i++ {
+			rows.values = append(rows.values, []driver.Value{int64(i), d.authorID, fmt.Sprintf("post %d", i), entity.PostStatusOpen, int64(i * 2)})
+		}
+		return rows, nil
+	case strings.HasPrefix(query, "SELECT count(*)"):
+		return &cannedRows{columns: []string{"count"}, values: [][]driver.Value{{int64(1000)}}}, nil
+	default:
+		return &cannedRows{}, nil
+	}
+}
+
+type cannedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *cannedRows) Columns() []string { return r.columns }
+
+func (r *cannedRows) Close() error { return nil }
+
+func (r *cannedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type testDatabase struct {
+	db *gorm.DB
+}
+
+func (t testDatabase) GetDb() *gorm.DB { return t.db }
+
+type fakeSkillUseCase struct {
+	skillUseCase.SkillUseCase
+}
+
+func (fakeSkillUseCase) Normalize(skills []string) ([]string, error) { return skills, nil }
+
+func (fakeSkillUseCase) Canonical(skill string) (string, error) { return skill, nil }
+
+func newCountingUseCase(t testing.TB) (PostUseCase, *countingDriver) {
+	t.Helper()
+	counting := &countingDriver{}
+	name := fmt.Sprintf("counting-%p", counting)
+	sql.Register(name, counting)
+
+	sqlDB, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database := testDatabase{db: db}
+	uc := NewPostUseCase(repository.NewPostRepository(database), repository.NewSavedPostRepository(database),
+		mailer.Mailer{}, nil, "", fakeSkillUseCase{}, nil, nil, repository.NewPostViewRepository(database), nil)
+	return uc, counting
+}
+
+// listPosts runs the queries of GET /v2/posts for a page of pageSize posts.
+func listPosts(t testing.TB, uc PostUseCase, pageSize int, viewerID int64) []*entity.Post {
+	t.Helper()
+	filters := postsFilter.Filters{Page: 1, PageSize: pageSize, Sort: "created_at", SortSafeList: []string{"created_at"}}
+	posts, _, err := uc.GetFilteredPosts(&entity.Post{Status: entity.PostStatusOpen}, nil, filters, 0)
+	if err != nil {
+		t.Fatalf("GetFilteredPosts: %v", err)
+	}
+	if len(posts) != pageSize {
+		t.Fatalf("got %d posts, want %d", len(posts), pageSize)
+	}
+	includes := postsFilter.Includes{Author: true, Stats: true}
+	if err := uc.ExpandPosts(posts, includes, viewerID); err != nil {
+		t.Fatalf("ExpandPosts: %v", err)
+	}
+	return posts
+}
+
+func TestListingQueryCountDoesNotGrowWithPageSize(t *testing.T) {
+	uc, counting := newCountingUseCase(t)
+
+	var counts []int
+	for _, pageSize := range []int{5, 50} {
+		counting.reset(pageSize, 1)
+		listPosts(t, uc, pageSize, 1)
+		counts = append(counts, counting.count())
+	}
+
+	if counts[0] != counts[1] {
+		t.Errorf("%d queries for 5 posts but %d for 50", counts[0], counts[1])
+	}
+	// The count, the page, its skill requirements, authors, views and saves.
+	if counts[0] != 6 {
+		t.Errorf("%d queries, want 6: %q", counts[0], counting.queries)
+	}
+}
+
+func TestExpandPostsOnlyShowsStatsToAuthor(t *testing.T) {
+	uc, counting := newCountingUseCase(t)
+
+	counting.reset(5, 1)
+	for _, post := range listPosts(t, uc, 5, 1) {
+		if post.Stats == nil {
+			t.Fatal("the author should see the stats of their posts")
+		}
+	}
+
+	for _, viewerID := range []int64{0, 2} {
+		counting.reset(5, 1)
+		for _, post := range listPosts(t, uc, 5, viewerID) {
+			if post.Stats != nil {
+				t.Fatalf("viewer %d must not see the stats of post %d", viewerID, post.ID)
+			}
+		}
+		if counting.count() != 4 {
+			t.Errorf("viewer %d: %d queries, want 4 without the stats", viewerID, counting.count())
+		}
+	}
+}
+
+func TestOnlyOwnPostsListIncludeHidden(t *testing.T) {
+	uc, counting := newCountingUseCase(t)
+	filters := postsFilter.Filters{Page: 1, PageSize: 5, Sort: "created_at", SortSafeList: []string{"created_at"}}
+
+	for _, tt := range []struct {
+		authorID, ownerID int64
+		wantHidden        bool
+	}{
+		{authorID: 1, ownerID: 1, wantHidden: true},
+		{authorID: 1, ownerID: 0, wantHidden: false},
+		{authorID: 1, ownerID: 2, wantHidden: false},
+		{authorID: 0, ownerID: 1, wantHidden: false},
+	} {
+		counting.reset(5, 1)
+		if _, _, err := uc.GetFilteredPosts(&entity.Post{AuthorID: tt.authorID}, nil, filters, tt.ownerID); err != nil {
+			t.Fatal(err)
+		}
+		checked := 0
+		for _, query := range counting.queries {
+			if !strings.Contains(query, `FROM "posts"`) {
+				continue
+			}
+			checked++
+			if filtered := strings.Contains(query, "hidden"); filtered == tt.wantHidden {
+				t.Errorf("author %d, owner %d: hidden filter = %v in %q", tt.authorID, tt.ownerID, filtered, query)
+			}
+		}
+		if checked != 2 {
+			t.Errorf("checked %d queries, want the count and the page", checked)
+		}
+	}
+}
+
+func TestListingCountsUndeletedComments(t *testing.T) {
+	uc, counting := newCountingUseCase(t)
+
+	counting.reset(5, 1)
+	for _, post := range listPosts(t, uc, 5, 1) {
+		if post.CommentCount != post.ID*2 {
+			t.Errorf("post %d: comment count = %d, want %d", post.ID, post.CommentCount, post.ID*2)
+		}
+	}
+	for _, query := range counting.queries {
+		if strings.Contains(query, "comment_count") && !strings.Contains(query, "comments.deleted_at IS NULL") {
+			t.Errorf("comment count includes deleted comments: %q", query)
+		}
+	}
+}
+
+func BenchmarkListing(b *testing.B) {
+	for _, pageSize := range []int{10, 100} {
+		b.Run(fmt.Sprintf("pageSize=%d", pageSize), func(b *testing.B) {
+			uc, counting := newCountingUseCase(b)
+			for i := 0; i < b.N; i++ {
+				counting.reset(pageSize, 1)
+				listPosts(b, uc, pageSize, 1)
+			}
+			b.ReportMetric(float64(counting.count()), "queries/op")
+		})
+	}
+}
+
+func (r *fakePostRepo) Update(post *entity.Post) error {
+	r.posts[post.ID] = post
+	return nil
+}
+
+func TestPublishedAtIsSetOnFirstPublish(t *testing.T) {
+	posts := &fakePostRepo{posts: map[int64]*entity.Post{
+		1: {ID: 1, AuthorID: 1, Status: entity.PostStatusDraft},
+	}}
+	uc := NewPostUseCase(posts, nil, mailer.Mailer{}, nil, "", fakeSkillUseCase{}, events.New(echo.New().Logger), nil, nil, nil)
+
+	if err := uc.PublishPost(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	published := posts.posts[1].PublishedAt
+	if published == nil {
+		t.Fatal("publishing a draft didn't set PublishedAt")
+	}
+
+	if err := uc.ClosePost(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.ReopenPost(1, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !posts.posts[1].PublishedAt.Equal(*published) {
+		t.Errorf("reopening moved PublishedAt from %v to %v", published, posts.posts[1].PublishedAt)
+	}
+}
+
+func (r *fakePostRepo) GetExpiringUnnotified(time.Time) ([]*entity.Post, error) {
+	return nil, nil
+}
+
+func (r *fakePostRepo) ArchiveExpired() ([]*entity.Post, error) {
+	var archived []*entity.Post
+	for _, post := range r.posts {
+		if post.Status == entity.PostStatusOpen && post.ExpiresAt != nil && !post.ExpiresAt.After(time.Now()) {
+			post.Status = entity.PostStatusArchived
+			copied := *post
+			archived = append(archived, &copied)
+		}
+	}
+	return archived, nil
+}
+
+func TestArchiveExpiredPostsPublishesUpdates(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	posts := &fakePostRepo{posts: map[int64]*entity.Post{
+		1: {ID: 1, AuthorID: 1, Status: entity.PostStatusOpen, ExpiresAt: &expired},
+		2: {ID: 2, AuthorID: 1, Status: entity.PostStatusOpen},
+	}}
+	bus := events.New(echo.New().Logger)
+	var updates []entity.Post
+	events.Subscribe(bus, func(event events.PostUpdated) error {
+		updates = append(updates, event.Post)
+		return nil
+	})
+	uc := NewPostUseCase(posts, nil, mailer.Mailer{}, &config.Posts{}, "", fakeSkillUseCase{}, bus, nil, nil, nil)
+
+	if err := uc.ArchiveExpiredPosts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].ID != 1 || updates[0].Status != entity.PostStatusArchived {
+		t.Fatalf("updates = %+v, want post 1 archived", updates)
+	}
+}
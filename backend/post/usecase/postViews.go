@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/post/repository"
+	"container/list"
+	"golang.org/x/net/context"
+	"sync"
+	"time"
+)
+
+type viewKey struct {
+	postID int64
+	viewer string
+}
+
+type dayKey struct {
+	postID int64
+	day    string
+}
+
+// ViewCounter buffers post views in memory and writes them in batches, so a
+// popular post costs one row update per flush instead of one per view. A
+// viewer is counted once per post and day. The set of seen viewers only
+// lives in memory: after a restart, or on another instance, a viewer can be
+// counted again, and views not yet flushed are lost on exit. At most maxSeen
+// viewers are remembered; past that the least recently seen are forgotten
+// and may be counted again.
+type ViewCounter struct {
+	mu      sync.Mutex
+	repo    repository.PostViewRepository
+	day     string
+	maxSeen int
+	seen    map[viewKey]*list.Element
+	order   *list.List
+	pending map[dayKey]int64
+}
+
+func NewViewCounter(repo repository.PostViewRepository, maxSeen int) *ViewCounter {
+	return &ViewCounter{
+		repo:    repo,
+		maxSeen: maxSeen,
+		seen:    make(map[viewKey]*list.Element),
+		order:   list.New(),
+		pending: make(map[dayKey]int64),
+	}
+}
+
+// Record counts a view of postID by viewer, a user or an IP address.
+func (v *ViewCounter) Record(postID int64, viewer string) {
+	day := time.Now().UTC().Format(time.DateOnly)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if day != v.day {
+		v.day = day
+		v.seen = make(map[viewKey]*list.Element)
+		v.order.Init()
+	}
+
+	key := viewKey{postID: postID, viewer: viewer}
+	if element, ok := v.seen[key]; ok {
+		v.order.MoveToFront(element)
+		return
+	}
+	v.seen[key] = v.order.PushFront(key)
+	if v.order.Len() > v.maxSeen {
+		oldest := v.order.Back()
+		v.order.Remove(oldest)
+		delete(v.seen, oldest.Value.(viewKey))
+	}
+	v.pending[dayKey{postID: postID, day: day}]++
+}
+
+// Flush writes the buffered views. When the write fails they are kept for
+// the next flush.
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	pending := v.pending
+	v.pending = make(map[dayKey]int64)
+	v.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	views := make([]entity.PostView, 0, len(pending))
+	for key, count := range pending {
+		day, err := time.Parse(time.DateOnly, key.day)
+		if err != nil {
+			return err
+		}
+		views = append(views, entity.PostView{PostID: key.postID, Day: day, Views: count})
+	}
+
+	if err := v.repo.AddViews(views); err != nil {
+		v.mu.Lock()
+		for key, count := range pending {
+			v.pending[key] += count
+		}
+		v.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/post/repository"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"testing"
+)
+
+type fakeViewRepo struct {
+	repository.PostViewRepository
+	views map[int64]int64
+	err   error
+}
+
+func (r *fakeViewRepo) AddViews(views []entity.PostView) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, view := range views {
+		r.views[view.PostID] += view.Views
+	}
+	return nil
+}
+
+func TestViewCounterCountsViewerOnce(t *testing.T) {
+	repo := &fakeViewRepo{views: make(map[int64]int64)}
+	counter := NewViewCounter(repo, 10)
+
+	counter.Record(1, "alice")
+	counter.Record(1, "alice")
+	counter.Record(1, "bob")
+	counter.Record(2, "alice")
+
+	if err := counter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views[1] != 2 || repo.views[2] != 1 {
+		t.Fatalf("views = %v, want post 1: 2, post 2: 1", repo.views)
+	}
+
+	// Flushed views are not written twice.
+	if err := counter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	counter.Record(1, "alice")
+	if err := counter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views[1] != 2 {
+		t.Fatalf("post 1 views = %d after repeat flushes, want 2", repo.views[1])
+	}
+}
+
+func TestViewCounterForgetsLeastRecentViewer(t *testing.T) {
+	repo := &fakeViewRepo{views: make(map[int64]int64)}
+	counter := NewViewCounter(repo, 2)
+
+	counter.Record(1, "alice")
+	counter.Record(1, "bob")
+	counter.Record(1, "alice")
+	counter.Record(1, "carol")
+	if len(counter.seen) != 2 {
+		t.Fatalf("%d viewers remembered, want 2", len(counter.seen))
+	}
+
+	// bob was seen least recently and is counted again; alice is not.
+	counter.Record(1, "alice")
+	counter.Record(1, "bob")
+
+	if err := counter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views[1] != 4 {
+		t.Fatalf("views = %d, want 4", repo.views[1])
+	}
+}
+
+func TestViewCounterKeepsViewsWhenFlushFails(t *testing.T) {
+	repo := &fakeViewRepo{views: make(map[int64]int64), err: errors.New("database is down")}
+	counter := NewViewCounter(repo, 10)
+
+	counter.Record(1, "alice")
+	if err := counter.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() succeeded with a failing repository")
+	}
+	counter.Record(1, "bob")
+
+	repo.err = nil
+	if err := counter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.views[1] != 2 {
+		t.Fatalf("views = %d, want 2", repo.views[1])
+	}
+}
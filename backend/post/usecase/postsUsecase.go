@@ -3,12 +3,27 @@ package usecase
 import (
 	"DiplomaV2/backend/internal/entity"
 	postsFilter "DiplomaV2/backend/post"
+	"golang.org/x/net/context"
+	"time"
 )
 
 type PostUseCase interface {
 	CreatePost(post *entity.Post) error
 	GetPostById(id int64) (*entity.Post, error)
+	GetVisiblePost(id, viewerID int64) (*entity.Post, error)
 	UpdatePost(id int64, authorID int64, post *entity.Post) error
 	DeletePost(id int64) error
-	GetFilteredPosts(post *entity.Post, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error)
+	GetFilteredPosts(post *entity.Post, levels []entity.SkillLevelFilter, filters postsFilter.Filters, ownerID int64) ([]*entity.Post, postsFilter.Metadata, error)
+	PublishPost(postID, userID int64) error
+	ClosePost(postID, userID int64) error
+	ReopenPost(postID, userID int64, expiresAt *time.Time) error
+	ArchiveExpiredPosts(ctx context.Context) error
+	SavePost(userID, postID int64) error
+	UnsavePost(userID, postID int64) error
+	GetSavedPosts(userID int64, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error)
+	AnnotateForViewer(posts []*entity.Post, viewerID int64) error
+	ExpandPosts(posts []*entity.Post, includes postsFilter.Includes, viewerID int64) error
+	RecordView(post *entity.Post, viewerID int64, ip string)
+	GetPostStats(postID, userID int64, days int) (*entity.PostAnalytics, error)
+	SubscribeFeed(filter *entity.Post, levels []entity.SkillLevelFilter, lastEventID int64) (*FeedSubscription, error)
 }
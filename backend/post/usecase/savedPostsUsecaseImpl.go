@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"DiplomaV2/backend/post/repository"
+	"github.com/pkg/errors"
+)
+
+var ErrCannotSaveOwnPost = errors.New("you can't save your own post")
+
+func (p *postUseCaseImpl) SavePost(userID, postID int64) error {
+	thePost, err := p.GetVisiblePost(postID, userID)
+	if err != nil {
+		return err
+	}
+	if thePost.AuthorID == userID {
+		return ErrCannotSaveOwnPost
+	}
+
+	return p.savedRepo.Save(userID, postID)
+}
+
+func (p *postUseCaseImpl) UnsavePost(userID, postID int64) error {
+	removed, err := p.savedRepo.Unsave(userID, postID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return repository.ErrPostNotFound
+	}
+	return nil
+}
+
+func (p *postUseCaseImpl) GetSavedPosts(userID int64, filters postsFilter.Filters) ([]*entity.Post, postsFilter.Metadata, error) {
+	posts, metadata, err := p.savedRepo.GetSaved(userID, filters)
+	if err != nil {
+		return nil, metadata, err
+	}
+	for _, thePost := range posts {
+		thePost.IsSaved = true
+	}
+	return posts, metadata, nil
+}
+
+// AnnotateForViewer sets IsSaved on the posts and, on the viewer's own
+// posts, the number of users who saved them.
+func (p *postUseCaseImpl) AnnotateForViewer(posts []*entity.Post, viewerID int64) error {
+	if viewerID == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(posts))
+	var ownIDs []int64
+	for _, thePost := range posts {
+		ids = append(ids, thePost.ID)
+		if thePost.AuthorID == viewerID {
+			ownIDs = append(ownIDs, thePost.ID)
+		}
+	}
+
+	saved, err := p.savedRepo.SavedAmong(viewerID, ids)
+	if err != nil {
+		return err
+	}
+	counts, err := p.savedRepo.CountSaves(ownIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, thePost := range posts {
+		thePost.IsSaved = saved[thePost.ID]
+		if thePost.AuthorID == viewerID {
+			count := counts[thePost.ID]
+			thePost.SaveCount = &count
+		}
+	}
+	return nil
+}
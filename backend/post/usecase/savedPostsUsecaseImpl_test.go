@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/mailer"
+	"DiplomaV2/backend/post/repository"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+type fakePostRepo struct {
+	repository.PostRepository
+	posts map[int64]*entity.Post
+}
+
+func (r *fakePostRepo) GetByID(id int64) (*entity.Post, error) {
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, repository.ErrPostNotFound
+	}
+	copied := *post
+	return &copied, nil
+}
+
+type fakeSavedRepo struct {
+	repository.SavedPostRepository
+	saved []int64
+}
+
+func (r *fakeSavedRepo) Save(_, postID int64) error {
+	r.saved = append(r.saved, postID)
+	return nil
+}
+
+func TestSavePostOnlySavesVisiblePosts(t *testing.T) {
+	author := entity.User{ID: 1}
+	posts := &fakePostRepo{posts: map[int64]*entity.Post{
+		1: {ID: 1, AuthorID: 1, Author: author, Status: entity.PostStatusOpen},
+		2: {ID: 2, AuthorID: 1, Author: author, Status: entity.PostStatusOpen, Hidden: true},
+		3: {ID: 3, AuthorID: 1, Author: author, Status: entity.PostStatusDraft},
+		// The author is in their deletion grace period.
+		4: {ID: 4, AuthorID: 1, Status: entity.PostStatusOpen},
+	}}
+	saved := &fakeSavedRepo{}
+	uc := NewPostUseCase(posts, saved, mailer.Mailer{}, nil, "", fakeSkillUseCase{}, nil, nil, nil, nil)
+
+	tests := []struct {
+		userID, postID int64
+		want           error
+	}{
+		{userID: 2, postID: 1, want: nil},
+		{userID: 2, postID: 2, want: repository.ErrPostNotFound},
+		{userID: 2, postID: 3, want: repository.ErrPostNotFound},
+		{userID: 2, postID: 4, want: repository.ErrPostNotFound},
+		{userID: 2, postID: 5, want: repository.ErrPostNotFound},
+		{userID: 1, postID: 1, want: ErrCannotSaveOwnPost},
+		{userID: 1, postID: 3, want: ErrCannotSaveOwnPost},
+	}
+	for _, tt := range tests {
+		if err := uc.SavePost(tt.userID, tt.postID); !errors.Is(err, tt.want) {
+			t.Errorf("user %d saving post %d: err = %v, want %v", tt.userID, tt.postID, err, tt.want)
+		}
+	}
+	if len(saved.saved) != 1 || saved.saved[0] != 1 {
+		t.Errorf("saved = %v, want only post 1", saved.saved)
+	}
+}
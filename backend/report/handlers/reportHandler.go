@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type ReportHandler interface {
+	ReportPost(c echo.Context) error
+	ReportUser(c echo.Context) error
+	GetModerationQueue(c echo.Context) error
+	Resolve(c echo.Context) error
+	Dismiss(c echo.Context) error
+}
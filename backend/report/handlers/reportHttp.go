@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/validator"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/report/repository"
+	"DiplomaV2/backend/report/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type reportHttpHandler struct {
+	reportUseCase usecase.ReportUseCase
+}
+
+type reportInput struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (r *reportHttpHandler) ReportPost(c echo.Context) error {
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid post id"})
+	}
+
+	report, v, err := r.bindReport(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	if err := r.reportUseCase.ReportPost(report, postID); err != nil {
+		return r.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Report submitted"})
+}
+
+func (r *reportHttpHandler) ReportUser(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+	}
+
+	report, v, err := r.bindReport(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	if err := r.reportUseCase.ReportUser(report, userID); err != nil {
+		return r.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Report submitted"})
+}
+
+func (r *reportHttpHandler) GetModerationQueue(c echo.Context) error {
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 20, v),
+		Sort:         helpers.ReadString(qs, "sort", "created_at"),
+		SortSafeList: []string{"created_at", "-created_at"},
+	}
+
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	reports, metadata, err := r.reportUseCase.GetModerationQueue(filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	type Response struct {
+		Reports  []*entity.Report     `json:"reports"`
+		Metadata postsFilter.Metadata `json:"metadata"`
+	}
+
+	return c.JSON(http.StatusOK, Response{Reports: reports, Metadata: metadata})
+}
+
+func (r *reportHttpHandler) Resolve(c echo.Context) error {
+	return r.review(c, r.reportUseCase.Resolve, "Report resolved")
+}
+
+func (r *reportHttpHandler) Dismiss(c echo.Context) error {
+	return r.review(c, r.reportUseCase.Dismiss, "Report dismissed")
+}
+
+func (r *reportHttpHandler) review(c echo.Context, action func(reportID, moderatorID int64) error, message string) error {
+	reportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid report id"})
+	}
+
+	moderatorID := c.Get("userID").(int64)
+
+	if err := action(reportID, moderatorID); err != nil {
+		return r.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": message})
+}
+
+func (r *reportHttpHandler) bindReport(c echo.Context) (*entity.Report, *validator.Validator, error) {
+	var input reportInput
+	if err := c.Bind(&input); err != nil {
+		return nil, nil, err
+	}
+
+	report := &entity.Report{
+		ReporterID: c.Get("userID").(int64),
+		Reason:     input.Reason,
+		Details:    input.Details,
+	}
+
+	v := validator.New()
+	validator.ValidateReport(v, report)
+	return report, v, nil
+}
+
+func (r *reportHttpHandler) errorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrReportLimitExceeded):
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrAlreadyReported), errors.Is(err, usecase.ErrReportClosed):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCannotReportSelf):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, postRepository.ErrPostNotFound), errors.Is(err, repository.ErrReportNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func NewReportHttpHandler(reportUseCase usecase.ReportUseCase) ReportHandler {
+	return &reportHttpHandler{
+		reportUseCase: reportUseCase,
+	}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"time"
+)
+
+type ReportRepository interface {
+	Insert(report *entity.Report) error
+	GetByID(id int64) (*entity.Report, error)
+	Update(report *entity.Report) error
+	GetPending(filters postsFilter.Filters) ([]*entity.Report, postsFilter.Metadata, error)
+	CountByReporterSince(reporterID int64, since time.Time) (int64, error)
+	ExistsPending(reporterID int64, postID, userID *int64) (bool, error)
+	CountPendingReportersForPost(postID int64) (int64, error)
+	SetPostHidden(postID int64, hidden bool) error
+	ResolveAllForTarget(report *entity.Report, status string, moderatorID int64) error
+}
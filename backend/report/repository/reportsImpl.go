@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"math"
+	"time"
+)
+
+var (
+	ErrReportNotFound = errors.New("report not found")
+)
+
+type reportRepository struct {
+	DB database.Database
+}
+
+func NewReportRepository(db database.Database) ReportRepository {
+	return &reportRepository{DB: db}
+}
+
+func (r *reportRepository) Insert(report *entity.Report) error {
+	return r.DB.GetDb().Create(report).Error
+}
+
+func (r *reportRepository) GetByID(id int64) (*entity.Report, error) {
+	var report entity.Report
+	if err := r.DB.GetDb().First(&report, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepository) Update(report *entity.Report) error {
+	return r.DB.GetDb().Save(report).Error
+}
+
+func (r *reportRepository) GetPending(filters postsFilter.Filters) ([]*entity.Report, postsFilter.Metadata, error) {
+	var reports []*entity.Report
+	query := r.DB.GetDb().Model(&entity.Report{}).Where("status = ?", entity.ReportStatusPending)
+
+	var totalRecords int64
+	countQuery := *query
+	if err := countQuery.Count(&totalRecords).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s", filters.SortColumn(), filters.SortDirection())).
+		Offset((filters.Page - 1) * filters.PageSize).
+		Limit(filters.PageSize)
+
+	if err := query.Find(&reports).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	return reports, calculateMetadata(int(totalRecords), filters.Page, filters.PageSize), nil
+}
+
+func (r *reportRepository) CountByReporterSince(reporterID int64, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.GetDb().Model(&entity.Report{}).
+		Where("reporter_id = ? AND created_at > ?", reporterID, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *reportRepository) ExistsPending(reporterID int64, postID, userID *int64) (bool, error) {
+	query := r.DB.GetDb().Model(&entity.Report{}).
+		Where("reporter_id = ? AND status = ?", reporterID, entity.ReportStatusPending)
+	if postID != nil {
+		query = query.Where("target_post_id = ?", *postID)
+	}
+	if userID != nil {
+		query = query.Where("target_user_id = ?", *userID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *reportRepository) CountPendingReportersForPost(postID int64) (int64, error) {
+	var count int64
+	err := r.DB.GetDb().Model(&entity.Report{}).
+		Where("target_post_id = ? AND status = ?", postID, entity.ReportStatusPending).
+		Distinct("reporter_id").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *reportRepository) SetPostHidden(postID int64, hidden bool) error {
+	return r.DB.GetDb().Model(&entity.Post{}).Where("id = ?", postID).Update("hidden", hidden).Error
+}
+
+// ResolveAllForTarget closes every pending report that points at the same post
+// or user as the given report, so a single moderator decision clears the queue.
+func (r *reportRepository) ResolveAllForTarget(report *entity.Report, status string, moderatorID int64) error {
+	query := r.DB.GetDb().Model(&entity.Report{}).Where("status = ?", entity.ReportStatusPending)
+	if report.TargetPostID != nil {
+		query = query.Where("target_post_id = ?", *report.TargetPostID)
+	} else {
+		query = query.Where("target_user_id = ?", *report.TargetUserID)
+	}
+
+	return query.Updates(map[string]interface{}{
+		"status":         status,
+		"resolved_by_id": moderatorID,
+		"resolved_at":    time.Now(),
+	}).Error
+}
+
+func calculateMetadata(totalRecords, page, pageSize int) postsFilter.Metadata {
+	if totalRecords == 0 {
+		return postsFilter.Metadata{}
+	}
+	return postsFilter.Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
@@ -0,0 +1,14 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+)
+
+type ReportUseCase interface {
+	ReportPost(report *entity.Report, postID int64) error
+	ReportUser(report *entity.Report, userID int64) error
+	GetModerationQueue(filters postsFilter.Filters) ([]*entity.Report, postsFilter.Metadata, error)
+	Resolve(reportID, moderatorID int64) error
+	Dismiss(reportID, moderatorID int64) error
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/report/repository"
+	userRepository "DiplomaV2/backend/user/repository"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var (
+	ErrReportLimitExceeded = errors.New("too many reports, try again later")
+	ErrAlreadyReported     = errors.New("you have already reported this")
+	ErrCannotReportSelf    = errors.New("you cannot report yourself or your own post")
+	ErrReportClosed        = errors.New("report has already been reviewed")
+)
+
+type reportUseCaseImpl struct {
+	repo     repository.ReportRepository
+	postRepo postRepository.PostRepository
+	userRepo userRepository.UserRepository
+	conf     *config.Moderation
+	bus      *events.Bus
+}
+
+func NewReportUseCase(repo repository.ReportRepository, postRepo postRepository.PostRepository, userRepo userRepository.UserRepository, conf *config.Moderation, bus *events.Bus) ReportUseCase {
+	return &reportUseCaseImpl{
+		repo:     repo,
+		postRepo: postRepo,
+		userRepo: userRepo,
+		conf:     conf,
+		bus:      bus,
+	}
+}
+
+func (r *reportUseCaseImpl) ReportPost(report *entity.Report, postID int64) error {
+	post, err := r.postRepo.GetByID(postID)
+	if err != nil {
+		return err
+	}
+	// Drafts and hidden posts can't be reported, nor shown to exist.
+	if !post.VisibleTo(report.ReporterID) {
+		return postRepository.ErrPostNotFound
+	}
+	if post.AuthorID == report.ReporterID {
+		return ErrCannotReportSelf
+	}
+
+	report.TargetPostID = &post.ID
+	if err := r.insert(report); err != nil {
+		return err
+	}
+
+	reporters, err := r.repo.CountPendingReportersForPost(post.ID)
+	if err != nil {
+		return err
+	}
+	if int(reporters) >= r.conf.HideThreshold && !post.Hidden {
+		return r.setPostHidden(post, true)
+	}
+	return nil
+}
+
+func (r *reportUseCaseImpl) ReportUser(report *entity.Report, userID int64) error {
+	if userID == report.ReporterID {
+		return ErrCannotReportSelf
+	}
+
+	user, err := r.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	report.TargetUserID = &user.ID
+	return r.insert(report)
+}
+
+func (r *reportUseCaseImpl) GetModerationQueue(filters postsFilter.Filters) ([]*entity.Report, postsFilter.Metadata, error) {
+	return r.repo.GetPending(filters)
+}
+
+// Resolve confirms the reports against a target. A hidden post stays hidden.
+func (r *reportUseCaseImpl) Resolve(reportID, moderatorID int64) error {
+	report, err := r.getPending(reportID)
+	if err != nil {
+		return err
+	}
+	return r.repo.ResolveAllForTarget(report, entity.ReportStatusResolved, moderatorID)
+}
+
+// Dismiss rejects the reports against a target and makes a hidden post visible again.
+func (r *reportUseCaseImpl) Dismiss(reportID, moderatorID int64) error {
+	report, err := r.getPending(reportID)
+	if err != nil {
+		return err
+	}
+
+	err = r.repo.ResolveAllForTarget(report, entity.ReportStatusDismissed, moderatorID)
+	if err != nil {
+		return err
+	}
+
+	if report.TargetPostID == nil {
+		return nil
+	}
+	post, err := r.postRepo.GetByID(*report.TargetPostID)
+	if errors.Is(err, postRepository.ErrPostNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !post.Hidden {
+		return nil
+	}
+	return r.setPostHidden(post, false)
+}
+
+// setPostHidden publishes the change as a post update, so open post streams
+// drop or show the post.
+func (r *reportUseCaseImpl) setPostHidden(post *entity.Post, hidden bool) error {
+	if err := r.repo.SetPostHidden(post.ID, hidden); err != nil {
+		return err
+	}
+	post.Hidden = hidden
+	r.bus.Publish(events.PostUpdated{Post: *post})
+	return nil
+}
+
+func (r *reportUseCaseImpl) insert(report *entity.Report) error {
+	count, err := r.repo.CountByReporterSince(report.ReporterID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if int(count) >= r.conf.ReportsPerHour {
+		return ErrReportLimitExceeded
+	}
+
+	exists, err := r.repo.ExistsPending(report.ReporterID, report.TargetPostID, report.TargetUserID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadyReported
+	}
+
+	report.Status = entity.ReportStatusPending
+	return r.repo.Insert(report)
+}
+
+func (r *reportUseCaseImpl) getPending(reportID int64) (*entity.Report, error) {
+	report, err := r.repo.GetByID(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != entity.ReportStatusPending {
+		return nil, ErrReportClosed
+	}
+	return report, nil
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	postRepository "DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/report/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+type fakePostRepo struct {
+	postRepository.PostRepository
+	posts map[int64]*entity.Post
+}
+
+func (r *fakePostRepo) GetByID(id int64) (*entity.Post, error) {
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, postRepository.ErrPostNotFound
+	}
+	copied := *post
+	return &copied, nil
+}
+
+type fakeReportRepo struct {
+	repository.ReportRepository
+	reports []*entity.Report
+	posts   *fakePostRepo
+}
+
+func (r *fakeReportRepo) Insert(report *entity.Report) error {
+	report.ID = int64(len(r.reports) + 1)
+	report.CreatedAt = time.Now()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func (r *fakeReportRepo) CountByReporterSince(reporterID int64, since time.Time) (int64, error) {
+	var count int64
+	for _, report := range r.reports {
+		if report.ReporterID == reporterID && report.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeReportRepo) ExistsPending(reporterID int64, postID, userID *int64) (bool, error) {
+	for _, report := range r.reports {
+		if report.ReporterID == reporterID && report.Status == entity.ReportStatusPending &&
+			sameTarget(report.TargetPostID, postID) && sameTarget(report.TargetUserID, userID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func sameTarget(a, b *int64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func (r *fakeReportRepo) CountPendingReportersForPost(postID int64) (int64, error) {
+	reporters := make(map[int64]bool)
+	for _, report := range r.reports {
+		if report.Status == entity.ReportStatusPending && sameTarget(report.TargetPostID, &postID) {
+			reporters[report.ReporterID] = true
+		}
+	}
+	return int64(len(reporters)), nil
+}
+
+func (r *fakeReportRepo) SetPostHidden(postID int64, hidden bool) error {
+	r.posts.posts[postID].Hidden = hidden
+	return nil
+}
+
+func (r *fakeReportRepo) GetByID(id int64) (*entity.Report, error) {
+	return r.reports[id-1], nil
+}
+
+func (r *fakeReportRepo) ResolveAllForTarget(report *entity.Report, status string, _ int64) error {
+	for _, other := range r.reports {
+		if other.Status == entity.ReportStatusPending && sameTarget(other.TargetPostID, report.TargetPostID) &&
+			sameTarget(other.TargetUserID, report.TargetUserID) {
+			other.Status = status
+		}
+	}
+	return nil
+}
+
+func newTestUseCase() (ReportUseCase, *fakeReportRepo, *fakePostRepo) {
+	return newTestUseCaseWithBus(events.New(echo.New().Logger))
+}
+
+func newTestUseCaseWithBus(bus *events.Bus) (ReportUseCase, *fakeReportRepo, *fakePostRepo) {
+	posts := &fakePostRepo{posts: map[int64]*entity.Post{
+		1: {ID: 1, AuthorID: 1, Author: entity.User{ID: 1}, Status: entity.PostStatusOpen},
+		2: {ID: 2, AuthorID: 1, Author: entity.User{ID: 1}, Status: entity.PostStatusOpen},
+		3: {ID: 3, AuthorID: 1, Author: entity.User{ID: 1}, Status: entity.PostStatusDraft},
+	}}
+	reports := &fakeReportRepo{posts: posts}
+	uc := NewReportUseCase(reports, posts, nil, &config.Moderation{HideThreshold: 3, ReportsPerHour: 2}, bus)
+	return uc, reports, posts
+}
+
+func report(reporterID int64) *entity.Report {
+	return &entity.Report{ReporterID: reporterID, Reason: entity.ReportReasonSpam}
+}
+
+func TestReportPostHidesAtThreshold(t *testing.T) {
+	uc, _, posts := newTestUseCase()
+
+	for reporterID := int64(2); reporterID <= 3; reporterID++ {
+		if err := uc.ReportPost(report(reporterID), 1); err != nil {
+			t.Fatalf("report by %d: %v", reporterID, err)
+		}
+	}
+	if posts.posts[1].Hidden {
+		t.Fatal("the post must stay visible below the threshold")
+	}
+
+	if err := uc.ReportPost(report(2), 1); !errors.Is(err, ErrAlreadyReported) {
+		t.Fatalf("second report by the same user: err = %v, want ErrAlreadyReported", err)
+	}
+	if posts.posts[1].Hidden {
+		t.Fatal("repeated reports by one user must not count towards the threshold")
+	}
+
+	if err := uc.ReportPost(report(4), 1); err != nil {
+		t.Fatal(err)
+	}
+	if !posts.posts[1].Hidden {
+		t.Error("the post should be hidden once enough users reported it")
+	}
+}
+
+func TestReportPostLimitsReportsPerHour(t *testing.T) {
+	uc, reports, posts := newTestUseCase()
+	posts.posts[4] = &entity.Post{ID: 4, AuthorID: 1, Author: entity.User{ID: 1}, Status: entity.PostStatusOpen}
+
+	if err := uc.ReportPost(report(2), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.ReportPost(report(2), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.ReportPost(report(2), 4); !errors.Is(err, ErrReportLimitExceeded) {
+		t.Fatalf("err = %v, want ErrReportLimitExceeded", err)
+	}
+
+	reports.reports[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	if err := uc.ReportPost(report(2), 1); !errors.Is(err, ErrAlreadyReported) {
+		t.Fatalf("after an old report expired from the window: err = %v, want ErrAlreadyReported", err)
+	}
+}
+
+func TestReportPostRejectsInvisiblePosts(t *testing.T) {
+	uc, _, posts := newTestUseCase()
+	posts.posts[2].Hidden = true
+
+	for _, postID := range []int64{2, 3} {
+		if err := uc.ReportPost(report(2), postID); !errors.Is(err, postRepository.ErrPostNotFound) {
+			t.Errorf("post %d: err = %v, want ErrPostNotFound", postID, err)
+		}
+	}
+	if err := uc.ReportPost(report(1), 1); !errors.Is(err, ErrCannotReportSelf) {
+		t.Errorf("own post: err = %v, want ErrCannotReportSelf", err)
+	}
+}
+
+func TestHidingAndDismissingPublishPostUpdates(t *testing.T) {
+	bus := events.New(echo.New().Logger)
+	var updates []entity.Post
+	events.Subscribe(bus, func(event events.PostUpdated) error {
+		updates = append(updates, event.Post)
+		return nil
+	})
+	uc, _, posts := newTestUseCaseWithBus(bus)
+
+	for reporterID := int64(2); reporterID <= 4; reporterID++ {
+		if err := uc.ReportPost(report(reporterID), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(updates) != 1 || updates[0].ID != 1 || !updates[0].Hidden {
+		t.Fatalf("updates = %+v, want post 1 hidden", updates)
+	}
+
+	if err := uc.Dismiss(1, 9); err != nil {
+		t.Fatal(err)
+	}
+	if posts.posts[1].Hidden {
+		t.Fatal("dismissing the reports must show the post again")
+	}
+	if len(updates) != 2 || updates[1].ID != 1 || updates[1].Hidden {
+		t.Fatalf("updates = %+v, want post 1 shown again", updates)
+	}
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type SearchHandler interface {
+	CreateSearch(c echo.Context) error
+	GetSearches(c echo.Context) error
+	UpdateSearch(c echo.Context) error
+	DeleteSearch(c echo.Context) error
+	Unsubscribe(c echo.Context) error
+}
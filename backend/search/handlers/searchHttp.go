@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/search/repository"
+	"DiplomaV2/backend/search/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type searchHttpHandler struct {
+	searchUseCase usecase.SearchUseCase
+}
+
+type searchInput struct {
+	Name        string   `json:"name"`
+	PostName    string   `json:"postName"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Skills      []string `json:"skills"`
+	SkillLevels []string `json:"skillLevels"`
+	Roles       []string `json:"roles"`
+	WorkMode    string   `json:"workMode"`
+	City        string   `json:"city"`
+	Commitment  string   `json:"commitment"`
+	Stage       string   `json:"stage"`
+	OpenSlots   int      `json:"openSlots"`
+	Frequency   string   `json:"frequency"`
+	// EmailEnabled is only used on update; new searches are subscribed.
+	EmailEnabled *bool `json:"emailEnabled"`
+}
+
+func (s *searchHttpHandler) CreateSearch(c echo.Context) error {
+	search, v, err := s.bindSearch(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	if err := s.searchUseCase.CreateSearch(search); err != nil {
+		return s.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, search)
+}
+
+func (s *searchHttpHandler) GetSearches(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	searches, err := s.searchUseCase.GetSearches(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"searches": searches})
+}
+
+func (s *searchHttpHandler) UpdateSearch(c echo.Context) error {
+	searchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid search id"})
+	}
+
+	search, v, err := s.bindSearch(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+	search.ID = searchID
+
+	if err := s.searchUseCase.UpdateSearch(search.UserID, search); err != nil {
+		return s.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, search)
+}
+
+func (s *searchHttpHandler) DeleteSearch(c echo.Context) error {
+	searchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid search id"})
+	}
+
+	userID := c.Get("userID").(int64)
+
+	if err := s.searchUseCase.DeleteSearch(userID, searchID); err != nil {
+		return s.errorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *searchHttpHandler) Unsubscribe(c echo.Context) error {
+	search, err := s.searchUseCase.Unsubscribe(c.QueryParam("token"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidUnsubscribe) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "You will no longer receive emails for \"" + search.Name + "\""})
+}
+
+func (s *searchHttpHandler) bindSearch(c echo.Context) (*entity.SavedSearch, *validator.Validator, error) {
+	var input searchInput
+	if err := c.Bind(&input); err != nil {
+		return nil, nil, err
+	}
+
+	search := &entity.SavedSearch{
+		UserID:       c.Get("userID").(int64),
+		Name:         strings.TrimSpace(input.Name),
+		PostName:     input.PostName,
+		Description:  input.Description,
+		Type:         strings.ToLower(input.Type),
+		Skills:       input.Skills,
+		SkillLevels:  input.SkillLevels,
+		Roles:        input.Roles,
+		WorkMode:     input.WorkMode,
+		City:         strings.TrimSpace(input.City),
+		Commitment:   input.Commitment,
+		Stage:        input.Stage,
+		OpenSlots:    input.OpenSlots,
+		Frequency:    input.Frequency,
+		EmailEnabled: true,
+	}
+	if search.Frequency == "" {
+		search.Frequency = entity.SearchFrequencyDaily
+	}
+	if input.EmailEnabled != nil {
+		search.EmailEnabled = *input.EmailEnabled
+	}
+
+	v := validator.New()
+	validator.ValidateSavedSearch(v, search)
+	return search, v, nil
+}
+
+func (s *searchHttpHandler) errorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSearchNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNotSearchOwner):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrTooManySearches):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func NewSearchHttpHandler(searchUseCase usecase.SearchUseCase) SearchHandler {
+	return &searchHttpHandler{
+		searchUseCase: searchUseCase,
+	}
+}
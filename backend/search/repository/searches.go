@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"time"
+)
+
+type SearchRepository interface {
+	Insert(search *entity.SavedSearch) error
+	GetByID(id int64) (*entity.SavedSearch, error)
+	GetAllForUser(userID int64) ([]*entity.SavedSearch, error)
+	CountForUser(userID int64) (int64, error)
+	Update(search *entity.SavedSearch) error
+	Delete(id int64) error
+	GetDue(now time.Time) ([]*entity.SavedSearch, error)
+	MarkRun(id int64, at time.Time) error
+}
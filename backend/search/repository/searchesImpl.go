@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrSearchNotFound = errors.New("saved search not found")
+
+type searchRepository struct {
+	DB database.Database
+}
+
+func NewSearchRepository(db database.Database) SearchRepository {
+	return &searchRepository{DB: db}
+}
+
+func (r *searchRepository) Insert(search *entity.SavedSearch) error {
+	return r.DB.GetDb().Omit("User").Create(search).Error
+}
+
+func (r *searchRepository) GetByID(id int64) (*entity.SavedSearch, error) {
+	var search entity.SavedSearch
+	if err := r.DB.GetDb().First(&search, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSearchNotFound
+		}
+		return nil, err
+	}
+	return &search, nil
+}
+
+func (r *searchRepository) GetAllForUser(userID int64) ([]*entity.SavedSearch, error) {
+	var searches []*entity.SavedSearch
+	if err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+func (r *searchRepository) CountForUser(userID int64) (int64, error) {
+	var count int64
+	err := r.DB.GetDb().Model(&entity.SavedSearch{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *searchRepository) Update(search *entity.SavedSearch) error {
+	return r.DB.GetDb().Omit("User").Save(search).Error
+}
+
+func (r *searchRepository) Delete(id int64) error {
+	result := r.DB.GetDb().Delete(&entity.SavedSearch{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSearchNotFound
+	}
+	return nil
+}
+
+// GetDue returns the searches whose frequency period has passed since their
+// last run, with their active, activated owners preloaded. Searches with
+// email turned off are included; they still notify in the app.
+func (r *searchRepository) GetDue(now time.Time) ([]*entity.SavedSearch, error) {
+	var searches []*entity.SavedSearch
+	result := r.DB.GetDb().
+		Joins("User").
+		Where(`"User".activated = ?`, true).
+		Where(r.DB.GetDb().
+			Where("saved_searches.frequency = ?", entity.SearchFrequencyInstant).
+			Or("saved_searches.frequency = ? AND saved_searches.last_run_at <= ?", entity.SearchFrequencyDaily, now.Add(-24*time.Hour)).
+			Or("saved_searches.frequency = ? AND saved_searches.last_run_at <= ?", entity.SearchFrequencyWeekly, now.Add(-7*24*time.Hour))).
+		Find(&searches)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return searches, nil
+}
+
+func (r *searchRepository) MarkRun(id int64, at time.Time) error {
+	return r.DB.GetDb().Model(&entity.SavedSearch{}).Where("id = ?", id).Update("last_run_at", at).Error
+}
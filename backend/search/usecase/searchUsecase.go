@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"golang.org/x/net/context"
+)
+
+type SearchUseCase interface {
+	CreateSearch(search *entity.SavedSearch) error
+	GetSearches(userID int64) ([]*entity.SavedSearch, error)
+	UpdateSearch(userID int64, search *entity.SavedSearch) error
+	DeleteSearch(userID, searchID int64) error
+	Unsubscribe(token string) (*entity.SavedSearch, error)
+	SendDigests(ctx context.Context) error
+}
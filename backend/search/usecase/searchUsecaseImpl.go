@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/mailer"
+	notificationUseCase "DiplomaV2/backend/notification/usecase"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/search/repository"
+	skillUseCase "DiplomaV2/backend/skill/usecase"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooManySearches    = errors.New("saved search limit reached")
+	ErrNotSearchOwner     = errors.New("saved search doesn't belong to this user")
+	ErrInvalidUnsubscribe = errors.New("unsubscribe link is invalid")
+)
+
+// digestSize caps the number of posts listed in one digest email.
+const digestSize = 20
+
+type searchUseCaseImpl struct {
+	repo        repository.SearchRepository
+	postRepo    postRepository.PostRepository
+	skills      skillUseCase.SkillUseCase
+	notifier    notificationUseCase.NotificationUseCase
+	mailer      mailer.Mailer
+	conf        *config.Searches
+	frontendURL string
+	publicURL   string
+}
+
+func NewSearchUseCase(repo repository.SearchRepository, postRepo postRepository.PostRepository, skills skillUseCase.SkillUseCase, notifier notificationUseCase.NotificationUseCase, mailer mailer.Mailer, conf *config.Searches, server *config.Server) SearchUseCase {
+	return &searchUseCaseImpl{
+		repo:        repo,
+		postRepo:    postRepo,
+		skills:      skills,
+		notifier:    notifier,
+		mailer:      mailer,
+		conf:        conf,
+		frontendURL: server.FrontendURL,
+		publicURL:   server.PublicURL,
+	}
+}
+
+func (s *searchUseCaseImpl) CreateSearch(search *entity.SavedSearch) error {
+	count, err := s.repo.CountForUser(search.UserID)
+	if err != nil {
+		return err
+	}
+	if count >= int64(s.conf.MaxPerUser) {
+		return ErrTooManySearches
+	}
+
+	if err := s.normalize(search); err != nil {
+		return err
+	}
+	// Only posts published from now on are mailed.
+	search.LastRunAt = time.Now()
+	search.EmailEnabled = true
+	return s.repo.Insert(search)
+}
+
+func (s *searchUseCaseImpl) GetSearches(userID int64) ([]*entity.SavedSearch, error) {
+	return s.repo.GetAllForUser(userID)
+}
+
+func (s *searchUseCaseImpl) UpdateSearch(userID int64, search *entity.SavedSearch) error {
+	existing, err := s.getOwned(userID, search.ID)
+	if err != nil {
+		return err
+	}
+
+	search.UserID = existing.UserID
+	search.CreatedAt = existing.CreatedAt
+	search.LastRunAt = existing.LastRunAt
+	if err := s.normalize(search); err != nil {
+		return err
+	}
+	return s.repo.Update(search)
+}
+
+func (s *searchUseCaseImpl) DeleteSearch(userID, searchID int64) error {
+	if _, err := s.getOwned(userID, searchID); err != nil {
+		return err
+	}
+	return s.repo.Delete(searchID)
+}
+
+// Unsubscribe turns off the emails of the search named by a signed token,
+// so the link in a digest works without logging in.
+func (s *searchUseCaseImpl) Unsubscribe(token string) (*entity.SavedSearch, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(id))) {
+		return nil, ErrInvalidUnsubscribe
+	}
+
+	searchID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidUnsubscribe
+	}
+
+	search, err := s.repo.GetByID(searchID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSearchNotFound) {
+			return nil, ErrInvalidUnsubscribe
+		}
+		return nil, err
+	}
+
+	search.EmailEnabled = false
+	if err := s.repo.Update(search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// SendDigests mails the posts published since the last run of every due
+// search. Searches without new posts are only marked as run.
+func (s *searchUseCaseImpl) SendDigests(ctx context.Context) error {
+	now := time.Now()
+	searches, err := s.repo.GetDue(now)
+	if err != nil {
+		return err
+	}
+
+	// One failing search must not hold back the others; the failures are
+	// reported together.
+	var failed []error
+	for _, search := range searches {
+		if ctx.Err() != nil {
+			failed = append(failed, ctx.Err())
+			break
+		}
+		if err := s.runSearch(search, now); err != nil {
+			failed = append(failed, fmt.Errorf("saved search %d: %w", search.ID, err))
+		}
+	}
+	return stderrors.Join(failed...)
+}
+
+// runSearch notifies the owner of the posts new since the last run. The
+// search is marked as run right after the in-app notification, so a failing
+// mail isn't retried together with a second notification for the same posts.
+func (s *searchUseCaseImpl) runSearch(search *entity.SavedSearch, now time.Time) error {
+	posts, total, err := s.newPosts(search)
+	if err != nil {
+		return err
+	}
+
+	if len(posts) > 0 {
+		if err := s.notifyInApp(search, posts, total); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.MarkRun(search.ID, now); err != nil {
+		return err
+	}
+
+	if len(posts) == 0 {
+		return nil
+	}
+	return s.mailDigest(search, posts, total)
+}
+
+func (s *searchUseCaseImpl) notifyInApp(search *entity.SavedSearch, posts []*entity.Post, total int) error {
+	return s.notifier.Notify(&entity.Notification{
+		UserID: search.UserID,
+		Type:   entity.NotificationNewMatchingPost,
+		Title:  fmt.Sprintf("%d new post(s) match \"%s\"", total, search.Name),
+		Body:   posts[0].Name,
+		Link:   "/",
+	}, entity.ChannelInApp)
+}
+
+// mailDigest mails the new matches if email is on for the search and the
+// owner wants new matching posts by email.
+func (s *searchUseCaseImpl) mailDigest(search *entity.SavedSearch, posts []*entity.Post, total int) error {
+	if !search.EmailEnabled {
+		return nil
+	}
+	wantsEmail, err := s.notifier.Wants(search.UserID, entity.NotificationNewMatchingPost, entity.ChannelEmail)
+	if err != nil || !wantsEmail {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"searchName":      search.Name,
+		"posts":           posts,
+		"total":           total,
+		"more":            total - len(posts),
+		"browseLink":      s.frontendURL + "/",
+		"unsubscribeLink": fmt.Sprintf("%s/v2/searches/unsubscribe?token=%s", s.publicURL, url.QueryEscape(s.unsubscribeToken(search.ID))),
+	}
+	return s.mailer.Send(search.User.Email, "saved_search_digest.tmpl", data)
+}
+
+func (s *searchUseCaseImpl) newPosts(search *entity.SavedSearch) ([]*entity.Post, int, error) {
+	example := &entity.Post{
+		PublishedAt: &search.LastRunAt,
+		Name:        search.PostName,
+		Description: search.Description,
+		Type:        search.Type,
+		Skills:      search.Skills,
+		Roles:       search.Roles,
+		WorkMode:    search.WorkMode,
+		City:        search.City,
+		Commitment:  search.Commitment,
+		Stage:       search.Stage,
+		OpenSlots:   search.OpenSlots,
+		Status:      entity.PostStatusOpen,
+	}
+
+	filters := postsFilter.Filters{
+		Page:         1,
+		PageSize:     digestSize,
+		Sort:         "-published_at",
+		SortSafeList: []string{"-published_at"},
+	}
+
+	posts, metadata, err := s.postRepo.GetFilteredPosts(example, levelFilters(search.SkillLevels), filters, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The owner's own posts are never worth a notification.
+	matched := posts[:0]
+	for _, post := range posts {
+		if post.AuthorID != search.UserID {
+			matched = append(matched, post)
+		}
+	}
+	total := metadata.TotalRecords - (len(posts) - len(matched))
+	return matched, total, nil
+}
+
+func (s *searchUseCaseImpl) normalize(search *entity.SavedSearch) error {
+	skills, err := s.skills.Normalize(search.Skills)
+	if err != nil {
+		return err
+	}
+	search.Skills = skills
+
+	for i, pair := range search.SkillLevels {
+		skill, level, _ := strings.Cut(pair, ":")
+		canonical, err := s.skills.Canonical(skill)
+		if err != nil {
+			return err
+		}
+		search.SkillLevels[i] = canonical + ":" + level
+	}
+	return nil
+}
+
+func (s *searchUseCaseImpl) getOwned(userID, searchID int64) (*entity.SavedSearch, error) {
+	search, err := s.repo.GetByID(searchID)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != userID {
+		return nil, ErrNotSearchOwner
+	}
+	return search, nil
+}
+
+func (s *searchUseCaseImpl) unsubscribeToken(searchID int64) string {
+	id := strconv.FormatInt(searchID, 10)
+	return id + "." + s.sign(id)
+}
+
+func (s *searchUseCaseImpl) sign(id string) string {
+	secret := s.conf.UnsubscribeSecret
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("saved-search-unsubscribe:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func levelFilters(pairs []string) []entity.SkillLevelFilter {
+	filters := make([]entity.SkillLevelFilter, 0, len(pairs))
+	for _, pair := range pairs {
+		skill, level, _ := strings.Cut(pair, ":")
+		filters = append(filters, entity.SkillLevelFilter{Skill: skill, Level: level})
+	}
+	return filters
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/mailer"
+	notificationUseCase "DiplomaV2/backend/notification/usecase"
+	postsFilter "DiplomaV2/backend/post"
+	postRepository "DiplomaV2/backend/post/repository"
+	"DiplomaV2/backend/search/repository"
+	"golang.org/x/net/context"
+	"testing"
+	"time"
+)
+
+type fakeSearchRepo struct {
+	repository.SearchRepository
+	due []*entity.SavedSearch
+	run map[int64]time.Time
+}
+
+func (r *fakeSearchRepo) GetDue(time.Time) ([]*entity.SavedSearch, error) {
+	return r.due, nil
+}
+
+func (r *fakeSearchRepo) MarkRun(id int64, at time.Time) error {
+	r.run[id] = at
+	return nil
+}
+
+// fakePostRepo returns posts for any query and keeps the example it got.
+type fakePostRepo struct {
+	postRepository.PostRepository
+	posts    []*entity.Post
+	examples []entity.Post
+}
+
+func (r *fakePostRepo) GetFilteredPosts(post *entity.Post, _ []entity.SkillLevelFilter, _ postsFilter.Filters, _ int64) ([]*entity.Post, postsFilter.Metadata, error) {
+	r.examples = append(r.examples, *post)
+	return r.posts, postsFilter.Metadata{TotalRecords: len(r.posts)}, nil
+}
+
+type fakeNotifier struct {
+	notificationUseCase.NotificationUseCase
+	notified []*entity.Notification
+	asked    int
+}
+
+func (n *fakeNotifier) Notify(notification *entity.Notification, _ ...string) error {
+	n.notified = append(n.notified, notification)
+	return nil
+}
+
+func (n *fakeNotifier) Wants(int64, string, string) (bool, error) {
+	n.asked++
+	return false, nil
+}
+
+func TestSendDigestsNotifiesInAppWithEmailOff(t *testing.T) {
+	lastRun := time.Now().Add(-time.Hour)
+	searches := &fakeSearchRepo{
+		due: []*entity.SavedSearch{{ID: 1, UserID: 1, Name: "go", EmailEnabled: false, LastRunAt: lastRun}},
+		run: make(map[int64]time.Time),
+	}
+	posts := &fakePostRepo{posts: []*entity.Post{{ID: 7, AuthorID: 2, Name: "Go team"}}}
+	notifier := &fakeNotifier{}
+	uc := NewSearchUseCase(searches, posts, nil, notifier, mailer.Mailer{}, &config.Searches{}, &config.Server{})
+
+	if err := uc.SendDigests(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notifier.notified) != 1 || notifier.notified[0].UserID != 1 {
+		t.Fatalf("notified = %+v, want one in-app notification for user 1", notifier.notified)
+	}
+	if notifier.asked != 0 {
+		t.Error("the email preference was checked for a search with email off")
+	}
+	if _, ok := searches.run[1]; !ok {
+		t.Error("the search wasn't marked as run")
+	}
+
+	example := posts.examples[0]
+	if example.PublishedAt == nil || !example.PublishedAt.Equal(lastRun) || !example.CreatedAt.IsZero() {
+		t.Errorf("example published after %v, created after %v; want posts published since the last run",
+			example.PublishedAt, example.CreatedAt)
+	}
+	if example.Status != entity.PostStatusOpen {
+		t.Errorf("example status = %q, want open", example.Status)
+	}
+}
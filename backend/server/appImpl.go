@@ -1,23 +1,56 @@
 package server
 
 import (
+	commentHandlers "DiplomaV2/backend/comment/handlers"
+	commentRepositories "DiplomaV2/backend/comment/repository"
+	commentUseCases "DiplomaV2/backend/comment/usecase"
+	exportHandlers "DiplomaV2/backend/export/handlers"
+	exportRepositories "DiplomaV2/backend/export/repository"
+	exportUseCases "DiplomaV2/backend/export/usecase"
+	identityHandlers "DiplomaV2/backend/identity/handlers"
+	identityRepositories "DiplomaV2/backend/identity/repository"
+	identityUseCases "DiplomaV2/backend/identity/usecase"
 	"DiplomaV2/backend/internal/config"
 	"DiplomaV2/backend/internal/database"
 	userModels "DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
 	"DiplomaV2/backend/internal/mailer"
 	mymiddleware "DiplomaV2/backend/internal/middleware"
+	"DiplomaV2/backend/internal/netguard"
+	"DiplomaV2/backend/internal/ratelimit"
+	"DiplomaV2/backend/internal/scheduler"
+	"DiplomaV2/backend/internal/sse"
+	"DiplomaV2/backend/internal/verify"
+	notificationHandlers "DiplomaV2/backend/notification/handlers"
+	notificationRepositories "DiplomaV2/backend/notification/repository"
+	notificationUseCases "DiplomaV2/backend/notification/usecase"
 	postHandlers "DiplomaV2/backend/post/handlers"
 	postRepositories "DiplomaV2/backend/post/repository"
 	postUseCases "DiplomaV2/backend/post/usecase"
+	reportHandlers "DiplomaV2/backend/report/handlers"
+	reportRepositories "DiplomaV2/backend/report/repository"
+	reportUseCases "DiplomaV2/backend/report/usecase"
+	searchHandlers "DiplomaV2/backend/search/handlers"
+	searchRepositories "DiplomaV2/backend/search/repository"
+	searchUseCases "DiplomaV2/backend/search/usecase"
+	skillHandlers "DiplomaV2/backend/skill/handlers"
+	skillRepositories "DiplomaV2/backend/skill/repository"
+	skillUseCases "DiplomaV2/backend/skill/usecase"
 	userHandlers "DiplomaV2/backend/user/handlers"
 	userRepositories "DiplomaV2/backend/user/repository"
 	tokenRepositories "DiplomaV2/backend/user/tokenRepository"
 	userUseCases "DiplomaV2/backend/user/usecase"
+	webhookHandlers "DiplomaV2/backend/webhook/handlers"
+	webhookRepositories "DiplomaV2/backend/webhook/repository"
+	webhookUseCases "DiplomaV2/backend/webhook/usecase"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
+	"golang.org/x/net/context"
+	"net"
 	"net/http"
+	"time"
 )
 
 type echoServer struct {
@@ -25,18 +58,57 @@ type echoServer struct {
 	db     database.Database
 	conf   *config.Config
 	mailer mailer.Mailer
+	skills skillUseCases.SkillUseCase
+	// notifications holds the SSE hub, so every handler and job shares one.
+	notifications notificationUseCases.NotificationUseCase
+	// events is shared so subscribers registered here see every publisher.
+	events *events.Bus
+	// postFeed keeps recent post events for the post stream.
+	postFeed *postUseCases.PostFeed
+	// postViews buffers post views until the flush job writes them.
+	postViews *postUseCases.ViewCounter
+	webhooks  webhookUseCases.WebhookUseCase
+	// verifiers holds the platforms with a configured bot.
+	verifiers map[string]verify.Verifier
 }
 
 func NewEchoServer(conf *config.Config, db database.Database) Server {
 	echoApp := echo.New()
 	echoApp.Logger.SetLevel(log.DEBUG)
+	echoApp.IPExtractor = newIPExtractor(conf.Server.TrustedProxies, echoApp.Logger)
 	appMailer := mailer.New("sandbox.smtp.mailtrap.io", 25, "b8c7b64d353ab5", "5692cb78f75c91", "Test <no-reply@test.com>")
 
+	notifications := notificationUseCases.NewNotificationUseCase(
+		notificationRepositories.NewNotificationRepository(db),
+		userRepositories.NewUserRepository(db),
+		appMailer,
+		sse.NewHub(),
+		conf.Server.FrontendURL,
+	)
+
+	bus := events.New(echoApp.Logger)
+	userUseCases.SubscribeMailer(bus, appMailer, conf.Tokens, conf.Server)
+
+	webhooks := webhookUseCases.NewWebhookUseCase(
+		webhookRepositories.NewWebhookRepository(db),
+		netguard.NewClient(conf.Webhooks.Timeout),
+		conf.Webhooks,
+	)
+	webhookUseCases.SubscribeEvents(bus, webhooks)
+	commentUseCases.SubscribeNotifications(bus, notifications)
+
 	return &echoServer{
-		app:    echoApp,
-		db:     db,
-		conf:   conf,
-		mailer: appMailer,
+		app:           echoApp,
+		db:            db,
+		conf:          conf,
+		mailer:        appMailer,
+		skills:        skillUseCases.NewSkillUseCase(skillRepositories.NewSkillRepository(db)),
+		notifications: notifications,
+		events:        bus,
+		postFeed:      postUseCases.NewPostFeed(bus, conf.Posts.FeedSize),
+		postViews:     postUseCases.NewViewCounter(postRepositories.NewPostViewRepository(db), conf.Posts.ViewDedupeSize),
+		webhooks:      webhooks,
+		verifiers:     newVerifiers(conf.Verification),
 	}
 }
 
@@ -59,9 +131,20 @@ func (s *echoServer) Start() {
 	})
 
 	s.initializeMigrations()
+	s.initializeSkills()
 
 	s.initializePostHttpHandler()
 	s.initializeUserHttpHandler()
+	s.initializeReportHttpHandler()
+	s.initializeIdentityHttpHandler()
+	s.initializeExportHttpHandler()
+	s.initializeSkillHttpHandler()
+	s.initializeSearchHttpHandler()
+	s.initializeNotificationHttpHandler()
+	s.initializeWebhookHttpHandler()
+	s.initializeCommentHttpHandler()
+
+	s.initializeJobs()
 
 	serverUrl := fmt.Sprintf(":%d", s.conf.Server.Port)
 	s.app.Logger.Fatal(s.app.Start(serverUrl))
@@ -72,6 +155,22 @@ func (s *echoServer) initializeMigrations() {
 		&userModels.User{},
 		&userModels.Post{},
 		&userModels.Token{},
+		&userModels.Report{},
+		&userModels.RateLimitBucket{},
+		&userModels.UserIdentity{},
+		&userModels.Skill{},
+		&userModels.UserSkill{},
+		&userModels.PostSkill{},
+		&userModels.SavedPost{},
+		&userModels.SavedSearch{},
+		&userModels.Notification{},
+		&userModels.NotificationPreference{},
+		&userModels.Webhook{},
+		&userModels.WebhookDelivery{},
+		&userModels.HandleVerification{},
+		&userModels.UsernameHistory{},
+		&userModels.PostView{},
+		&userModels.Comment{},
 	)
 	if err != nil {
 		return
@@ -79,42 +178,265 @@ func (s *echoServer) initializeMigrations() {
 }
 
 func (s *echoServer) initializeUserHttpHandler() {
-	userPostgresRepository := userRepositories.NewUserRepository(s.db)
-	tokenPostgresRepository := tokenRepositories.NewTokenRepository(s.db)
-	userUseCase := userUseCases.NewUserUseCase(userPostgresRepository, tokenPostgresRepository)
-	userHttpHandler := userHandlers.NewUserHttpHandler(userUseCase, s.mailer)
+	userUseCase := s.newUserUseCase()
+	mymiddleware.SessionValidator = userUseCase.ValidateSession
+	mymiddleware.RoleLookup = userUseCase.GetRole
+	accountLimiter := ratelimit.New(s.conf.RateLimit.Store, s.db, s.conf.RateLimit.AccountLimit, s.conf.RateLimit.AccountWindow)
+	ipLimiter := ratelimit.New(s.conf.RateLimit.Store, s.db, s.conf.RateLimit.IPLimit, s.conf.RateLimit.IPWindow)
+	userHttpHandler := userHandlers.NewUserHttpHandler(userUseCase, accountLimiter)
 
 	userRouters := s.app.Group("/v2/users")
 	{
-		userRouters.POST("/registration", userHttpHandler.Registration)
+		userRouters.POST("/registration", userHttpHandler.Registration, mymiddleware.RateLimitByIP(ipLimiter, "registration"))
 		userRouters.GET("/activate/:token", userHttpHandler.Activation)
-		userRouters.POST("/login", userHttpHandler.Authentication)
+		userRouters.POST("/activation/resend", userHttpHandler.ResendActivation, mymiddleware.RateLimitByIP(ipLimiter, "activation-resend"))
+		userRouters.POST("/login", userHttpHandler.Authentication, mymiddleware.RateLimitByIP(ipLimiter, "login"))
+		userRouters.POST("/login/2fa", userHttpHandler.VerifyTwoFactor, mymiddleware.RateLimitByIP(ipLimiter, "login-2fa"))
 		userRouters.GET("/check-auth", userHttpHandler.CheckAuth)
 		userRouters.GET("/", userHttpHandler.GetAllUsers, mymiddleware.LoginMiddleware)
-		userRouters.GET("/:id", userHttpHandler.GetUserInfoById, mymiddleware.LoginMiddleware)
+		userRouters.GET("/:id", userHttpHandler.GetUserInfoById, mymiddleware.OptionalLoginMiddleware)
+		userRouters.GET("/by-username/:username", userHttpHandler.GetUserByUsername, mymiddleware.OptionalLoginMiddleware)
+		userRouters.PUT("/privacy", userHttpHandler.UpdatePrivacy, mymiddleware.LoginMiddleware)
 		userRouters.GET("/my", userHttpHandler.GetMyInfo, mymiddleware.LoginMiddleware)
 		userRouters.PATCH("/update", userHttpHandler.UpdateUserInfo, mymiddleware.LoginMiddleware)
 		userRouters.PATCH("/password", userHttpHandler.ChangePassword, mymiddleware.LoginMiddleware)
+		userRouters.POST("/email", userHttpHandler.ChangeEmail, mymiddleware.LoginMiddleware)
+		userRouters.POST("/email/confirm", userHttpHandler.ConfirmEmailChange)
 		userRouters.POST("/logout", userHttpHandler.Logout, mymiddleware.LoginMiddleware)
 		userRouters.DELETE("/", userHttpHandler.DeleteUser, mymiddleware.LoginMiddleware)
-		userRouters.POST("/forgot-password", userHttpHandler.ForgotPassword)
+		userRouters.POST("/forgot-password", userHttpHandler.ForgotPassword, mymiddleware.RateLimitByIP(ipLimiter, "forgot-password"))
 		userRouters.POST("/reset-password", userHttpHandler.ResetPassword)
+		userRouters.POST("/2fa/enroll", userHttpHandler.EnrollTwoFactor, mymiddleware.LoginMiddleware)
+		userRouters.POST("/2fa/confirm", userHttpHandler.ConfirmTwoFactor, mymiddleware.LoginMiddleware)
+		userRouters.POST("/2fa/disable", userHttpHandler.DisableTwoFactor, mymiddleware.LoginMiddleware)
+		userRouters.POST("/2fa/recovery-codes", userHttpHandler.RegenerateRecoveryCodes, mymiddleware.LoginMiddleware)
+		userRouters.POST("/verifications/:platform", userHttpHandler.StartVerification, mymiddleware.LoginMiddleware)
+		userRouters.POST("/verifications/:platform/check", userHttpHandler.CheckVerification, mymiddleware.LoginMiddleware)
 	}
 }
 
 func (s *echoServer) initializePostHttpHandler() {
-	postPostgresRepository := postRepositories.NewPostRepository(s.db)
-	postUseCase := postUseCases.NewPostUseCase(postPostgresRepository)
+	postUseCase := s.newPostUseCase()
 	postHttpHandler := postHandlers.NewPostHttpHandler(postUseCase)
 
 	postRouters := s.app.Group("/v2/posts")
 	{
 		postRouters.POST("/", postHttpHandler.CreatePost, mymiddleware.LoginMiddleware)
-		postRouters.GET("/:id", postHttpHandler.GetPostById)
-		postRouters.GET("/", postHttpHandler.GetFilteredPosts)
+		postRouters.GET("/:id", postHttpHandler.GetPostById, mymiddleware.OptionalLoginMiddleware)
+		postRouters.GET("/:id/stats", postHttpHandler.GetPostStats, mymiddleware.LoginMiddleware)
+		postRouters.GET("/", postHttpHandler.GetFilteredPosts, mymiddleware.OptionalLoginMiddleware)
 		postRouters.GET("/my", postHttpHandler.GetMyPosts, mymiddleware.LoginMiddleware)
+		postRouters.GET("/types", postHttpHandler.GetPostTypes)
+		postRouters.GET("/stream", postHttpHandler.StreamPosts)
+		postRouters.GET("/saved", postHttpHandler.GetSavedPosts, mymiddleware.LoginMiddleware)
+		postRouters.POST("/:id/save", postHttpHandler.SavePost, mymiddleware.LoginMiddleware)
+		postRouters.DELETE("/:id/save", postHttpHandler.UnsavePost, mymiddleware.LoginMiddleware)
 		postRouters.PATCH("/:id", postHttpHandler.UpdatePost, mymiddleware.LoginMiddleware)
 		postRouters.DELETE("/:id", postHttpHandler.DeletePost, mymiddleware.LoginMiddleware)
+		postRouters.POST("/:id/publish", postHttpHandler.PublishPost, mymiddleware.LoginMiddleware)
+		postRouters.POST("/:id/close", postHttpHandler.ClosePost, mymiddleware.LoginMiddleware)
+		postRouters.POST("/:id/reopen", postHttpHandler.ReopenPost, mymiddleware.LoginMiddleware)
+	}
+
+}
+
+func (s *echoServer) initializeReportHttpHandler() {
+	reportPostgresRepository := reportRepositories.NewReportRepository(s.db)
+	postPostgresRepository := postRepositories.NewPostRepository(s.db)
+	userPostgresRepository := userRepositories.NewUserRepository(s.db)
+	reportUseCase := reportUseCases.NewReportUseCase(reportPostgresRepository, postPostgresRepository, userPostgresRepository, s.conf.Moderation, s.events)
+	reportHttpHandler := reportHandlers.NewReportHttpHandler(reportUseCase)
+
+	s.app.POST("/v2/posts/:id/report", reportHttpHandler.ReportPost, mymiddleware.LoginMiddleware)
+	s.app.POST("/v2/users/:id/report", reportHttpHandler.ReportUser, mymiddleware.LoginMiddleware)
+
+	moderationRouters := s.app.Group("/v2/moderation", mymiddleware.LoginMiddleware, mymiddleware.AdminMiddleware)
+	{
+		moderationRouters.GET("/reports", reportHttpHandler.GetModerationQueue)
+		moderationRouters.POST("/reports/:id/resolve", reportHttpHandler.Resolve)
+		moderationRouters.POST("/reports/:id/dismiss", reportHttpHandler.Dismiss)
+	}
+}
+
+func (s *echoServer) initializeIdentityHttpHandler() {
+	identityPostgresRepository := identityRepositories.NewIdentityRepository(s.db)
+	userPostgresRepository := userRepositories.NewUserRepository(s.db)
+	userUseCase := s.newUserUseCase()
+	identityUseCase := identityUseCases.NewIdentityUseCase(identityPostgresRepository, userPostgresRepository, s.conf.OAuth)
+	identityHttpHandler := identityHandlers.NewIdentityHttpHandler(identityUseCase, userUseCase, s.conf.Server.FrontendURL)
+
+	authRouters := s.app.Group("/v2/auth")
+	{
+		authRouters.GET("/providers", identityHttpHandler.GetProviders)
+		authRouters.GET("/:provider/login", identityHttpHandler.Login)
+		authRouters.GET("/:provider/callback", identityHttpHandler.Callback)
+		authRouters.GET("/identities", identityHttpHandler.GetIdentities, mymiddleware.LoginMiddleware)
+		authRouters.DELETE("/identities/:id", identityHttpHandler.Unlink, mymiddleware.LoginMiddleware)
+	}
+}
+
+func (s *echoServer) initializeExportHttpHandler() {
+	userPostgresRepository := userRepositories.NewUserRepository(s.db)
+	postPostgresRepository := postRepositories.NewPostRepository(s.db)
+	identityPostgresRepository := identityRepositories.NewIdentityRepository(s.db)
+	exportPostgresRepository := exportRepositories.NewExportRepository(s.db)
+	exportUseCase := exportUseCases.NewExportUseCase(userPostgresRepository, postPostgresRepository, identityPostgresRepository, exportPostgresRepository)
+	exportHttpHandler := exportHandlers.NewExportHttpHandler(exportUseCase)
+
+	s.app.GET("/v2/users/export", exportHttpHandler.ExportMyData, mymiddleware.LoginMiddleware)
+}
+
+func (s *echoServer) initializeSkills() {
+	if err := s.skills.SeedDefaults(); err != nil {
+		s.app.Logger.Errorf("seeding skills: %v", err)
+	}
+}
+
+func (s *echoServer) initializeSkillHttpHandler() {
+	skillHttpHandler := skillHandlers.NewSkillHttpHandler(s.skills)
+
+	s.app.GET("/v2/skills", skillHttpHandler.SearchSkills)
+
+	skillRouters := s.app.Group("/v2/moderation/skills", mymiddleware.LoginMiddleware, mymiddleware.AdminMiddleware)
+	{
+		skillRouters.POST("", skillHttpHandler.CreateSkill)
+		skillRouters.POST("/:id/merge", skillHttpHandler.MergeSkills)
+	}
+}
+
+func (s *echoServer) initializeSearchHttpHandler() {
+	searchHttpHandler := searchHandlers.NewSearchHttpHandler(s.newSearchUseCase())
+
+	s.app.GET("/v2/searches/unsubscribe", searchHttpHandler.Unsubscribe)
+
+	searchRouters := s.app.Group("/v2/searches", mymiddleware.LoginMiddleware)
+	{
+		searchRouters.POST("", searchHttpHandler.CreateSearch)
+		searchRouters.GET("", searchHttpHandler.GetSearches)
+		searchRouters.PUT("/:id", searchHttpHandler.UpdateSearch)
+		searchRouters.DELETE("/:id", searchHttpHandler.DeleteSearch)
+	}
+}
+
+func (s *echoServer) initializeWebhookHttpHandler() {
+	webhookHttpHandler := webhookHandlers.NewWebhookHttpHandler(s.webhooks)
+
+	webhookRouters := s.app.Group("/v2/webhooks", mymiddleware.LoginMiddleware)
+	{
+		webhookRouters.POST("", webhookHttpHandler.CreateWebhook)
+		webhookRouters.GET("", webhookHttpHandler.GetWebhooks)
+		webhookRouters.GET("/:id", webhookHttpHandler.GetWebhook)
+		webhookRouters.PUT("/:id", webhookHttpHandler.UpdateWebhook)
+		webhookRouters.DELETE("/:id", webhookHttpHandler.DeleteWebhook)
+		webhookRouters.GET("/:id/deliveries", webhookHttpHandler.GetDeliveries)
+		webhookRouters.POST("/:id/deliveries/:deliveryId/redeliver", webhookHttpHandler.Redeliver)
+	}
+}
+
+func (s *echoServer) initializeNotificationHttpHandler() {
+	notificationHttpHandler := notificationHandlers.NewNotificationHttpHandler(s.notifications)
+
+	notificationRouters := s.app.Group("/v2/notifications", mymiddleware.LoginMiddleware)
+	{
+		notificationRouters.GET("", notificationHttpHandler.GetNotifications)
+		notificationRouters.GET("/stream", notificationHttpHandler.Stream)
+		notificationRouters.POST("/:id/read", notificationHttpHandler.MarkRead)
+		notificationRouters.POST("/read-all", notificationHttpHandler.MarkAllRead)
+		notificationRouters.GET("/preferences", notificationHttpHandler.GetPreferences)
+		notificationRouters.PUT("/preferences", notificationHttpHandler.UpdatePreferences)
 	}
+}
+
+func (s *echoServer) initializeCommentHttpHandler() {
+	commentPostgresRepository := commentRepositories.NewCommentRepository(s.db)
+	postPostgresRepository := postRepositories.NewPostRepository(s.db)
+	commentUseCase := commentUseCases.NewCommentUseCase(commentPostgresRepository, postPostgresRepository, s.events)
+	commentHttpHandler := commentHandlers.NewCommentHttpHandler(commentUseCase)
+
+	s.app.GET("/v2/posts/:id/comments", commentHttpHandler.GetComments, mymiddleware.OptionalLoginMiddleware)
+	s.app.POST("/v2/posts/:id/comments", commentHttpHandler.CreateComment, mymiddleware.LoginMiddleware)
+
+	commentRouters := s.app.Group("/v2/comments", mymiddleware.LoginMiddleware)
+	{
+		commentRouters.PATCH("/:id", commentHttpHandler.UpdateComment)
+		commentRouters.DELETE("/:id", commentHttpHandler.DeleteComment)
+	}
+}
+
+func (s *echoServer) initializeJobs() {
+	ctx := context.Background()
+
+	userUseCase := s.newUserUseCase()
+	postUseCase := s.newPostUseCase()
+	searchUseCase := s.newSearchUseCase()
+
+	scheduler.Every(ctx, s.app.Logger, "purge-deleted-users", s.conf.Accounts.PurgeInterval, userUseCase.PurgeDeletedUsers)
+	scheduler.Every(ctx, s.app.Logger, "archive-expired-posts", s.conf.Posts.ExpiryCheckInterval, postUseCase.ArchiveExpiredPosts)
+	scheduler.Every(ctx, s.app.Logger, "flush-post-views", s.conf.Posts.ViewFlushInterval, s.postViews.Flush)
+	scheduler.Every(ctx, s.app.Logger, "send-search-digests", s.conf.Searches.DigestInterval, searchUseCase.SendDigests)
+	scheduler.Every(ctx, s.app.Logger, "retry-webhook-deliveries", s.conf.Webhooks.RetryInterval, s.webhooks.RetryDeliveries)
 
+	if s.conf.RateLimit.Store == ratelimit.StorePostgres {
+		idle := s.conf.RateLimit.IPWindow
+		if s.conf.RateLimit.AccountWindow > idle {
+			idle = s.conf.RateLimit.AccountWindow
+		}
+		scheduler.Every(ctx, s.app.Logger, "purge-rate-limit-buckets", idle, func(context.Context) error {
+			return ratelimit.PurgeIdleBuckets(s.db, idle)
+		})
+	}
+}
+
+func (s *echoServer) newUserUseCase() userUseCases.UserUseCase {
+	userPostgresRepository := userRepositories.NewUserRepository(s.db)
+	tokenPostgresRepository := tokenRepositories.NewTokenRepository(s.db)
+	return userUseCases.NewUserUseCase(userPostgresRepository, tokenPostgresRepository, s.conf.Lockout, s.conf.Tokens, s.conf.Accounts, s.skills, s.events, s.verifiers, s.conf.Verification)
+}
+
+// newIPExtractor decides where c.RealIP comes from, which the per-IP rate
+// limits and view counts rely on. Forwarding headers are only believed from
+// the configured proxies; otherwise clients could send any address.
+func newIPExtractor(trustedProxies []string, logger echo.Logger) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			logger.Fatalf("invalid trusted proxy %q: %v", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+func newVerifiers(conf *config.Verification) map[string]verify.Verifier {
+	client := &http.Client{Timeout: 10 * time.Second}
+	verifiers := make(map[string]verify.Verifier)
+	if conf.TelegramBotToken != "" {
+		verifiers[userModels.PlatformTelegram] = verify.NewTelegram(client, conf.TelegramBotToken, conf.TelegramBotUsername)
+	}
+	if conf.DiscordBotToken != "" {
+		verifiers[userModels.PlatformDiscord] = verify.NewDiscord(client, conf.DiscordBotToken, conf.DiscordChannelID)
+	}
+	return verifiers
+}
+
+func (s *echoServer) newPostUseCase() postUseCases.PostUseCase {
+	postPostgresRepository := postRepositories.NewPostRepository(s.db)
+	savedPostPostgresRepository := postRepositories.NewSavedPostRepository(s.db)
+	postViewPostgresRepository := postRepositories.NewPostViewRepository(s.db)
+	return postUseCases.NewPostUseCase(postPostgresRepository, savedPostPostgresRepository, s.mailer, s.conf.Posts, s.conf.Server.FrontendURL, s.skills, s.events, s.postFeed, postViewPostgresRepository, s.postViews)
+}
+
+func (s *echoServer) newSearchUseCase() searchUseCases.SearchUseCase {
+	searchPostgresRepository := searchRepositories.NewSearchRepository(s.db)
+	postPostgresRepository := postRepositories.NewPostRepository(s.db)
+	return searchUseCases.NewSearchUseCase(searchPostgresRepository, postPostgresRepository, s.skills, s.notifications, s.mailer, s.conf.Searches, s.conf.Server)
 }
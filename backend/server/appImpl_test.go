@@ -0,0 +1,37 @@
+package server
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func realIP(t *testing.T, trustedProxies []string, remoteAddr, forwardedFor string) string {
+	t.Helper()
+	e := echo.New()
+	e.IPExtractor = newIPExtractor(trustedProxies, e.Logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/posts/1", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+	return e.NewContext(req, httptest.NewRecorder()).RealIP()
+}
+
+func TestIPExtractorIgnoresClientHeaders(t *testing.T) {
+	if ip := realIP(t, nil, "203.0.113.7:5000", "198.51.100.1"); ip != "203.0.113.7" {
+		t.Errorf("RealIP = %s, want the connecting address 203.0.113.7", ip)
+	}
+}
+
+func TestIPExtractorTrustsConfiguredProxies(t *testing.T) {
+	proxies := []string{"10.0.0.0/8"}
+
+	if ip := realIP(t, proxies, "10.0.0.2:5000", "198.51.100.1"); ip != "198.51.100.1" {
+		t.Errorf("behind a trusted proxy: RealIP = %s, want 198.51.100.1", ip)
+	}
+	if ip := realIP(t, proxies, "203.0.113.7:5000", "198.51.100.1"); ip != "203.0.113.7" {
+		t.Errorf("from an untrusted address: RealIP = %s, want 203.0.113.7", ip)
+	}
+}
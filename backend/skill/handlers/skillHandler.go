@@ -0,0 +1,9 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type SkillHandler interface {
+	SearchSkills(c echo.Context) error
+	CreateSkill(c echo.Context) error
+	MergeSkills(c echo.Context) error
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/skill/repository"
+	"DiplomaV2/backend/skill/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+type skillHttpHandler struct {
+	skillUseCase usecase.SkillUseCase
+}
+
+func (s *skillHttpHandler) SearchSkills(c echo.Context) error {
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	prefix := helpers.ReadString(qs, "q", "")
+	limit := helpers.ReadInt(qs, "limit", 10, v)
+	v.Check(limit > 0 && limit <= 50, "limit", "must be between 1 and 50")
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	skills, err := s.skillUseCase.Search(prefix, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"skills": skills})
+}
+
+func (s *skillHttpHandler) CreateSkill(c echo.Context) error {
+	var input struct {
+		Name     string   `json:"name"`
+		Category string   `json:"category"`
+		Aliases  []string `json:"aliases"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	skill := &entity.Skill{
+		Name:     input.Name,
+		Category: input.Category,
+		Aliases:  input.Aliases,
+	}
+	if skill.Category == "" {
+		skill.Category = entity.SkillCategoryOther
+	}
+
+	v := validator.New()
+	if validator.ValidateSkill(v, skill); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	if err := s.skillUseCase.CreateSkill(skill); err != nil {
+		return s.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, skill)
+}
+
+func (s *skillHttpHandler) MergeSkills(c echo.Context) error {
+	sourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid skill id"})
+	}
+
+	var input struct {
+		TargetID int64 `json:"targetId"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	target, err := s.skillUseCase.MergeSkills(sourceID, input.TargetID)
+	if err != nil {
+		return s.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, target)
+}
+
+func (s *skillHttpHandler) errorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSkillNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrDuplicateSkill):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrMergeIntoSelf):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func NewSkillHttpHandler(skillUseCase usecase.SkillUseCase) SkillHandler {
+	return &skillHttpHandler{
+		skillUseCase: skillUseCase,
+	}
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+)
+
+type SkillRepository interface {
+	Insert(skill *entity.Skill) error
+	GetByID(id int64) (*entity.Skill, error)
+	GetAll() ([]*entity.Skill, error)
+	Search(prefix string, limit int) ([]*entity.Skill, error)
+	Merge(source, target *entity.Skill) error
+	RewriteSkill(from, to string) error
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"strings"
+)
+
+var (
+	ErrSkillNotFound  = errors.New("skill not found")
+	ErrDuplicateSkill = errors.New("skill already exists")
+)
+
+type skillRepository struct {
+	DB database.Database
+}
+
+func NewSkillRepository(db database.Database) SkillRepository {
+	return &skillRepository{DB: db}
+}
+
+const usageColumns = `skills.*,
+	(SELECT COUNT(*) FROM posts WHERE skills.name = ANY(posts.skills)) AS post_count,
+	(SELECT COUNT(*) FROM users WHERE skills.name = ANY(users.skills) AND users.deleted_at IS NULL) AS user_count`
+
+func (r *skillRepository) Insert(skill *entity.Skill) error {
+	result := r.DB.GetDb().Create(skill)
+	if result.Error != nil {
+		if strings.Contains(result.Error.Error(), "duplicate key value") {
+			return ErrDuplicateSkill
+		}
+		return result.Error
+	}
+	return nil
+}
+
+func (r *skillRepository) GetByID(id int64) (*entity.Skill, error) {
+	var skill entity.Skill
+	if err := r.DB.GetDb().First(&skill, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSkillNotFound
+		}
+		return nil, err
+	}
+	return &skill, nil
+}
+
+func (r *skillRepository) GetAll() ([]*entity.Skill, error) {
+	var skills []*entity.Skill
+	if err := r.DB.GetDb().Order("name").Find(&skills).Error; err != nil {
+		return nil, err
+	}
+	return skills, nil
+}
+
+// Search matches the prefix against names and aliases and returns the most
+// used skills first.
+func (r *skillRepository) Search(prefix string, limit int) ([]*entity.Skill, error) {
+	var skills []*entity.Skill
+	query := r.DB.GetDb().Model(&entity.Skill{}).Select(usageColumns)
+
+	if prefix != "" {
+		pattern := escapeLike(strings.ToLower(prefix)) + "%"
+		query = query.Where(
+			"LOWER(skills.name) LIKE ? OR EXISTS (SELECT 1 FROM unnest(skills.aliases) AS alias WHERE alias LIKE ?)",
+			pattern, pattern,
+		)
+	}
+
+	err := query.Order("post_count + user_count DESC").Order("skills.name").Limit(limit).Find(&skills).Error
+	if err != nil {
+		return nil, err
+	}
+	return skills, nil
+}
+
+// Merge folds source into target: every post and user array is rewritten,
+// the source name and aliases become aliases of the target and the source
+// row is removed, all in one transaction.
+func (r *skillRepository) Merge(source, target *entity.Skill) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := rewriteSkill(tx, source.Name, target.Name); err != nil {
+			return err
+		}
+
+		aliases := append(pq.StringArray{}, target.Aliases...)
+		for _, alias := range append([]string{strings.ToLower(source.Name)}, source.Aliases...) {
+			if !containsFold(aliases, alias) && !strings.EqualFold(alias, target.Name) {
+				aliases = append(aliases, alias)
+			}
+		}
+		target.Aliases = aliases
+
+		if err := tx.Model(target).Update("aliases", target.Aliases).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.Skill{}, source.ID).Error
+	})
+}
+
+func (r *skillRepository) RewriteSkill(from, to string) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		return rewriteSkill(tx, from, to)
+	})
+}
+
+// rewriteSkill replaces from with to in the skill arrays of posts, users and
+// saved searches, keeping the original order and dropping the duplicate if
+// both were present. Array elements are matched case-insensitively, like the
+// citext skill columns of the level tables.
+func rewriteSkill(tx *gorm.DB, from, to string) error {
+	const rewrite = `UPDATE %[1]s SET %[2]s = ARRAY(
+		SELECT s FROM (
+			SELECT CASE WHEN lower(s) = lower(?) THEN ? ELSE s END AS s, i
+			FROM unnest(%[2]s) WITH ORDINALITY AS t(s, i)
+		) AS r GROUP BY s ORDER BY MIN(i)
+	) WHERE EXISTS (SELECT 1 FROM unnest(%[2]s) AS s WHERE lower(s) = lower(?))`
+
+	for _, table := range []string{"posts", "users", "saved_searches"} {
+		if err := tx.Exec(fmt.Sprintf(rewrite, table, "skills"), from, to, from).Error; err != nil {
+			return err
+		}
+	}
+
+	// Saved searches keep their level filters as "skill:level" pairs.
+	const rewritePairs = `UPDATE saved_searches SET skill_levels = ARRAY(
+		SELECT p FROM (
+			SELECT CASE WHEN lower(split_part(p, ':', 1)) = lower(?) THEN ? || substr(p, length(split_part(p, ':', 1)) + 1) ELSE p END AS p, i
+			FROM unnest(skill_levels) WITH ORDINALITY AS t(p, i)
+		) AS r GROUP BY p ORDER BY MIN(i)
+	) WHERE EXISTS (SELECT 1 FROM unnest(skill_levels) AS p WHERE lower(split_part(p, ':', 1)) = lower(?))`
+	if err := tx.Exec(rewritePairs, from, to, from).Error; err != nil {
+		return err
+	}
+
+	// Level rows are keyed by skill, so a row that would collide with an
+	// existing one for the target skill is dropped instead of renamed.
+	const dropDuplicates = `DELETE FROM %[1]s AS a WHERE a.skill = ? AND EXISTS (
+		SELECT 1 FROM %[1]s AS b WHERE b.%[2]s = a.%[2]s AND b.skill = ?
+	)`
+	for table, owner := range map[string]string{"post_skills": "post_id", "user_skills": "user_id"} {
+		if err := tx.Exec(fmt.Sprintf(dropDuplicates, table, owner), from, to).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("UPDATE %s SET skill = ? WHERE skill = ?", table), to, from).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
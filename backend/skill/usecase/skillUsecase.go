@@ -0,0 +1,12 @@
+package usecase
+
+import "DiplomaV2/backend/internal/entity"
+
+type SkillUseCase interface {
+	Normalize(skills []string) ([]string, error)
+	Canonical(skill string) (string, error)
+	Search(prefix string, limit int) ([]*entity.Skill, error)
+	CreateSkill(skill *entity.Skill) error
+	MergeSkills(sourceID, targetID int64) (*entity.Skill, error)
+	SeedDefaults() error
+}
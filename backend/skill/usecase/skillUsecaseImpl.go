@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/skill/repository"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+)
+
+var ErrMergeIntoSelf = errors.New("a skill can't be merged into itself")
+
+// defaultSkills are the skills the frontend used to hardcode, seeded on start.
+var defaultSkills = []entity.Skill{
+	{Name: "golang", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"go"}},
+	{Name: "python", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"py", "python3"}},
+	{Name: "java", Category: entity.SkillCategoryLanguage},
+	{Name: "javascript", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"js", "ecmascript"}},
+	{Name: "c++", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"cpp", "cplusplus"}},
+	{Name: "c#", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"csharp", "c sharp"}},
+	{Name: "rust", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"rustlang"}},
+	{Name: "php", Category: entity.SkillCategoryLanguage},
+	{Name: "kotlin", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"kt"}},
+	{Name: "ruby", Category: entity.SkillCategoryLanguage, Aliases: pq.StringArray{"rb"}},
+}
+
+type skillUseCaseImpl struct {
+	repo repository.SkillRepository
+
+	mu      sync.RWMutex
+	lookup  map[string]string
+	fetched bool
+}
+
+func NewSkillUseCase(repo repository.SkillRepository) SkillUseCase {
+	return &skillUseCaseImpl{repo: repo}
+}
+
+// Normalize maps every skill to its canonical name, matching names and
+// aliases case-insensitively. Unknown skills are kept as typed, trimmed.
+// Duplicates are dropped, keeping the first occurrence.
+func (s *skillUseCaseImpl) Normalize(skills []string) ([]string, error) {
+	lookup, err := s.getLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		skill = strings.TrimSpace(skill)
+		if skill == "" {
+			continue
+		}
+		if canonical, ok := lookup[strings.ToLower(skill)]; ok {
+			skill = canonical
+		}
+		if key := strings.ToLower(skill); !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, skill)
+		}
+	}
+	return normalized, nil
+}
+
+// Canonical returns the canonical name of a single skill.
+func (s *skillUseCaseImpl) Canonical(skill string) (string, error) {
+	lookup, err := s.getLookup()
+	if err != nil {
+		return "", err
+	}
+
+	skill = strings.TrimSpace(skill)
+	if canonical, ok := lookup[strings.ToLower(skill)]; ok {
+		return canonical, nil
+	}
+	return skill, nil
+}
+
+func (s *skillUseCaseImpl) Search(prefix string, limit int) ([]*entity.Skill, error) {
+	return s.repo.Search(strings.TrimSpace(prefix), limit)
+}
+
+func (s *skillUseCaseImpl) CreateSkill(skill *entity.Skill) error {
+	skill.Name = strings.TrimSpace(skill.Name)
+	skill.Aliases = normalizeAliases(skill.Name, skill.Aliases)
+
+	if err := s.repo.Insert(skill); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// MergeSkills folds the source skill into the target one and rewrites every
+// post and user that used the source name.
+func (s *skillUseCaseImpl) MergeSkills(sourceID, targetID int64) (*entity.Skill, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	source, err := s.repo.GetByID(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.repo.GetByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Merge(source, target); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return target, nil
+}
+
+// SeedDefaults inserts the default skills that are missing and rewrites
+// alias spellings already stored on posts and users to the canonical names.
+func (s *skillUseCaseImpl) SeedDefaults() error {
+	existing, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, skill := range existing {
+		known[strings.ToLower(skill.Name)] = true
+	}
+
+	for _, def := range defaultSkills {
+		if known[strings.ToLower(def.Name)] {
+			continue
+		}
+		skill := def
+		if err := s.repo.Insert(&skill); err != nil && !errors.Is(err, repository.ErrDuplicateSkill) {
+			return err
+		}
+		for _, alias := range skill.Aliases {
+			if err := s.repo.RewriteSkill(alias, skill.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.invalidate()
+	return nil
+}
+
+func (s *skillUseCaseImpl) getLookup() (map[string]string, error) {
+	s.mu.RLock()
+	if s.fetched {
+		defer s.mu.RUnlock()
+		return s.lookup, nil
+	}
+	s.mu.RUnlock()
+
+	skills, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := make(map[string]string)
+	for _, skill := range skills {
+		lookup[strings.ToLower(skill.Name)] = skill.Name
+		for _, alias := range skill.Aliases {
+			lookup[strings.ToLower(alias)] = skill.Name
+		}
+	}
+
+	s.mu.Lock()
+	s.lookup = lookup
+	s.fetched = true
+	s.mu.Unlock()
+	return lookup, nil
+}
+
+func (s *skillUseCaseImpl) invalidate() {
+	s.mu.Lock()
+	s.fetched = false
+	s.mu.Unlock()
+}
+
+func normalizeAliases(name string, aliases []string) pq.StringArray {
+	result := pq.StringArray{}
+	seen := map[string]bool{strings.ToLower(name): true}
+	for _, alias := range aliases {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		result = append(result, alias)
+	}
+	return result
+}
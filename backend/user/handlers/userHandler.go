@@ -5,16 +5,28 @@ import "github.com/labstack/echo/v4"
 type UserHandler interface {
 	Registration(c echo.Context) error
 	Activation(c echo.Context) error
+	ResendActivation(c echo.Context) error
 	Authentication(c echo.Context) error
 	CheckAuth(c echo.Context) error
 	GetAllUsers(c echo.Context) error
 	GetUserInfoByEmail(c echo.Context) error
 	GetUserInfoById(c echo.Context) error
+	GetUserByUsername(c echo.Context) error
 	GetMyInfo(c echo.Context) error
 	UpdateUserInfo(c echo.Context) error
+	UpdatePrivacy(c echo.Context) error
 	ChangePassword(c echo.Context) error
+	ChangeEmail(c echo.Context) error
+	ConfirmEmailChange(c echo.Context) error
 	ResetPassword(c echo.Context) error
 	ForgotPassword(c echo.Context) error
 	Logout(c echo.Context) error
 	DeleteUser(c echo.Context) error
+	VerifyTwoFactor(c echo.Context) error
+	EnrollTwoFactor(c echo.Context) error
+	ConfirmTwoFactor(c echo.Context) error
+	DisableTwoFactor(c echo.Context) error
+	RegenerateRecoveryCodes(c echo.Context) error
+	StartVerification(c echo.Context) error
+	CheckVerification(c echo.Context) error
 }
@@ -1,21 +1,23 @@
 package handlers
 
 import (
+	"DiplomaV2/backend/internal/dto"
 	"DiplomaV2/backend/internal/entity"
 	"DiplomaV2/backend/internal/helpers"
-	"DiplomaV2/backend/internal/mailer"
 	middleware2 "DiplomaV2/backend/internal/middleware"
+	"DiplomaV2/backend/internal/ratelimit"
 	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/user/repository"
 	"DiplomaV2/backend/user/usecase"
+	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
-	"github.com/lib/pq"
 	"github.com/pkg/errors"
-	"golang.org/x/net/context"
+	"gorm.io/gorm"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
-	"time"
 )
 
 var (
@@ -27,8 +29,8 @@ var (
 )
 
 type userHttpHandler struct {
-	userUseCase usecase.UserUseCase
-	mailer      mailer.Mailer
+	userUseCase    usecase.UserUseCase
+	accountLimiter ratelimit.Limiter
 }
 
 func (u *userHttpHandler) Authentication(c echo.Context) error {
@@ -47,40 +49,43 @@ func (u *userHttpHandler) Authentication(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrWrongCredentials.Error())
 	}
 
-	user, err := u.userUseCase.GetUserByEmail(input.Email)
+	allowed, wait, err := u.accountLimiter.Allow("login:account:" + strings.ToLower(input.Email))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrWrongCredentials.Error())
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !allowed {
+		return middleware2.TooManyRequests(c, wait)
 	}
 
-	match, err := user.Password.Matches(input.Password)
+	user, err := u.userUseCase.Authentication(input.Email, input.Password)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrWrongPassword.Error())
+		switch {
+		// A locked account answers like a wrong password, otherwise the
+		// lock would tell that the email is registered.
+		case errors.Is(err, usecase.ErrInvalidCredentials), errors.Is(err, usecase.ErrAccountLocked):
+			return c.JSON(http.StatusBadRequest, ErrWrongCredentials.Error())
+		case errors.Is(err, usecase.ErrNotActivated):
+			return c.JSON(http.StatusForbidden, ErrNotActive.Error())
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Authentication failed"})
+		}
 	}
 
-	if !match {
-		return c.JSON(http.StatusBadRequest, ErrWrongPassword.Error())
+	if user.TOTPEnabled {
+		return u.startTwoFactor(c, user)
 	}
 
-	if user.Activated != true {
-		return c.JSON(http.StatusForbidden, ErrNotActive.Error())
-	}
+	return u.signIn(c, user)
+}
 
-	token, err := u.userUseCase.Authentication(user)
+// signIn issues the session cookie for a fully authenticated user.
+func (u *userHttpHandler) signIn(c echo.Context, user *entity.User) error {
+	token, err := u.userUseCase.CreateAuthenticationToken(user)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrWrongCredentials.Error())
 	}
 
-	expiry := time.Now().Add(24 * time.Hour)
-
-	c.SetCookie(&http.Cookie{
-		Expires:  expiry,
-		Name:     "jwt",
-		Value:    token,
-		Path:     "/",
-		Secure:   true,
-		HttpOnly: true,
-		SameSite: http.SameSiteNoneMode,
-	})
+	middleware2.SetAuthCookie(c, token)
 
 	c.Set("user", user)
 
@@ -95,29 +100,7 @@ func (u *userHttpHandler) GetMyInfo(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	responseUser := struct {
-		ID           int64    `json:"id"`
-		Name         string   `json:"name"`
-		Surname      string   `json:"surname"`
-		Username     string   `json:"username"`
-		Telegram     string   `json:"telegram"`
-		Discord      string   `json:"discord"`
-		Skills       []string `json:"skills"`
-		Email        string   `json:"email"`
-		ProfileImage string   `json:"profileImage"`
-	}{
-		ID:           user.ID,
-		Name:         user.Name,
-		Surname:      user.Surname,
-		Username:     user.Username,
-		Telegram:     user.Telegram,
-		Discord:      user.Discord,
-		Skills:       user.Skills,
-		Email:        user.Email,
-		ProfileImage: user.ProfileImage,
-	}
-
-	return c.JSON(http.StatusOK, responseUser)
+	return c.JSON(http.StatusOK, dto.NewAccount(user))
 }
 
 func (u *userHttpHandler) CheckAuth(c echo.Context) error {
@@ -142,6 +125,9 @@ func (u *userHttpHandler) Activation(c echo.Context) error {
 
 	err := u.userUseCase.Activation(tokenPlaintext)
 	if err != nil {
+		if errors.Is(err, usecase.InvalidToken) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired activation token"})
+		}
 		println("activation error: " + err.Error())
 		return c.JSON(http.StatusInternalServerError, ErrFailedValidation.Error())
 	}
@@ -149,6 +135,32 @@ func (u *userHttpHandler) Activation(c echo.Context) error {
 	return c.JSON(http.StatusAccepted, map[string]interface{}{"message": "Activation successful"})
 }
 
+func (u *userHttpHandler) ResendActivation(c echo.Context) error {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	v := validator.New()
+	if validator.ValidateEmail(v, input.Email); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	// Same response for unknown, activated and throttled accounts.
+	response := map[string]string{"message": "If the account exists and is not yet activated, a new activation email has been sent"}
+
+	allowed, _, err := u.accountLimiter.Allow("activation:account:" + strings.ToLower(input.Email))
+	if err != nil || !allowed {
+		return c.JSON(http.StatusAccepted, response)
+	}
+
+	_ = u.userUseCase.ResendActivation(input.Email)
+
+	return c.JSON(http.StatusAccepted, response)
+}
+
 func (u *userHttpHandler) ForgotPassword(c echo.Context) error {
 	var input struct {
 		Email string `json:"email"`
@@ -157,21 +169,17 @@ func (u *userHttpHandler) ForgotPassword(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	token, err := u.userUseCase.ForgotPassword(input.Email)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	// The response is the same whether or not the account exists.
+	response := map[string]string{"message": "If an account with that email exists, a password reset email has been sent"}
+
+	allowed, _, err := u.accountLimiter.Allow("forgot-password:account:" + strings.ToLower(input.Email))
+	if err != nil || !allowed {
+		return c.JSON(http.StatusOK, response)
 	}
-	forgotPasswordLink := fmt.Sprintf("http://localhost:5173/reset-password/%s", token)
 
-	u.background(func() error {
-		data := map[string]any{
-			"passwordResetToken": token,
-			"forgotPasswordLink": forgotPasswordLink,
-		}
-		return u.mailer.Send(input.Email, "token_password_reset.tmpl", data)
-	})
+	_ = u.userUseCase.ForgotPassword(input.Email)
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Password reset email sent"})
+	return c.JSON(http.StatusOK, response)
 }
 
 func (u *userHttpHandler) ResetPassword(c echo.Context) error {
@@ -233,37 +241,86 @@ func (u *userHttpHandler) ChangePassword(c echo.Context) error {
 	return c.JSON(http.StatusOK, "Password updated successfully")
 }
 
-func (u *userHttpHandler) GetAllUsers(c echo.Context) error {
-	type UserInfo struct {
-		ID       int64          `json:"id"`
-		Name     string         `json:"name"`
-		Surname  string         `json:"surname"`
-		Username string         `json:"username"`
-		Telegram string         `json:"telegram"`
-		Discord  string         `json:"discord"`
-		Skills   pq.StringArray `json:"skills"`
+func (u *userHttpHandler) ChangeEmail(c echo.Context) error {
+	var input struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewEmail        string `json:"newEmail"`
+	}
+
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
 	}
 
-	users, err := u.userUseCase.GetAllUsers()
+	v := validator.New()
+	if validator.ValidateEmail(v, input.NewEmail); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	userId := c.Get("userID").(int64)
+
+	err := u.userUseCase.RequestEmailChange(userId, input.CurrentPassword, input.NewEmail)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		switch {
+		case errors.Is(err, usecase.ErrWrongPassword):
+			return c.JSON(http.StatusBadRequest, "Current password is incorrect")
+		case errors.Is(err, usecase.ErrSameEmail):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, "Failed to change email")
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "Confirmation email sent to the new address"})
+}
+
+// ConfirmEmailChange is a POST from the frontend's confirmation page rather
+// than a link in the email, so mail scanners that open links can't confirm
+// a change.
+func (u *userHttpHandler) ConfirmEmailChange(c echo.Context) error {
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	var usersInfo []UserInfo
-	for _, user := range users {
-		userInfo := UserInfo{
-			ID:       user.ID,
-			Name:     user.Name,
-			Surname:  user.Surname,
-			Username: user.Username,
-			Telegram: user.Telegram,
-			Discord:  user.Discord,
-			Skills:   user.Skills,
+	v := validator.New()
+	if validator.ValidateTokenPlaintext(v, input.Token); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
+	}
+
+	_, err := u.userUseCase.ConfirmEmailChange(input.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.InvalidToken):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired email change token"})
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Email is already in use"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to change email"})
 		}
-		usersInfo = append(usersInfo, userInfo)
 	}
 
-	return c.JSON(http.StatusOK, usersInfo)
+	return c.JSON(http.StatusOK, map[string]string{"message": "Email changed successfully"})
+}
+
+func (u *userHttpHandler) GetAllUsers(c echo.Context) error {
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	skills := helpers.ReadCSV(qs, "skills", []string{})
+	levels := helpers.ReadSkillLevels(qs, "skillLevels", v)
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	viewer := viewerID(c)
+	users, err := u.userUseCase.GetAllUsers(viewer, skills, levels)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewProfiles(users, viewer))
 }
 func (u *userHttpHandler) Registration(c echo.Context) error {
 	var input struct {
@@ -296,23 +353,19 @@ func (u *userHttpHandler) Registration(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
 	}
 
-	token, err := u.userUseCase.Registration(user)
+	// A taken email gets the same response as a new account; its owner is
+	// emailed instead.
+	err = u.userUseCase.Registration(user)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-	}
-
-	activationLink := fmt.Sprintf("http://localhost:4000/v2/users/activate/%s", token.Plaintext)
-
-	u.background(func() error {
-		data := map[string]interface{}{
-			"activationToken": token.Plaintext,
-			"userID":          user.ID,
-			"activationLink":  activationLink,
+		switch {
+		case errors.Is(err, repository.ErrDuplicateUsername):
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Username is already taken"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Registration failed"})
 		}
-		return u.mailer.Send(user.Email, "user_welcome.tmpl", data)
-	})
+	}
 
-	return c.JSON(http.StatusCreated, map[string]interface{}{"user": user})
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Check your email to activate your account"})
 }
 
 func (u *userHttpHandler) GetUserInfoByEmail(c echo.Context) error {
@@ -326,7 +379,7 @@ func (u *userHttpHandler) GetUserInfoByEmail(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-	return c.JSON(http.StatusOK, user)
+	return c.JSON(http.StatusOK, dto.NewProfile(user, viewerID(c)))
 }
 
 func (u *userHttpHandler) GetUserInfoById(c echo.Context) error {
@@ -342,28 +395,27 @@ func (u *userHttpHandler) GetUserInfoById(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// Create a response user object excluding 'CreatedAt' and 'Email'
-	responseUser := struct {
-		ID           int64    `json:"id"`
-		Name         string   `json:"name"`
-		Surname      string   `json:"surname"`
-		Username     string   `json:"username"`
-		Telegram     string   `json:"telegram"`
-		Discord      string   `json:"discord"`
-		Skills       []string `json:"skills"`
-		ProfileImage string   `json:"profileImage"`
-	}{
-		ID:           user.ID,
-		Name:         user.Name,
-		Surname:      user.Surname,
-		Username:     user.Username,
-		Telegram:     user.Telegram,
-		Discord:      user.Discord,
-		Skills:       user.Skills,
-		ProfileImage: user.ProfileImage,
-	}
-
-	return c.JSON(http.StatusOK, responseUser)
+	return c.JSON(http.StatusOK, dto.NewProfile(user, viewerID(c)))
+}
+
+// GetUserByUsername serves profiles by their slug. A previous username
+// redirects permanently to the current one.
+func (u *userHttpHandler) GetUserByUsername(c echo.Context) error {
+	user, moved, err := u.userUseCase.GetUserByUsername(c.Param("username"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	if moved {
+		location := "/v2/users/by-username/" + url.PathEscape(user.Username)
+		c.Response().Header().Set(echo.HeaderLocation, location)
+		return c.JSON(http.StatusMovedPermanently, map[string]interface{}{"id": user.ID, "username": user.Username})
+	}
+
+	return c.JSON(http.StatusOK, dto.NewProfile(user, viewerID(c)))
 }
 
 func (u *userHttpHandler) UpdateUserInfo(c echo.Context) error {
@@ -380,10 +432,37 @@ func (u *userHttpHandler) UpdateUserInfo(c echo.Context) error {
 	name := form.Value["name"][0]
 	surname := form.Value["surname"][0]
 	username := form.Value["username"][0]
-	telegram := form.Value["telegram"][0]
-	discord := form.Value["discord"][0]
+	telegram := usecase.NormalizeTelegram(form.Value["telegram"][0])
+	discord := usecase.NormalizeDiscord(form.Value["discord"][0])
 	skills := form.Value["skills"]
 
+	current, err := u.userUseCase.GetUserById(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Usernames from before the current rules stay valid until changed.
+	v := validator.New()
+	if username != current.Username {
+		validator.ValidateUsername(v, username)
+	}
+	validator.ValidateTelegram(v, telegram)
+	validator.ValidateDiscord(v, discord)
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	// skillLevels is sent as a JSON array, e.g. [{"skill":"golang","level":"advanced","years":3}]
+	var skillLevels []entity.UserSkill
+	if raw := form.Value["skillLevels"]; len(raw) > 0 && raw[0] != "" {
+		if err := json.Unmarshal([]byte(raw[0]), &skillLevels); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid skillLevels"})
+		}
+		if validator.ValidateSkillLevels(v, skillLevels); !v.Valid() {
+			return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+		}
+	}
+
 	profileImage := form.File["profileImage"]
 	var profileImageURL string
 	if len(profileImage) > 0 {
@@ -405,11 +484,15 @@ func (u *userHttpHandler) UpdateUserInfo(c echo.Context) error {
 		Telegram:     telegram,
 		Discord:      discord,
 		Skills:       skills,
+		SkillLevels:  skillLevels,
 		ProfileImage: profileImageURL, // Assuming this is the URL of the uploaded image
 	}
 
 	err = u.userUseCase.UpdateUserInfo(user)
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateUsername) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "Username is already taken"})
+		}
 		fmt.Println("Error updating user info:", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -418,72 +501,55 @@ func (u *userHttpHandler) UpdateUserInfo(c echo.Context) error {
 	return c.JSON(http.StatusAccepted, map[string]string{"message": "User updated successfully"})
 }
 
-func (u *userHttpHandler) DeleteUser(c echo.Context) error {
-	// Get the userID
+func (u *userHttpHandler) UpdatePrivacy(c echo.Context) error {
 	userID := c.Get("userID").(int64)
 
-	// Retrieve user information to get the profile image URL
-	userInfo, err := u.userUseCase.GetUserById(userID)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve user information"})
+	var privacy entity.ProfilePrivacy
+	if err := c.Bind(&privacy); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// If profile image URL exists, delete the corresponding object from GCS
-	if userInfo.ProfileImage != "" {
-		// Extract object name from the profile image URL
-		objectName := userInfo.ProfileImage[strings.LastIndex(userInfo.ProfileImage, "/")+1:]
-
-		// Initialize GCS client
-		ctx := context.Background()
-		client, err := helpers.NewStorageClient(ctx, "C:/Users/krump/Downloads/lucid-volt-424719-f0-5df86076a210.json")
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to initialize GCS client"})
-		}
+	v := validator.New()
+	if validator.ValidateProfilePrivacy(v, &privacy); !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
 
-		// Delete the object from GCS
-		if err := helpers.DeleteFileFromGCS(ctx, client, "teamfinderimages", objectName); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete profile image from GCS"})
-		}
+	if err := u.userUseCase.UpdatePrivacy(userID, privacy); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
-	// Call the repository method to delete the user
+	return c.JSON(http.StatusOK, privacy)
+}
+
+func (u *userHttpHandler) DeleteUser(c echo.Context) error {
+	// Get the userID
+	userID := c.Get("userID").(int64)
+
+	// The account is soft deleted; logging in again within the grace period restores it
 	if err := u.userUseCase.DeleteUser(userID); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete user"})
 	}
 
+	middleware2.ClearAuthCookie(c)
+
 	return c.NoContent(http.StatusNoContent)
 }
 
 func (u *userHttpHandler) Logout(c echo.Context) error {
-	c.SetCookie(&http.Cookie{
-		Name:     "jwt",
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
-	})
+	middleware2.ClearAuthCookie(c)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"message": "Logout successful"})
 }
 
-func (u *userHttpHandler) background(fn func() error) {
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				return
-			}
-		}()
-		err := fn()
-		if err != nil {
-			return
-		}
-	}()
+// viewerID returns the signed in user, or 0 when the route allows anonymous
+// requests and there is none.
+func viewerID(c echo.Context) int64 {
+	id, _ := c.Get("userID").(int64)
+	return id
 }
 
-func NewUserHttpHandler(userUsecase usecase.UserUseCase, theMailer mailer.Mailer) UserHandler {
+func NewUserHttpHandler(userUsecase usecase.UserUseCase, accountLimiter ratelimit.Limiter) UserHandler {
 	return &userHttpHandler{userUsecase,
-		theMailer,
+		accountLimiter,
 	}
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/ratelimit"
+	"DiplomaV2/backend/user/repository"
+	"DiplomaV2/backend/user/usecase"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeUserUseCase struct {
+	usecase.UserUseCase
+	registered []*entity.User
+	confirmErr error
+}
+
+func (f *fakeUserUseCase) Registration(user *entity.User) error {
+	user.ID = int64(len(f.registered) + 1)
+	f.registered = append(f.registered, user)
+	return nil
+}
+
+func register(t *testing.T, handler UserHandler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v2/users/registration", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := handler.Registration(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestRegistrationDoesNotLeakAccount(t *testing.T) {
+	uc := &fakeUserUseCase{}
+	handler := NewUserHttpHandler(uc, ratelimit.NewMemoryLimiter(10, time.Minute))
+
+	rec := register(t, handler, `{"name":"Jane","email":"jane@example.com","username":"jane","password":"correct horse battery"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if len(uc.registered) != 1 {
+		t.Fatal("the user should be registered")
+	}
+	for _, leak := range []string{"jane@example.com", "correct horse battery", "$2a$", `"email"`, `"password"`, `"hash"`, `"tokens"`} {
+		if strings.Contains(rec.Body.String(), leak) {
+			t.Errorf("response leaks %s: %s", leak, rec.Body)
+		}
+	}
+}
+
+func (f *fakeUserUseCase) ConfirmEmailChange(string) (*entity.User, error) {
+	return nil, f.confirmErr
+}
+
+func TestConfirmEmailChangeToTakenAddressConflicts(t *testing.T) {
+	uc := &fakeUserUseCase{confirmErr: repository.ErrDuplicateEmail}
+	handler := NewUserHttpHandler(uc, ratelimit.NewMemoryLimiter(10, time.Minute))
+
+	body := `{"token":"` + strings.Repeat("A", 26) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v2/users/email/confirm", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := handler.ConfirmEmailChange(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	middleware2 "DiplomaV2/backend/internal/middleware"
+	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/user/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// startTwoFactor is the first half of a login for accounts with TOTP enabled:
+// instead of the session cookie the client gets a short-lived pending cookie.
+func (u *userHttpHandler) startTwoFactor(c echo.Context, user *entity.User) error {
+	token, err := u.userUseCase.CreateMFAPendingToken(user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Authentication failed"})
+	}
+
+	middleware2.SetMFAPendingCookie(c, token)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":     "Two-factor authentication required",
+		"mfaRequired": true,
+	})
+}
+
+func (u *userHttpHandler) VerifyTwoFactor(c echo.Context) error {
+	var input struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recoveryCode"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
+	}
+
+	v := validator.New()
+	if input.RecoveryCode != "" {
+		validator.ValidateTokenPlaintext(v, input.RecoveryCode)
+	} else {
+		validator.ValidateTOTPCode(v, input.Code)
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	cookie, err := c.Cookie(middleware2.MFAPendingCookieName)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": usecase.ErrInvalidMFAToken.Error()})
+	}
+
+	// A pending login gets a single guess, since VerifyMFA spends the token
+	// before checking the code; this only stops replays of a leaked cookie.
+	// A new one requires the password again, which is throttled per account.
+	allowed, wait, err := u.accountLimiter.Allow("mfa:session:" + cookie.Value)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !allowed {
+		return middleware2.TooManyRequests(c, wait)
+	}
+
+	user, err := u.userUseCase.VerifyMFA(cookie.Value, input.Code, input.RecoveryCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidMFAToken):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		case errors.Is(err, usecase.ErrInvalidTOTPCode), errors.Is(err, usecase.ErrTOTPNotEnabled):
+			middleware2.ClearMFAPendingCookie(c)
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": usecase.ErrInvalidTOTPCode.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Authentication failed"})
+		}
+	}
+
+	middleware2.ClearMFAPendingCookie(c)
+
+	return u.signIn(c, user)
+}
+
+func (u *userHttpHandler) EnrollTwoFactor(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	secret, uri, err := u.userUseCase.EnrollTOTP(userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTOTPAlreadyEnabled) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"secret": secret,
+		"uri":    uri,
+	})
+}
+
+func (u *userHttpHandler) ConfirmTwoFactor(c echo.Context) error {
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
+	}
+
+	v := validator.New()
+	if validator.ValidateTOTPCode(v, input.Code); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	userID := c.Get("userID").(int64)
+
+	codes, err := u.userUseCase.ConfirmTOTP(userID, input.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"recoveryCodes": codes})
+}
+
+func (u *userHttpHandler) DisableTwoFactor(c echo.Context) error {
+	var input struct {
+		Password string `json:"password"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
+	}
+
+	userID := c.Get("userID").(int64)
+
+	if err := u.userUseCase.DisableTOTP(userID, input.Password); err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+func (u *userHttpHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	var input struct {
+		Password string `json:"password"`
+	}
+	if err := c.Bind(&input); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrFailedValidation.Error())
+	}
+
+	userID := c.Get("userID").(int64)
+
+	codes, err := u.userUseCase.RegenerateRecoveryCodes(userID, input.Password)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"recoveryCodes": codes})
+}
+
+func twoFactorErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrWrongPassword):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Current password is incorrect"})
+	case errors.Is(err, usecase.ErrInvalidTOTPCode):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrTOTPAlreadyEnabled),
+		errors.Is(err, usecase.ErrTOTPNotEnabled),
+		errors.Is(err, usecase.ErrTOTPNotEnrolled):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
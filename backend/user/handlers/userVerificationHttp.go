@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	middleware2 "DiplomaV2/backend/internal/middleware"
+	"DiplomaV2/backend/user/repository"
+	"DiplomaV2/backend/user/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+func (u *userHttpHandler) StartVerification(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	verification, instructions, err := u.userUseCase.StartVerification(userID, c.Param("platform"))
+	if err != nil {
+		return verificationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"verification": verification,
+		"instructions": instructions,
+	})
+}
+
+// CheckVerification is rate limited per account because every check calls
+// the platform's API.
+func (u *userHttpHandler) CheckVerification(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	allowed, wait, err := u.accountLimiter.Allow("verification:account:" + strconv.FormatInt(userID, 10))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if !allowed {
+		return middleware2.TooManyRequests(c, wait)
+	}
+
+	if err := u.userUseCase.CheckVerification(c.Request().Context(), userID, c.Param("platform")); err != nil {
+		return verificationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"verified": true})
+}
+
+func verificationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrUnknownPlatform):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrVerificationUnavailable):
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNoHandle),
+		errors.Is(err, usecase.ErrAlreadyVerified),
+		errors.Is(err, usecase.ErrVerificationExpired),
+		errors.Is(err, repository.ErrVerificationNotFound):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCodeNotFound):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
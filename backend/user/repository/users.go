@@ -2,14 +2,32 @@ package repository
 
 import (
 	"DiplomaV2/backend/internal/entity"
+	"time"
 )
 
 type UserRepository interface {
 	Insert(user *entity.User) error
-	GetAll() ([]*entity.User, error)
+	GetAll(viewerID int64, skills []string, levels []entity.SkillLevelFilter) ([]*entity.User, error)
 	GetByID(id int64) (*entity.User, error)
 	GetByEmail(email string) (*entity.User, error)
+	GetByUsername(username string) (*entity.User, error)
+	GetByPreviousUsername(username string) (*entity.User, error)
+	UsernameTaken(username string, exceptUserID int64) (bool, error)
+	RecordUsernameChange(userID int64, oldUsername, newUsername string) error
 	Update(user *entity.User) error
+	IncrementFailedLogins(user *entity.User, maxAttempts int, lock, maxLock time.Duration) error
+	ResetFailedLogins(id int64) error
+	AdvanceTOTPStep(id int64, step int64) (bool, error)
+	ReplaceSkillLevels(userID int64, levels []entity.UserSkill) error
 	GetForToken(tokenScope, tokenPlaintext string) (*entity.User, error)
 	Delete(id int64) error
+	GetSessionsRevokedAt(id int64) (*time.Time, error)
+	GetByEmailIncludingDeleted(email string) (*entity.User, error)
+	GetByIDIncludingDeleted(id int64) (*entity.User, error)
+	Restore(id int64) error
+	GetDeletedBefore(cutoff time.Time) ([]*entity.User, error)
+	Purge(user *entity.User, cleanup func() error) error
+	SaveVerification(verification *entity.HandleVerification) error
+	GetVerification(userID int64, platform string) (*entity.HandleVerification, error)
+	DeleteVerification(userID int64, platform string) error
 }
@@ -4,6 +4,8 @@ import (
 	"DiplomaV2/backend/internal/database"
 	"DiplomaV2/backend/internal/entity"
 	"crypto/sha256"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"time"
@@ -17,26 +19,62 @@ func NewUserRepository(db database.Database) UserRepository {
 	return &userRepository{DB: db}
 }
 
+// uniqueViolation is the SQLSTATE Postgres reports for a duplicate key.
+const uniqueViolation = "23505"
+
 var (
-	ErrDuplicateEmail = errors.New("duplicate email")
+	ErrDuplicateEmail       = errors.New("duplicate email")
+	ErrDuplicateUsername    = errors.New("duplicate username")
+	ErrVerificationNotFound = errors.New("no verification in progress")
 )
 
 func (r *userRepository) Insert(user *entity.User) error {
 	result := r.DB.GetDb().Create(user).Scan(user)
-	if result.Error != nil {
-		switch {
-		case result.Error.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
-			return ErrDuplicateEmail
-		default:
-			return result.Error
-		}
+	return duplicateError(result.Error)
+}
+
+// duplicateError maps a violation of the users' unique constraints to
+// ErrDuplicateEmail or ErrDuplicateUsername. AutoMigrate names them
+// uni_users_<column>; databases created before that use users_<column>_key.
+func duplicateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case "uni_users_email", "users_email_key":
+		return ErrDuplicateEmail
+	case "uni_users_username", "users_username_key":
+		return ErrDuplicateUsername
+	default:
+		return err
 	}
-	return nil
 }
 
-func (r *userRepository) GetAll() ([]*entity.User, error) {
+// GetAll returns the users having all the given skills, at the given minimum
+// levels for the level filters.
+// GetAll searches the users that haven't hidden their profile. Skill
+// filters only match users whose skills viewerID is allowed to see.
+func (r *userRepository) GetAll(viewerID int64, skills []string, levels []entity.SkillLevelFilter) ([]*entity.User, error) {
 	var users []*entity.User
-	result := r.DB.GetDb().Find(&users)
+	query := r.DB.GetDb().Preload("SkillLevels").Where("privacy_hidden_from_search = ?", false)
+
+	if len(skills) > 0 || len(levels) > 0 {
+		query = query.Where("(privacy_skills IN ? OR users.id = ?)",
+			[]string{entity.VisibilityPublic, entity.VisibilityMembers}, viewerID)
+	}
+
+	if len(skills) > 0 {
+		query = query.Where("skills @> ?", pq.Array(skills))
+	}
+	for _, level := range levels {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM user_skills WHERE user_skills.user_id = users.id AND user_skills.skill = ? AND user_skills.level IN ?)",
+			level.Skill, entity.SkillLevelsAtLeast(level.Level),
+		)
+	}
+
+	result := query.Find(&users)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -46,7 +84,7 @@ func (r *userRepository) GetAll() ([]*entity.User, error) {
 func (r *userRepository) GetByID(id int64) (*entity.User, error) {
 	var user entity.User
 
-	result := r.DB.GetDb().First(&user, id)
+	result := r.DB.GetDb().Preload("SkillLevels").First(&user, id)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -72,14 +110,122 @@ func (r *userRepository) GetByEmail(email string) (*entity.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) Update(user *entity.User) error {
-	result := r.DB.GetDb().Save(user)
+func (r *userRepository) GetByUsername(username string) (*entity.User, error) {
+	var user entity.User
+	if err := r.DB.GetDb().Preload("SkillLevels").Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByPreviousUsername returns the user who most recently gave up username.
+func (r *userRepository) GetByPreviousUsername(username string) (*entity.User, error) {
+	var user entity.User
+	err := r.DB.GetDb().
+		Joins("INNER JOIN username_histories ON username_histories.user_id = users.id").
+		Where("username_histories.username = ?", username).
+		Order("username_histories.changed_at DESC").
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UsernameTaken reports whether username is in use or was given up by a
+// user other than exceptUserID. Pass 0 to check against everyone.
+func (r *userRepository) UsernameTaken(username string, exceptUserID int64) (bool, error) {
+	var count int64
+	result := r.DB.GetDb().Model(&entity.User{}).Unscoped().Where("username = ? AND id <> ?", username, exceptUserID).Count(&count)
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	result = r.DB.GetDb().Model(&entity.UsernameHistory{}).Where("username = ? AND user_id <> ?", username, exceptUserID).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// RecordUsernameChange keeps oldUsername for redirects. Taking back a
+// previous username removes it from the history.
+func (r *userRepository) RecordUsernameChange(userID int64, oldUsername, newUsername string) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND username = ?", userID, newUsername).Delete(&entity.UsernameHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.UsernameHistory{UserID: userID, Username: oldUsername}).Error
+	})
+}
+
+func (r *userRepository) Update(user *entity.User) error {
+	result := r.DB.GetDb().Omit("SkillLevels").Save(user)
+	return duplicateError(result.Error)
+}
+
+// IncrementFailedLogins counts a failed login in a single statement, so
+// concurrent attempts can't lose increments or overwrite other changes to
+// the user. From maxAttempts on the account is locked for lock, doubled for
+// every further failure up to maxLock. The new values are set on user.
+func (r *userRepository) IncrementFailedLogins(user *entity.User, maxAttempts int, lock, maxLock time.Duration) error {
+	var row struct {
+		FailedLogins int
+		LockedUntil  *time.Time
 	}
+	err := r.DB.GetDb().Raw(`UPDATE users SET
+		failed_logins = failed_logins + 1,
+		locked_until = CASE WHEN failed_logins + 1 >= ?
+			THEN now() + make_interval(secs => LEAST(? * power(2, LEAST(failed_logins + 1 - ?, 30)), ?))
+			ELSE locked_until END
+		WHERE id = ?
+		RETURNING failed_logins, locked_until`,
+		maxAttempts, lock.Seconds(), maxAttempts, maxLock.Seconds(), user.ID,
+	).Scan(&row).Error
+	if err != nil {
+		return err
+	}
+
+	user.FailedLogins = row.FailedLogins
+	user.LockedUntil = row.LockedUntil
 	return nil
 }
 
+func (r *userRepository) ResetFailedLogins(id int64) error {
+	return r.DB.GetDb().Model(&entity.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+// AdvanceTOTPStep stores step as the last accepted TOTP step only if it is
+// newer than the stored one, so a code can't be replayed by a parallel request.
+func (r *userRepository) AdvanceTOTPStep(id int64, step int64) (bool, error) {
+	result := r.DB.GetDb().Model(&entity.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) ReplaceSkillLevels(userID int64, levels []entity.UserSkill) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.UserSkill{}).Error; err != nil {
+			return err
+		}
+		if len(levels) == 0 {
+			return nil
+		}
+		for i := range levels {
+			levels[i].UserID = userID
+		}
+		return tx.Create(&levels).Error
+	})
+}
+
 func (r *userRepository) GetForToken(tokenScope, tokenPlaintext string) (*entity.User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
@@ -100,15 +246,13 @@ func (r *userRepository) GetForToken(tokenScope, tokenPlaintext string) (*entity
 	return &user, nil
 }
 
+// Delete soft-deletes the user and revokes the sessions issued until now.
 func (r *userRepository) Delete(id int64) error {
-	user := entity.User{ID: id}
-
-	result := r.DB.GetDb().Delete(&user)
+	now := time.Now()
+	result := r.DB.GetDb().Model(&entity.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"deleted_at": now, "sessions_revoked_at": now})
 
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return result.Error
-		}
 		return result.Error
 	}
 
@@ -118,3 +262,109 @@ func (r *userRepository) Delete(id int64) error {
 
 	return nil
 }
+
+// GetSessionsRevokedAt returns gorm.ErrRecordNotFound for deleted users, so
+// none of their sessions are accepted.
+func (r *userRepository) GetSessionsRevokedAt(id int64) (*time.Time, error) {
+	var user entity.User
+	result := r.DB.GetDb().Select("id", "sessions_revoked_at").First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return user.SessionsRevokedAt, nil
+}
+
+func (r *userRepository) GetByEmailIncludingDeleted(email string) (*entity.User, error) {
+	var user entity.User
+
+	result := r.DB.GetDb().Unscoped().Where("email = ?", email).First(&user)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByIDIncludingDeleted(id int64) (*entity.User, error) {
+	var user entity.User
+
+	result := r.DB.GetDb().Unscoped().First(&user, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Restore(id int64) error {
+	result := r.DB.GetDb().Unscoped().Model(&entity.User{}).Where("id = ?", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) GetDeletedBefore(cutoff time.Time) ([]*entity.User, error) {
+	var users []*entity.User
+	result := r.DB.GetDb().Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
+// Purge permanently removes a soft-deleted user with their posts and tokens.
+// Their comments on other posts are emptied and detached instead, so replies
+// keep their thread. cleanup runs inside the transaction, so a failure there
+// (e.g. removing the profile image from storage) leaves the database
+// untouched for the next run.
+func (r *userRepository) Purge(user *entity.User, cleanup func() error) error {
+	return r.DB.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&entity.Token{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("author_id = ?", user.ID).Delete(&entity.Post{}).Error; err != nil {
+			return err
+		}
+		// Detached before the user is deleted, as databases migrated before
+		// comments were kept still cascade the delete to them.
+		err := tx.Model(&entity.Comment{}).Where("author_id = ?", user.ID).Updates(map[string]interface{}{
+			"author_id":  nil,
+			"body":       "",
+			"deleted_at": gorm.Expr("COALESCE(deleted_at, ?)", time.Now()),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&entity.User{}, user.ID).Error; err != nil {
+			return err
+		}
+		return cleanup()
+	})
+}
+
+// SaveVerification replaces the pending verification of the platform.
+func (r *userRepository) SaveVerification(verification *entity.HandleVerification) error {
+	return r.DB.GetDb().Omit("User").Save(verification).Error
+}
+
+func (r *userRepository) GetVerification(userID int64, platform string) (*entity.HandleVerification, error) {
+	var verification entity.HandleVerification
+	err := r.DB.GetDb().Where("user_id = ? AND platform = ?", userID, platform).First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVerificationNotFound
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *userRepository) DeleteVerification(userID int64, platform string) error {
+	return r.DB.GetDb().Where("user_id = ? AND platform = ?", userID, platform).Delete(&entity.HandleVerification{}).Error
+}
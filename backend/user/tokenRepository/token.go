@@ -9,4 +9,5 @@ type TokenRepository interface {
 	New(userID int64, ttl time.Duration, scope string) (*entity.Token, error)
 	insert(token *entity.Token) error
 	DeleteAllForUser(scope string, userID int64) error
+	Delete(scope string, userID int64, tokenPlaintext string) (bool, error)
 }
@@ -12,6 +12,9 @@ import (
 const (
 	ScopeActivation    = "activation"
 	ScopePasswordReset = "password-reset"
+	ScopeRecoveryCode  = "recovery-code"
+	ScopeEmailChange   = "email-change"
+	ScopeMFAPending    = "mfa-pending"
 )
 
 type tokenRepository struct {
@@ -41,6 +44,19 @@ func (t *tokenRepository) DeleteAllForUser(scope string, userID int64) error {
 	return nil
 }
 
+// Delete removes a single token and reports whether it existed, which lets
+// one-time codes be consumed atomically.
+func (t *tokenRepository) Delete(scope string, userID int64, tokenPlaintext string) (bool, error) {
+	hash := sha256.Sum256([]byte(tokenPlaintext))
+	result := t.DB.GetDb().
+		Where("scope = ? AND user_id = ? AND hash = ? AND expiry > ?", scope, userID, hash[:], time.Now()).
+		Delete(&entity.Token{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func NewTokenRepository(db database.Database) TokenRepository {
 	return &tokenRepository{DB: db}
 }
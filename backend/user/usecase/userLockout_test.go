@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func (r *fakeUserRepo) GetByEmailIncludingDeleted(email string) (*entity.User, error) {
+	return r.GetByEmail(email)
+}
+
+// IncrementFailedLogins does what the UPDATE in the real repository does.
+func (r *fakeUserRepo) IncrementFailedLogins(user *entity.User, maxAttempts int, lock, maxLock time.Duration) error {
+	stored := r.users[user.ID]
+	stored.FailedLogins++
+	if stored.FailedLogins >= maxAttempts {
+		duration := lock << (stored.FailedLogins - maxAttempts)
+		if duration > maxLock || duration <= 0 {
+			duration = maxLock
+		}
+		lockedUntil := time.Now().Add(duration)
+		stored.LockedUntil = &lockedUntil
+	}
+	user.FailedLogins = stored.FailedLogins
+	user.LockedUntil = stored.LockedUntil
+	return nil
+}
+
+func (r *fakeUserRepo) GetByIDIncludingDeleted(id int64) (*entity.User, error) {
+	return r.GetByID(id)
+}
+
+func (r *fakeUserRepo) Restore(id int64) error {
+	r.users[id].DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *fakeUserRepo) ResetFailedLogins(id int64) error {
+	r.users[id].FailedLogins = 0
+	r.users[id].LockedUntil = nil
+	return nil
+}
+
+func newLockoutUseCase(t *testing.T) (UserUseCase, *fakeUserRepo) {
+	t.Helper()
+	user := &entity.User{ID: 1, Username: "jane", Email: "jane@example.com", Activated: true}
+	if err := user.Password.Set("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	repo := newFakeUserRepo(user)
+	lockout := &config.Lockout{MaxAttempts: 3, Duration: time.Minute, MaxDuration: 4 * time.Minute}
+	return NewUserUseCase(repo, nil, lockout, nil, nil, fakeSkillUseCase{}, nil, nil, nil), repo
+}
+
+func TestAuthenticationLocksAfterMaxAttempts(t *testing.T) {
+	uc, repo := newLockoutUseCase(t)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := uc.Authentication("jane@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", attempt, err)
+		}
+		if repo.users[1].LockedUntil != nil {
+			t.Fatalf("attempt %d: locked below the threshold", attempt)
+		}
+	}
+
+	if _, err := uc.Authentication("jane@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("third attempt: err = %v, want ErrInvalidCredentials", err)
+	}
+	lockedUntil := repo.users[1].LockedUntil
+	if lockedUntil == nil || time.Until(*lockedUntil) > time.Minute || time.Until(*lockedUntil) < 50*time.Second {
+		t.Fatalf("locked until %v, want about a minute from now", lockedUntil)
+	}
+
+	if _, err := uc.Authentication("jane@example.com", "correct horse battery"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("correct password while locked: err = %v, want ErrAccountLocked", err)
+	}
+	if repo.users[1].FailedLogins != 3 {
+		t.Error("attempts while locked must not count")
+	}
+}
+
+func TestAuthenticationLockDoublesUpToMax(t *testing.T) {
+	uc, repo := newLockoutUseCase(t)
+	repo.users[1].FailedLogins = 3
+
+	for _, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		repo.users[1].LockedUntil = nil
+		if _, err := uc.Authentication("jane@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatal(err)
+		}
+		got := time.Until(*repo.users[1].LockedUntil)
+		if got > want || got < want-10*time.Second {
+			t.Errorf("after %d failures locked for %v, want %v", repo.users[1].FailedLogins, got, want)
+		}
+	}
+}
+
+func TestAuthenticationAfterLockExpires(t *testing.T) {
+	uc, repo := newLockoutUseCase(t)
+	expired := time.Now().Add(-time.Second)
+	repo.users[1].FailedLogins = 3
+	repo.users[1].LockedUntil = &expired
+
+	user, err := uc.Authentication("jane@example.com", "correct horse battery")
+	if err != nil {
+		t.Fatalf("Authentication: %v", err)
+	}
+	if user.ID != 1 {
+		t.Fatalf("user = %d, want 1", user.ID)
+	}
+	if repo.users[1].FailedLogins != 3 {
+		t.Fatal("failed logins cleared before a session was issued")
+	}
+
+	t.Setenv("JWT_SECRET", "test-secret")
+	if _, err := uc.CreateAuthenticationToken(user); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[1].FailedLogins != 0 || repo.users[1].LockedUntil != nil {
+		t.Errorf("failed logins = %d, locked until %v after signing in, want them cleared",
+			repo.users[1].FailedLogins, repo.users[1].LockedUntil)
+	}
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/mailer"
+	"fmt"
+	"strings"
+)
+
+// userMailer sends the account emails in response to user events.
+type userMailer struct {
+	mailer      mailer.Mailer
+	tokens      *config.Tokens
+	frontendURL string
+}
+
+// SubscribeMailer registers the account emails on bus. It must be called
+// once per bus, otherwise every email is sent more than once.
+func SubscribeMailer(bus *events.Bus, theMailer mailer.Mailer, tokens *config.Tokens, server *config.Server) {
+	m := &userMailer{
+		mailer:      theMailer,
+		tokens:      tokens,
+		frontendURL: strings.TrimSuffix(server.FrontendURL, "/"),
+	}
+
+	events.SubscribeAsync(bus, m.userRegistered)
+	events.SubscribeAsync(bus, m.activationRequested)
+	events.SubscribeAsync(bus, m.passwordResetRequested)
+	events.SubscribeAsync(bus, m.passwordChanged)
+	events.SubscribeAsync(bus, m.emailChangeRequested)
+	events.SubscribeAsync(bus, m.emailChangeAttempted)
+	events.SubscribeAsync(bus, m.registrationAttempted)
+}
+
+func (m *userMailer) userRegistered(event events.UserRegistered) error {
+	return m.mailer.Send(event.User.Email, "user_welcome.tmpl", m.activationData(&event.User, &event.Token))
+}
+
+func (m *userMailer) activationRequested(event events.ActivationRequested) error {
+	return m.mailer.Send(event.User.Email, "token_activation.tmpl", m.activationData(&event.User, &event.Token))
+}
+
+func (m *userMailer) activationData(user *entity.User, token *entity.Token) map[string]interface{} {
+	return map[string]interface{}{
+		"activationToken": token.Plaintext,
+		"userID":          user.ID,
+		"activationLink":  fmt.Sprintf("%s/activate/%s", m.frontendURL, token.Plaintext),
+		"activationTTL":   helpers.HumanDuration(m.tokens.ActivationTTL),
+	}
+}
+
+func (m *userMailer) passwordResetRequested(event events.PasswordResetRequested) error {
+	data := map[string]any{
+		"passwordResetToken": event.Token,
+		"forgotPasswordLink": fmt.Sprintf("%s/reset-password/%s", m.frontendURL, event.Token),
+	}
+	return m.mailer.Send(event.User.Email, "token_password_reset.tmpl", data)
+}
+
+func (m *userMailer) passwordChanged(event events.PasswordChanged) error {
+	data := map[string]any{
+		"name":              event.User.Name,
+		"reset":             event.Reset,
+		"forgotPasswordURL": m.frontendURL + "/forgot-password",
+	}
+	return m.mailer.Send(event.User.Email, "password_changed.tmpl", data)
+}
+
+// emailChangeRequested sends the confirmation link to the new address and
+// a notice to the current one.
+func (m *userMailer) emailChangeRequested(event events.EmailChangeRequested) error {
+	data := map[string]any{
+		"confirmationLink": fmt.Sprintf("%s/confirm-email/%s", m.frontendURL, event.Token),
+	}
+	if err := m.mailer.Send(event.NewEmail, "email_change_confirm.tmpl", data); err != nil {
+		return err
+	}
+
+	data = map[string]any{
+		"newEmail": event.NewEmail,
+	}
+	return m.mailer.Send(event.User.Email, "email_change_notice.tmpl", data)
+}
+
+// emailChangeAttempted sends the current address the same notice as for a
+// free address and tells the owner of the requested one instead of sending
+// a confirmation link.
+func (m *userMailer) emailChangeAttempted(event events.EmailChangeAttempted) error {
+	data := map[string]any{
+		"newEmail": event.Owner.Email,
+	}
+	if err := m.mailer.Send(event.User.Email, "email_change_notice.tmpl", data); err != nil {
+		return err
+	}
+
+	data = map[string]any{
+		"name":              event.Owner.Name,
+		"forgotPasswordURL": m.frontendURL + "/forgot-password",
+	}
+	return m.mailer.Send(event.Owner.Email, "email_in_use.tmpl", data)
+}
+
+func (m *userMailer) registrationAttempted(event events.RegistrationAttempted) error {
+	data := map[string]any{
+		"name":              event.User.Name,
+		"loginURL":          m.frontendURL + "/login",
+		"forgotPasswordURL": m.frontendURL + "/forgot-password",
+	}
+	return m.mailer.Send(event.User.Email, "account_exists.tmpl", data)
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/totp"
+	"DiplomaV2/backend/user/tokenRepository"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"os"
+	"time"
+)
+
+const (
+	totpIssuer         = "TeamFinder"
+	mfaPendingAudience = "TeamFinder-MFA"
+	mfaPendingTTL      = 5 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeTTL    = 10 * 365 * 24 * time.Hour
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrTOTPNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken    = errors.New("two-factor session is invalid or expired")
+)
+
+func (u *userUseCaseImpl) EnrollTOTP(userID int64) (string, string, error) {
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := u.repo.Update(user); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.URI(totpIssuer, user.Email, secret), nil
+}
+
+func (u *userUseCaseImpl) ConfirmTOTP(userID int64, code string) ([]string, error) {
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	if err := u.checkTOTPCode(user, code); err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if err := u.repo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return u.generateRecoveryCodes(user.ID)
+}
+
+func (u *userUseCaseImpl) DisableTOTP(userID int64, password string) error {
+	user, err := u.getWithPassword(userID, password)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := u.repo.Update(user); err != nil {
+		return err
+	}
+
+	return u.tokenRepo.DeleteAllForUser(tokenRepository.ScopeRecoveryCode, user.ID)
+}
+
+func (u *userUseCaseImpl) RegenerateRecoveryCodes(userID int64, password string) ([]string, error) {
+	user, err := u.getWithPassword(userID, password)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	return u.generateRecoveryCodes(user.ID)
+}
+
+// CreateMFAPendingToken issues a short-lived token that only proves the
+// password step succeeded. LoginMiddleware rejects it because of its audience.
+// Its jti is stored as a token so VerifyMFA can accept it only once.
+func (u *userUseCaseImpl) CreateMFAPendingToken(user *entity.User) (string, error) {
+	pending, err := u.tokenRepo.New(user.ID, mfaPendingTTL, tokenRepository.ScopeMFAPending)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+		"jti": pending.Plaintext,
+		"iat": time.Now().Unix(),
+		"exp": pending.Expiry.Unix(),
+		"iss": "TeamFinder",
+		"aud": mfaPendingAudience,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// VerifyMFA completes a login started with a pending token using either a
+// TOTP code or one of the recovery codes. The pending token is consumed
+// before the code is checked, so a replayed token can't burn a recovery code
+// and every pending token gets a single attempt.
+func (u *userUseCaseImpl) VerifyMFA(pendingToken, code, recoveryCode string) (*entity.User, error) {
+	userID, jti, err := parseMFAPendingToken(pendingToken)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed, err := u.tokenRepo.Delete(tokenRepository.ScopeMFAPending, userID, jti)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAToken
+	}
+
+	// The account may be in its deletion grace period, which signing in
+	// cancels.
+	user, err := u.repo.GetByIDIncludingDeleted(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid && !u.withinGracePeriod(user) {
+		return nil, ErrInvalidMFAToken
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	if recoveryCode != "" {
+		consumed, err := u.tokenRepo.Delete(tokenRepository.ScopeRecoveryCode, user.ID, recoveryCode)
+		if err != nil {
+			return nil, err
+		}
+		if !consumed {
+			return nil, ErrInvalidTOTPCode
+		}
+	} else if err := u.checkTOTPCode(user, code); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *userUseCaseImpl) checkTOTPCode(user *entity.User, code string) error {
+	step, ok := totp.Validate(code, user.TOTPSecret, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return ErrInvalidTOTPCode
+	}
+
+	advanced, err := u.repo.AdvanceTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidTOTPCode
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+func (u *userUseCaseImpl) generateRecoveryCodes(userID int64) ([]string, error) {
+	err := u.tokenRepo.DeleteAllForUser(tokenRepository.ScopeRecoveryCode, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		token, err := u.tokenRepo.New(userID, recoveryCodeTTL, tokenRepository.ScopeRecoveryCode)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, token.Plaintext)
+	}
+	return codes, nil
+}
+
+func (u *userUseCaseImpl) getWithPassword(userID int64, password string) (*entity.User, error) {
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+func parseMFAPendingToken(tokenString string) (int64, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyAudience(mfaPendingAudience, true) {
+		return 0, "", ErrInvalidMFAToken
+	}
+
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return 0, "", ErrInvalidMFAToken
+	}
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return 0, "", ErrInvalidMFAToken
+	}
+	return int64(sub), jti, nil
+}
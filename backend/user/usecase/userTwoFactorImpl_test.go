@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/totp"
+	"DiplomaV2/backend/user/tokenRepository"
+	"fmt"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+// fakeTokenRepo keeps tokens in memory, keyed by scope, user and plaintext.
+type fakeTokenRepo struct {
+	tokenRepository.TokenRepository
+	tokens map[string]bool
+	next   int
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{tokens: make(map[string]bool)}
+}
+
+func tokenKey(scope string, userID int64, plaintext string) string {
+	return fmt.Sprintf("%s/%d/%s", scope, userID, plaintext)
+}
+
+func (r *fakeTokenRepo) New(userID int64, ttl time.Duration, scope string) (*entity.Token, error) {
+	r.next++
+	token := &entity.Token{
+		Plaintext: fmt.Sprintf("token-%d", r.next),
+		UserID:    userID,
+		Expiry:    time.Now().Add(ttl),
+		Scope:     scope,
+	}
+	r.tokens[tokenKey(scope, userID, token.Plaintext)] = true
+	return token, nil
+}
+
+func (r *fakeTokenRepo) DeleteAllForUser(scope string, userID int64) error {
+	prefix := tokenKey(scope, userID, "")
+	for key := range r.tokens {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			delete(r.tokens, key)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepo) Delete(scope string, userID int64, tokenPlaintext string) (bool, error) {
+	key := tokenKey(scope, userID, tokenPlaintext)
+	if !r.tokens[key] {
+		return false, nil
+	}
+	delete(r.tokens, key)
+	return true, nil
+}
+
+func (r *fakeUserRepo) AdvanceTOTPStep(id int64, step int64) (bool, error) {
+	user := r.users[id]
+	if step <= user.TOTPLastStep {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// newTwoFactorUseCase returns a use case for a user who has enrolled and
+// confirmed TOTP, along with the secret and the recovery codes.
+func newTwoFactorUseCase(t *testing.T) (*userUseCaseImpl, *fakeUserRepo, *fakeTokenRepo, string, []string) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	repo := newFakeUserRepo(&entity.User{ID: 1, Email: "jane@example.com", Activated: true})
+	tokens := newFakeTokenRepo()
+	uc := NewUserUseCase(repo, tokens, nil, nil, nil, fakeSkillUseCase{}, nil, nil, nil).(*userUseCaseImpl)
+
+	secret, _, err := uc.EnrollTOTP(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := uc.ConfirmTOTP(1, currentCode(t, secret))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	if !repo.users[1].TOTPEnabled {
+		t.Fatal("TOTP not enabled after confirmation")
+	}
+	return uc, repo, tokens, secret, codes
+}
+
+func pendingToken(t *testing.T, uc *userUseCaseImpl, repo *fakeUserRepo) string {
+	t.Helper()
+	token, err := uc.CreateMFAPendingToken(repo.users[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestConfirmTOTPRejectsWrongCode(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	repo := newFakeUserRepo(&entity.User{ID: 1, Email: "jane@example.com"})
+	uc := NewUserUseCase(repo, newFakeTokenRepo(), nil, nil, nil, fakeSkillUseCase{}, nil, nil, nil)
+
+	if _, _, err := uc.EnrollTOTP(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ConfirmTOTP(1, "000000x"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("err = %v, want ErrInvalidTOTPCode", err)
+	}
+	if repo.users[1].TOTPEnabled {
+		t.Fatal("TOTP enabled with a wrong code")
+	}
+}
+
+func TestVerifyMFAWithTOTPCode(t *testing.T) {
+	uc, repo, _, secret, _ := newTwoFactorUseCase(t)
+
+	// The confirmation code's step is spent; the next accepted code comes
+	// from a later step.
+	repo.users[1].TOTPLastStep = totp.Step(time.Now()) - 1
+	code := currentCode(t, secret)
+	user, err := uc.VerifyMFA(pendingToken(t, uc, repo), code, "")
+	if err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if user.ID != 1 {
+		t.Fatalf("user = %d, want 1", user.ID)
+	}
+
+	if _, err := uc.VerifyMFA(pendingToken(t, uc, repo), code, ""); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("reused code: err = %v, want ErrInvalidTOTPCode", err)
+	}
+}
+
+func TestVerifyMFARecoveryCodeWorksOnce(t *testing.T) {
+	uc, repo, _, _, codes := newTwoFactorUseCase(t)
+
+	if _, err := uc.VerifyMFA(pendingToken(t, uc, repo), "", codes[0]); err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if _, err := uc.VerifyMFA(pendingToken(t, uc, repo), "", codes[0]); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("reused recovery code: err = %v, want ErrInvalidTOTPCode", err)
+	}
+	if _, err := uc.VerifyMFA(pendingToken(t, uc, repo), "", codes[1]); err != nil {
+		t.Fatalf("second recovery code: err = %v", err)
+	}
+}
+
+func TestVerifyMFARejectsReplayedPendingToken(t *testing.T) {
+	uc, repo, tokens, _, codes := newTwoFactorUseCase(t)
+
+	pending := pendingToken(t, uc, repo)
+	if _, err := uc.VerifyMFA(pending, "", codes[0]); err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+
+	if _, err := uc.VerifyMFA(pending, "", codes[1]); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("replayed token: err = %v, want ErrInvalidMFAToken", err)
+	}
+	if !tokens.tokens[tokenKey(tokenRepository.ScopeRecoveryCode, 1, codes[1])] {
+		t.Fatal("replayed token burned a recovery code")
+	}
+}
+
+func TestVerifyMFAFailedCodeSpendsPendingToken(t *testing.T) {
+	uc, repo, _, _, codes := newTwoFactorUseCase(t)
+
+	pending := pendingToken(t, uc, repo)
+	if _, err := uc.VerifyMFA(pending, "", "not-a-code"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("err = %v, want ErrInvalidTOTPCode", err)
+	}
+	if _, err := uc.VerifyMFA(pending, "", codes[0]); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("retry: err = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestPasswordAloneDoesNotRestoreTwoFactorAccount(t *testing.T) {
+	uc, repo, _, _, codes := newTwoFactorUseCase(t)
+	uc.accounts = &config.Accounts{DeletionGracePeriod: 24 * time.Hour}
+	if err := repo.users[1].Password.Set("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	repo.users[1].DeletedAt = gorm.DeletedAt{Time: time.Now().Add(-time.Hour), Valid: true}
+
+	if _, err := uc.Authentication("jane@example.com", "correct horse battery"); err != nil {
+		t.Fatalf("Authentication() error = %v", err)
+	}
+	if !repo.users[1].DeletedAt.Valid {
+		t.Fatal("the password step restored the account")
+	}
+
+	user, err := uc.VerifyMFA(pendingToken(t, uc, repo), "", codes[0])
+	if err != nil {
+		t.Fatalf("VerifyMFA() error = %v", err)
+	}
+	if _, err := uc.CreateAuthenticationToken(user); err != nil {
+		t.Fatal(err)
+	}
+	if repo.users[1].DeletedAt.Valid {
+		t.Fatal("signing in didn't restore the account")
+	}
+}
@@ -2,20 +2,40 @@ package usecase
 
 import (
 	"DiplomaV2/backend/internal/entity"
+	"golang.org/x/net/context"
 	"mime/multipart"
+	"time"
 )
 
 type UserUseCase interface {
-	Registration(user *entity.User) (*entity.Token, error)
+	Registration(user *entity.User) error
 	Activation(token string) error
-	Authentication(user *entity.User) (string, error)
-	GetAllUsers() ([]*entity.User, error)
+	ResendActivation(email string) error
+	ActivationTTL() time.Duration
+	Authentication(email, password string) (*entity.User, error)
+	CreateAuthenticationToken(user *entity.User) (string, error)
+	ValidateSession(userID int64, issuedAt time.Time) (bool, error)
+	GetRole(userID int64) (string, error)
+	GetAllUsers(viewerID int64, skills []string, levels []entity.SkillLevelFilter) ([]*entity.User, error)
 	GetUserById(id int64) (*entity.User, error)
 	GetUserByEmail(email string) (*entity.User, error)
+	GetUserByUsername(username string) (*entity.User, bool, error)
 	UpdateUserInfo(user *entity.User) error
+	UpdatePrivacy(userID int64, privacy entity.ProfilePrivacy) error
 	UploadProfileImage(userID int64, file *multipart.FileHeader) (string, error)
 	ChangePassword(userID int64, currentPassword, newPassword string) error
-	ForgotPassword(email string) (string, error)
+	ForgotPassword(email string) error
 	ResetPassword(string, string) error
 	DeleteUser(id int64) error
+	PurgeDeletedUsers(ctx context.Context) error
+	RequestEmailChange(userID int64, password, newEmail string) error
+	ConfirmEmailChange(token string) (*entity.User, error)
+	EnrollTOTP(userID int64) (string, string, error)
+	ConfirmTOTP(userID int64, code string) ([]string, error)
+	DisableTOTP(userID int64, password string) error
+	RegenerateRecoveryCodes(userID int64, password string) ([]string, error)
+	CreateMFAPendingToken(user *entity.User) (string, error)
+	VerifyMFA(pendingToken, code, recoveryCode string) (*entity.User, error)
+	StartVerification(userID int64, platform string) (*entity.HandleVerification, string, error)
+	CheckVerification(ctx context.Context, userID int64, platform string) error
 }
@@ -1,30 +1,55 @@
 package usecase
 
 import (
+	"DiplomaV2/backend/internal/config"
 	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
 	"DiplomaV2/backend/internal/helpers"
 	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/internal/verify"
+	skillUseCase "DiplomaV2/backend/skill/usecase"
 	"DiplomaV2/backend/user/repository"
 	"DiplomaV2/backend/user/tokenRepository"
+	"cloud.google.com/go/storage"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/net/context"
 	"gorm.io/gorm"
 	"mime/multipart"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type userUseCaseImpl struct {
 	repo      repository.UserRepository
 	tokenRepo tokenRepository.TokenRepository
+	lockout   *config.Lockout
+	tokens    *config.Tokens
+	accounts  *config.Accounts
+	skills    skillUseCase.SkillUseCase
+	bus       *events.Bus
+	// verifiers holds the platforms handles can be verified on.
+	verifiers    map[string]verify.Verifier
+	verification *config.Verification
 }
 
-func (u *userUseCaseImpl) GetAllUsers() ([]*entity.User, error) {
-	users, err := u.repo.GetAll()
+func (u *userUseCaseImpl) GetAllUsers(viewerID int64, skills []string, levels []entity.SkillLevelFilter) ([]*entity.User, error) {
+	skills, err := u.skills.Normalize(skills)
+	if err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		if levels[i].Skill, err = u.skills.Canonical(levels[i].Skill); err != nil {
+			return nil, err
+		}
+	}
+
+	users, err := u.repo.GetAll(viewerID, skills, levels)
 	if err != nil {
 		return nil, err
 	}
@@ -32,14 +57,33 @@ func (u *userUseCaseImpl) GetAllUsers() ([]*entity.User, error) {
 }
 
 var (
-	TokenCreationFailed = errors.New("Token creation failed")
-	ErrWrongPassword    = errors.New("Wrong password")
-	InvalidToken        = errors.New("Token is invalid")
+	TokenCreationFailed   = errors.New("Token creation failed")
+	ErrWrongPassword      = errors.New("Wrong password")
+	InvalidToken          = errors.New("Token is invalid")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountLocked      = errors.New("account is temporarily locked")
+	ErrNotActivated       = errors.New("user is not activated")
+	ErrSameEmail          = errors.New("new email is the same as the current one")
+	ErrAlreadyActivated   = errors.New("user is already activated")
+)
+
+const (
+	gcsCredentialsFile  = "C:/Users/krump/Downloads/lucid-volt-424719-f0-5df86076a210.json"
+	gcsBucket           = "teamfinderimages"
+	defaultProfileImage = "https://storage.googleapis.com/teamfinderimages/default_photo.png"
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
 )
 
 func (u *userUseCaseImpl) Activation(tokenPlaintext string) error {
 	user, err := u.repo.GetForToken(tokenRepository.ScopeActivation, tokenPlaintext)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return InvalidToken
+		}
 		return err
 	}
 	user.Activated = true
@@ -52,7 +96,37 @@ func (u *userUseCaseImpl) Activation(tokenPlaintext string) error {
 	if err != nil {
 		return err
 	}
-	return err
+
+	u.bus.Publish(events.UserActivated{User: *user})
+	return nil
+}
+
+// ResendActivation replaces any outstanding activation token with a new one.
+func (u *userUseCaseImpl) ResendActivation(email string) error {
+	user, err := u.repo.GetByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user.Activated {
+		return ErrAlreadyActivated
+	}
+
+	err = u.tokenRepo.DeleteAllForUser(tokenRepository.ScopeActivation, user.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := u.createActivationToken(user)
+	if err != nil {
+		return err
+	}
+
+	u.bus.Publish(events.ActivationRequested{User: *user, Token: *token})
+	return nil
+}
+
+func (u *userUseCaseImpl) ActivationTTL() time.Duration {
+	return u.tokens.ActivationTTL
 }
 
 func (u *userUseCaseImpl) ChangePassword(userId int64, currentPassword string, newPassword string) error {
@@ -85,10 +159,59 @@ func (u *userUseCaseImpl) ChangePassword(userId int64, currentPassword string, n
 		return err
 	}
 
-	return err
+	u.bus.Publish(events.PasswordChanged{User: *user})
+	return nil
 }
 
-func (u *userUseCaseImpl) Authentication(user *entity.User) (string, error) {
+// Authentication checks the credentials and applies the progressive lockout.
+// Unknown emails and wrong passwords produce the same error.
+func (u *userUseCaseImpl) Authentication(email, password string) (*entity.User, error) {
+	user, err := u.repo.GetByEmailIncludingDeleted(email)
+	if err == nil && user.DeletedAt.Valid && !u.withinGracePeriod(user) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Spend the same bcrypt time as a real comparison so response
+			// timing doesn't reveal whether the account exists.
+			_ = bcrypt.CompareHashAndPassword(getDummyHash(), []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		_ = bcrypt.CompareHashAndPassword(getDummyHash(), []byte(password))
+		return nil, ErrAccountLocked
+	}
+
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if !match {
+		if err := u.registerFailedLogin(user); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.Activated {
+		return nil, ErrNotActivated
+	}
+
+	return user, nil
+}
+
+// CreateAuthenticationToken issues a full session. Only then is a pending
+// account deletion cancelled and are the failed logins cleared, so the
+// password alone does neither for an account with two-factor login.
+func (u *userUseCaseImpl) CreateAuthenticationToken(user *entity.User) (string, error) {
+	if err := u.completeLogin(user); err != nil {
+		return TokenCreationFailed.Error(), err
+	}
+
 	token, err := u.createAuthenticationToken(user)
 	if err != nil {
 		return TokenCreationFailed.Error(), err
@@ -96,17 +219,102 @@ func (u *userUseCaseImpl) Authentication(user *entity.User) (string, error) {
 	return token, nil
 }
 
-func (u *userUseCaseImpl) Registration(user *entity.User) (*entity.Token, error) {
-	err := u.repo.Insert(user)
+func (u *userUseCaseImpl) completeLogin(user *entity.User) error {
+	// Logging in during the grace period cancels a pending account deletion.
+	if user.DeletedAt.Valid {
+		if err := u.repo.Restore(user.ID); err != nil {
+			return err
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := u.repo.ResetFailedLogins(user.ID); err != nil {
+			return err
+		}
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+	}
+	return nil
+}
+
+// ValidateSession rejects session tokens of deleted users and tokens issued
+// before the user's sessions were revoked.
+func (u *userUseCaseImpl) ValidateSession(userID int64, issuedAt time.Time) (bool, error) {
+	revokedAt, err := u.repo.GetSessionsRevokedAt(userID)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return revokedAt == nil || issuedAt.After(*revokedAt), nil
+}
+
+// GetRole returns the current role of the user, which may differ from the
+// one in their session token.
+func (u *userUseCaseImpl) GetRole(userID int64) (string, error) {
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// registerFailedLogin locks the account once MaxAttempts is reached, doubling
+// the lock for every further failure up to MaxDuration.
+func (u *userUseCaseImpl) registerFailedLogin(user *entity.User) error {
+	return u.repo.IncrementFailedLogins(user, u.lockout.MaxAttempts, u.lockout.Duration, u.lockout.MaxDuration)
+}
+
+func getDummyHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), 12)
+	})
+	return dummyHash
+}
+
+func (u *userUseCaseImpl) Registration(user *entity.User) error {
+	taken, err := u.repo.UsernameTaken(user.Username, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return repository.ErrDuplicateUsername
+	}
+
+	err = u.repo.Insert(user)
+	if errors.Is(err, repository.ErrDuplicateEmail) {
+		return u.registrationAttempted(user.Email)
+	}
+	if err != nil {
+		return err
 	}
 
 	token, err := u.createActivationToken(user)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return token, nil
+
+	u.bus.Publish(events.UserRegistered{User: *user, Token: *token})
+	return nil
+}
+
+// registrationAttempted emails the owner of an existing account instead of
+// failing the registration, so it can't be used to find out which emails
+// have an account.
+func (u *userUseCaseImpl) registrationAttempted(email string) error {
+	existing, err := u.repo.GetByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The account is in its deletion grace period.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	u.bus.Publish(events.RegistrationAttempted{User: *existing})
+	return nil
 }
 
 func (u *userUseCaseImpl) UpdateUserInfo(user *entity.User) error {
@@ -115,12 +323,31 @@ func (u *userUseCaseImpl) UpdateUserInfo(user *entity.User) error {
 		return err
 	}
 
+	oldUsername := existingUser.Username
+	if user.Username != oldUsername {
+		taken, err := u.repo.UsernameTaken(user.Username, user.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return repository.ErrDuplicateUsername
+		}
+	}
+
 	existingUser.Name = user.Name
 	existingUser.Surname = user.Surname
 	existingUser.Username = user.Username
-	existingUser.Telegram = user.Telegram
-	existingUser.Discord = user.Discord
-	existingUser.Skills = user.Skills
+	if existingUser.Telegram != user.Telegram {
+		existingUser.Telegram = user.Telegram
+		existingUser.TelegramVerified = false
+	}
+	if existingUser.Discord != user.Discord {
+		existingUser.Discord = user.Discord
+		existingUser.DiscordVerified = false
+	}
+	if err := u.applySkills(existingUser, user); err != nil {
+		return err
+	}
 	existingUser.Version++
 
 	if user.ProfileImage != "" {
@@ -131,9 +358,75 @@ func (u *userUseCaseImpl) UpdateUserInfo(user *entity.User) error {
 	if err != nil {
 		return err
 	}
+	// A change of case only doesn't break links, usernames are case-insensitive.
+	if !strings.EqualFold(oldUsername, existingUser.Username) {
+		if err := u.repo.RecordUsernameChange(existingUser.ID, oldUsername, existingUser.Username); err != nil {
+			return err
+		}
+	}
+	return u.repo.ReplaceSkillLevels(existingUser.ID, existingUser.SkillLevels)
+}
+
+func (u *userUseCaseImpl) UpdatePrivacy(userID int64, privacy entity.ProfilePrivacy) error {
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	user.Privacy = privacy
+	user.Version++
+	return u.repo.Update(user)
+}
+
+// applySkills copies the skills of update onto user. When levels are sent
+// they define the flat list; otherwise the flat list is used as is and the
+// levels of removed skills are dropped.
+func (u *userUseCaseImpl) applySkills(user, update *entity.User) error {
+	if update.SkillLevels == nil {
+		skills, err := u.skills.Normalize(update.Skills)
+		if err != nil {
+			return err
+		}
+
+		kept := make([]entity.UserSkill, 0, len(user.SkillLevels))
+		for _, level := range user.SkillLevels {
+			if containsFold(skills, level.Skill) {
+				kept = append(kept, level)
+			}
+		}
+		user.Skills = skills
+		user.SkillLevels = kept
+		return nil
+	}
+
+	skills := make([]string, 0, len(update.SkillLevels))
+	levels := make([]entity.UserSkill, 0, len(update.SkillLevels))
+	for _, level := range update.SkillLevels {
+		name, err := u.skills.Canonical(level.Skill)
+		if err != nil {
+			return err
+		}
+		if containsFold(skills, name) {
+			continue
+		}
+		level.Skill = name
+		skills = append(skills, name)
+		levels = append(levels, level)
+	}
+	user.Skills = skills
+	user.SkillLevels = levels
 	return nil
 }
 
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *userUseCaseImpl) UploadProfileImage(userID int64, file *multipart.FileHeader) (string, error) {
 	user, err := u.repo.GetByID(userID)
 	if err != nil {
@@ -156,17 +449,17 @@ func (u *userUseCaseImpl) UploadProfileImage(userID int64, file *multipart.FileH
 
 	// Initialize GCS client
 	ctx := context.Background()
-	client, err := helpers.NewStorageClient(ctx, "C:/Users/krump/Downloads/lucid-volt-424719-f0-5df86076a210.json")
+	client, err := helpers.NewStorageClient(ctx, gcsCredentialsFile)
 	if err != nil {
 		return "", errors.New("Failed to initialize GCS client")
 	}
 
 	objectName := fmt.Sprintf("%d/%d", userID, user.Version) // Unique object name based on user ID
-	if err := helpers.UploadFileToGCS(ctx, client, "teamfinderimages", objectName, src); err != nil {
+	if err := helpers.UploadFileToGCS(ctx, client, gcsBucket, objectName, src); err != nil {
 		return "", errors.New("Failed to upload file to GCS")
 	}
 
-	flood := user.ProfileImage == "https://storage.googleapis.com/teamfinderimages/default_photo.png"
+	flood := user.ProfileImage == defaultProfileImage
 	baseUrl := "https://storage.googleapis.com/teamfinderimages/" + strconv.FormatInt(user.ID, 10) + "/"
 	var profileImageURL string
 
@@ -189,6 +482,8 @@ func (u *userUseCaseImpl) UploadProfileImage(userID int64, file *multipart.FileH
 	return profileImageURL, nil
 }
 
+// DeleteUser only marks the account as deleted. The data is removed by
+// PurgeDeletedUsers once the grace period is over.
 func (u *userUseCaseImpl) DeleteUser(id int64) error {
 	err := u.repo.Delete(id)
 	if err != nil {
@@ -197,6 +492,55 @@ func (u *userUseCaseImpl) DeleteUser(id int64) error {
 	return err
 }
 
+func (u *userUseCaseImpl) PurgeDeletedUsers(ctx context.Context) error {
+	users, err := u.repo.GetDeletedBefore(time.Now().Add(-u.accounts.DeletionGracePeriod))
+	if err != nil {
+		return err
+	}
+
+	var client *storage.Client
+	for _, user := range users {
+		objectName, ok := profileImageObject(user)
+
+		err := u.repo.Purge(user, func() error {
+			if !ok {
+				return nil
+			}
+			if client == nil {
+				newClient, err := helpers.NewStorageClient(ctx, gcsCredentialsFile)
+				if err != nil {
+					return err
+				}
+				client = newClient
+			}
+			err := helpers.DeleteFileFromGCS(ctx, client, gcsBucket, objectName)
+			if errors.Is(err, storage.ErrObjectNotExist) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "purging user %d", user.ID)
+		}
+	}
+
+	return nil
+}
+
+func (u *userUseCaseImpl) withinGracePeriod(user *entity.User) bool {
+	return user.DeletedAt.Time.Add(u.accounts.DeletionGracePeriod).After(time.Now())
+}
+
+// profileImageObject returns the GCS object behind a user's uploaded image.
+// The shared default photo is never deleted.
+func profileImageObject(user *entity.User) (string, bool) {
+	prefix := "https://storage.googleapis.com/" + gcsBucket + "/"
+	if !strings.HasPrefix(user.ProfileImage, prefix) || user.ProfileImage == defaultProfileImage {
+		return "", false
+	}
+	return strings.TrimPrefix(user.ProfileImage, prefix), true
+}
+
 func (u *userUseCaseImpl) GetUserById(id int64) (*entity.User, error) {
 	user, err := u.repo.GetByID(id)
 	if err != nil {
@@ -205,6 +549,24 @@ func (u *userUseCaseImpl) GetUserById(id int64) (*entity.User, error) {
 	return user, nil
 }
 
+// GetUserByUsername also finds users by a previous username, in which case
+// moved is true and the caller should redirect to the current one.
+func (u *userUseCaseImpl) GetUserByUsername(username string) (*entity.User, bool, error) {
+	user, err := u.repo.GetByUsername(username)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	user, err = u.repo.GetByPreviousUsername(username)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
 func (u *userUseCaseImpl) GetUserByEmail(email string) (*entity.User, error) {
 	user, err := u.repo.GetByEmail(email)
 	if err != nil {
@@ -212,18 +574,19 @@ func (u *userUseCaseImpl) GetUserByEmail(email string) (*entity.User, error) {
 	}
 	return user, nil
 }
-func (u *userUseCaseImpl) ForgotPassword(email string) (string, error) {
+func (u *userUseCaseImpl) ForgotPassword(email string) error {
 	user, err := u.repo.GetByEmail(email)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	token, err := u.tokenRepo.New(user.ID, 24*time.Hour, tokenRepository.ScopePasswordReset)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return token.Plaintext, nil
+	u.bus.Publish(events.PasswordResetRequested{User: *user, Token: token.Plaintext})
+	return nil
 }
 
 func (u *userUseCaseImpl) ResetPassword(tokenString, newPassword string) error {
@@ -255,11 +618,85 @@ func (u *userUseCaseImpl) ResetPassword(tokenString, newPassword string) error {
 		return err
 	}
 
+	u.bus.Publish(events.PasswordChanged{User: *user, Reset: true})
+	return nil
+}
+
+// RequestEmailChange stores the new address as pending and returns a token
+// for confirming it. The current email stays in place until the token is redeemed.
+func (u *userUseCaseImpl) RequestEmailChange(userID int64, password, newEmail string) error {
+	user, err := u.getWithPassword(userID, password)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return ErrSameEmail
+	}
+
+	existing, err := u.repo.GetByEmail(newEmail)
+	if err == nil {
+		// Answered like a free address, so this can't be used to find out
+		// which emails have an account.
+		u.bus.Publish(events.EmailChangeAttempted{User: *user, Owner: *existing})
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	user.PendingEmail = newEmail
+	if err := u.repo.Update(user); err != nil {
+		return err
+	}
+
+	err = u.tokenRepo.DeleteAllForUser(tokenRepository.ScopeEmailChange, user.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := u.tokenRepo.New(user.ID, 24*time.Hour, tokenRepository.ScopeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	u.bus.Publish(events.EmailChangeRequested{User: *user, NewEmail: newEmail, Token: token.Plaintext})
 	return nil
 }
 
+func (u *userUseCaseImpl) ConfirmEmailChange(tokenPlaintext string) (*entity.User, error) {
+	user, err := u.repo.GetForToken(tokenRepository.ScopeEmailChange, tokenPlaintext)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, InvalidToken
+		}
+		return nil, err
+	}
+
+	if user.PendingEmail == "" {
+		return nil, InvalidToken
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	user.Version++
+
+	// The unique constraint on email is the final check in case the address
+	// was registered by someone else after the change was requested.
+	if err := u.repo.Update(user); err != nil {
+		return nil, err
+	}
+
+	err = u.tokenRepo.DeleteAllForUser(tokenRepository.ScopeEmailChange, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (u *userUseCaseImpl) createActivationToken(user *entity.User) (*entity.Token, error) {
-	token, err := u.tokenRepo.New(user.ID, 1*time.Hour, tokenRepository.ScopeActivation)
+	token, err := u.tokenRepo.New(user.ID, u.tokens.ActivationTTL, tokenRepository.ScopeActivation)
 	if token == nil || err != nil {
 		return nil, err
 	}
@@ -268,12 +705,13 @@ func (u *userUseCaseImpl) createActivationToken(user *entity.User) (*entity.Toke
 
 func (u *userUseCaseImpl) createAuthenticationToken(user *entity.User) (string, error) {
 	claims := jwt.MapClaims{
-		"sub": user.ID,
-		"iat": time.Now().Unix(),
-		"nbf": time.Now().Unix(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
-		"iss": "TeamFinder",
-		"aud": "TeamFinder",
+		"sub":  user.ID,
+		"role": user.Role,
+		"iat":  time.Now().Unix(),
+		"nbf":  time.Now().Unix(),
+		"exp":  time.Now().Add(24 * time.Hour).Unix(),
+		"iss":  "TeamFinder",
+		"aud":  "TeamFinder",
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -286,9 +724,16 @@ func (u *userUseCaseImpl) createAuthenticationToken(user *entity.User) (string,
 	return jwtToken, nil
 }
 
-func NewUserUseCase(repo repository.UserRepository, tokenRepo tokenRepository.TokenRepository) UserUseCase {
+func NewUserUseCase(repo repository.UserRepository, tokenRepo tokenRepository.TokenRepository, lockout *config.Lockout, tokens *config.Tokens, accounts *config.Accounts, skills skillUseCase.SkillUseCase, bus *events.Bus, verifiers map[string]verify.Verifier, verification *config.Verification) UserUseCase {
 	return &userUseCaseImpl{
-		repo:      repo,
-		tokenRepo: tokenRepo,
+		repo:         repo,
+		tokenRepo:    tokenRepo,
+		lockout:      lockout,
+		tokens:       tokens,
+		accounts:     accounts,
+		skills:       skills,
+		bus:          bus,
+		verifiers:    verifiers,
+		verification: verification,
 	}
 }
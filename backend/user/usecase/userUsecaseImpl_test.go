@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+	"DiplomaV2/backend/user/repository"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"strings"
+	"testing"
+)
+
+// violatingDriver is a database/sql driver that fails every statement the
+// way Postgres does when the unique constraint named by the DSN is violated.
+type violatingDriver struct{}
+
+func (violatingDriver) Open(constraint string) (driver.Conn, error) {
+	return violatingConn{constraint: constraint}, nil
+}
+
+type violatingConn struct {
+	constraint string
+}
+
+func (c violatingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, c.err()
+}
+
+func (violatingConn) Close() error { return nil }
+
+func (c violatingConn) Begin() (driver.Tx, error) { return violatingTx{}, nil }
+
+func (c violatingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, c.err()
+}
+
+func (c violatingConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return nil, c.err()
+}
+
+func (c violatingConn) err() error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", c.constraint),
+		TableName:      "users",
+		ConstraintName: c.constraint,
+	}
+}
+
+type violatingTx struct{}
+
+func (violatingTx) Commit() error { return nil }
+
+func (violatingTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("violating", violatingDriver{})
+}
+
+type testDatabase struct {
+	db *gorm.DB
+}
+
+func (t testDatabase) GetDb() *gorm.DB { return t.db }
+
+// violatingRepo returns the real repository on a database that rejects
+// every statement for constraint, so the tests get the errors the handlers
+// see.
+func violatingRepo(constraint string) (repository.UserRepository, func()) {
+	sqlDB, err := sql.Open("violating", constraint)
+	if err != nil {
+		panic(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		panic(err)
+	}
+	return repository.NewUserRepository(testDatabase{db: db}), func() { sqlDB.Close() }
+}
+
+// conflict returns the unique constraint user would violate.
+func (r *fakeUserRepo) conflict(user *entity.User) string {
+	for _, existing := range r.users {
+		if existing.ID == user.ID {
+			continue
+		}
+		if strings.EqualFold(existing.Email, user.Email) {
+			return "uni_users_email"
+		}
+		if strings.EqualFold(existing.Username, user.Username) {
+			return "uni_users_username"
+		}
+	}
+	return ""
+}
+
+func (r *fakeUserRepo) Insert(user *entity.User) error {
+	if constraint := r.conflict(user); constraint != "" {
+		violating, closeDB := violatingRepo(constraint)
+		defer closeDB()
+		return violating.Insert(user)
+	}
+	user.ID = int64(len(r.users) + 1)
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*entity.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestRegistrationWithTakenEmailNotifiesOwner(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{ID: 1, Name: "Jane", Username: "jane", Email: "jane@example.com", Activated: true})
+	bus := events.New(echo.New().Logger)
+	var attempted []events.RegistrationAttempted
+	events.Subscribe(bus, func(event events.RegistrationAttempted) error {
+		attempted = append(attempted, event)
+		return nil
+	})
+	uc := NewUserUseCase(repo, nil, nil, nil, nil, fakeSkillUseCase{}, bus, nil, nil)
+
+	err := uc.Registration(&entity.User{Name: "Mallory", Username: "mallory", Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("Registration = %v, want nil so the email isn't revealed as taken", err)
+	}
+	if len(attempted) != 1 || attempted[0].User.ID != 1 {
+		t.Fatalf("attempted = %+v, want one notice for user 1", attempted)
+	}
+	if len(repo.users) != 1 {
+		t.Error("no account should be created")
+	}
+}
+
+func TestRegistrationLosingUsernameRace(t *testing.T) {
+	// UsernameTaken in the fake never sees the other account, like a
+	// registration that commits between the check and the insert.
+	repo := newFakeUserRepo(&entity.User{ID: 1, Name: "Jane", Username: "jane", Email: "jane@example.com", Activated: true})
+	uc := NewUserUseCase(repo, nil, nil, nil, nil, fakeSkillUseCase{}, events.New(echo.New().Logger), nil, nil)
+
+	err := uc.Registration(&entity.User{Name: "Jane", Username: "Jane", Email: "other@example.com"})
+	if !errors.Is(err, repository.ErrDuplicateUsername) {
+		t.Fatalf("Registration = %v, want ErrDuplicateUsername", err)
+	}
+}
+
+// tokenUserRepo finds users by their tokens in tokens, like the join in the
+// real repository.
+type tokenUserRepo struct {
+	*fakeUserRepo
+	tokens *fakeTokenRepo
+}
+
+func (r tokenUserRepo) GetForToken(scope, tokenPlaintext string) (*entity.User, error) {
+	for id := range r.users {
+		if r.tokens.tokens[tokenKey(scope, id, tokenPlaintext)] {
+			return r.GetByID(id)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newEmailChangeUseCase(t *testing.T, users ...*entity.User) (UserUseCase, *fakeUserRepo, *events.Bus) {
+	t.Helper()
+	for _, user := range users {
+		if err := user.Password.Set("correct horse battery"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := newFakeUserRepo(users...)
+	tokens := newFakeTokenRepo()
+	bus := events.New(echo.New().Logger)
+	uc := NewUserUseCase(tokenUserRepo{fakeUserRepo: repo, tokens: tokens}, tokens, nil, nil, nil, fakeSkillUseCase{}, bus, nil, nil)
+	return uc, repo, bus
+}
+
+func TestRequestEmailChangeToTakenAddressNotifiesOwner(t *testing.T) {
+	uc, repo, bus := newEmailChangeUseCase(t,
+		&entity.User{ID: 1, Username: "jane", Email: "jane@example.com"},
+		&entity.User{ID: 2, Username: "john", Email: "john@example.com"})
+	var attempted []events.EmailChangeAttempted
+	events.Subscribe(bus, func(event events.EmailChangeAttempted) error {
+		attempted = append(attempted, event)
+		return nil
+	})
+	events.Subscribe(bus, func(event events.EmailChangeRequested) error {
+		t.Errorf("confirmation requested for %s", event.NewEmail)
+		return nil
+	})
+
+	if err := uc.RequestEmailChange(1, "correct horse battery", "JOHN@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange = %v, want nil so the email isn't revealed as taken", err)
+	}
+	if len(attempted) != 1 || attempted[0].User.ID != 1 || attempted[0].Owner.ID != 2 {
+		t.Fatalf("attempted = %+v, want one notice from user 1 to user 2", attempted)
+	}
+	if repo.users[1].PendingEmail != "" {
+		t.Errorf("PendingEmail = %q, want none", repo.users[1].PendingEmail)
+	}
+}
+
+func TestConfirmEmailChangeLosingRace(t *testing.T) {
+	uc, repo, bus := newEmailChangeUseCase(t, &entity.User{ID: 1, Username: "jane", Email: "jane@example.com"})
+	var token string
+	events.Subscribe(bus, func(event events.EmailChangeRequested) error {
+		token = event.Token
+		return nil
+	})
+
+	if err := uc.RequestEmailChange(1, "correct horse battery", "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// The address is registered before the change is confirmed.
+	repo.users[2] = &entity.User{ID: 2, Username: "john", Email: "new@example.com"}
+
+	if _, err := uc.ConfirmEmailChange(token); !errors.Is(err, repository.ErrDuplicateEmail) {
+		t.Fatalf("ConfirmEmailChange = %v, want ErrDuplicateEmail", err)
+	}
+	if repo.users[1].Email != "jane@example.com" {
+		t.Errorf("Email = %q, want it unchanged", repo.users[1].Email)
+	}
+}
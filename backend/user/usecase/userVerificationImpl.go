@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/validator"
+	"DiplomaV2/backend/internal/verify"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownPlatform         = errors.New("unknown platform")
+	ErrVerificationUnavailable = errors.New("verification is not available for this platform")
+	ErrNoHandle                = errors.New("set your handle for this platform first")
+	ErrAlreadyVerified         = errors.New("handle is already verified")
+	ErrVerificationExpired     = errors.New("verification code has expired or the handle changed, start again")
+	ErrCodeNotFound            = errors.New("verification code wasn't found yet")
+)
+
+// StartVerification issues a code the user posts on the platform to prove
+// they own their handle, with instructions on where to post it.
+func (u *userUseCaseImpl) StartVerification(userID int64, platform string) (*entity.HandleVerification, string, error) {
+	verifier, err := u.verifier(platform)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	handle := user.Handle(platform)
+	if handle == "" {
+		return nil, "", ErrNoHandle
+	}
+	if handleVerified(user, platform) {
+		return nil, "", ErrAlreadyVerified
+	}
+
+	code, err := verify.GenerateCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	verification := &entity.HandleVerification{
+		UserID:    user.ID,
+		Platform:  platform,
+		Handle:    handle,
+		Code:      code,
+		ExpiresAt: time.Now().Add(u.verification.CodeTTL),
+	}
+	if err := u.repo.SaveVerification(verification); err != nil {
+		return nil, "", err
+	}
+	return verification, verifier.Instructions(code), nil
+}
+
+// CheckVerification asks the platform whether the code has been posted by
+// the handle and marks the handle verified if so.
+func (u *userUseCaseImpl) CheckVerification(ctx context.Context, userID int64, platform string) error {
+	verifier, err := u.verifier(platform)
+	if err != nil {
+		return err
+	}
+
+	verification, err := u.repo.GetVerification(userID, platform)
+	if err != nil {
+		return err
+	}
+
+	user, err := u.repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if verification.ExpiresAt.Before(time.Now()) || verification.Handle != user.Handle(platform) {
+		return ErrVerificationExpired
+	}
+
+	found, err := verifier.Verify(ctx, verification.Handle, verification.Code)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCodeNotFound
+	}
+
+	switch platform {
+	case entity.PlatformTelegram:
+		user.TelegramVerified = true
+	case entity.PlatformDiscord:
+		user.DiscordVerified = true
+	}
+	user.Version++
+	if err := u.repo.Update(user); err != nil {
+		return err
+	}
+	return u.repo.DeleteVerification(userID, platform)
+}
+
+func (u *userUseCaseImpl) verifier(platform string) (verify.Verifier, error) {
+	if !validator.PermittedValue(platform, entity.Platforms...) {
+		return nil, ErrUnknownPlatform
+	}
+	verifier, ok := u.verifiers[platform]
+	if !ok {
+		return nil, ErrVerificationUnavailable
+	}
+	return verifier, nil
+}
+
+func handleVerified(user *entity.User, platform string) bool {
+	switch platform {
+	case entity.PlatformTelegram:
+		return user.TelegramVerified
+	case entity.PlatformDiscord:
+		return user.DiscordVerified
+	}
+	return false
+}
+
+// NormalizeTelegram drops the leading @ people usually type.
+func NormalizeTelegram(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
+
+// NormalizeDiscord lowercases the handle, since Discord usernames are
+// case-insensitive.
+func NormalizeDiscord(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
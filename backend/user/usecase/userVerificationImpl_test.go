@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/verify"
+	skillUseCase "DiplomaV2/backend/skill/usecase"
+	"DiplomaV2/backend/user/repository"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	users         map[int64]*entity.User
+	verifications map[string]*entity.HandleVerification
+}
+
+func newFakeUserRepo(users ...*entity.User) *fakeUserRepo {
+	r := &fakeUserRepo{
+		users:         make(map[int64]*entity.User),
+		verifications: make(map[string]*entity.HandleVerification),
+	}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetByID(id int64) (*entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) Update(user *entity.User) error {
+	if constraint := r.conflict(user); constraint != "" {
+		violating, closeDB := violatingRepo(constraint)
+		defer closeDB()
+		return violating.Update(user)
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) UsernameTaken(string, int64) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeUserRepo) RecordUsernameChange(int64, string, string) error {
+	return nil
+}
+
+func (r *fakeUserRepo) ReplaceSkillLevels(int64, []entity.UserSkill) error {
+	return nil
+}
+
+func (r *fakeUserRepo) SaveVerification(verification *entity.HandleVerification) error {
+	r.verifications[verification.Platform] = verification
+	return nil
+}
+
+func (r *fakeUserRepo) GetVerification(userID int64, platform string) (*entity.HandleVerification, error) {
+	verification, ok := r.verifications[platform]
+	if !ok || verification.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return verification, nil
+}
+
+func (r *fakeUserRepo) DeleteVerification(userID int64, platform string) error {
+	delete(r.verifications, platform)
+	return nil
+}
+
+type fakeSkillUseCase struct {
+	skillUseCase.SkillUseCase
+}
+
+func (fakeSkillUseCase) Normalize(skills []string) ([]string, error) {
+	return skills, nil
+}
+
+func newVerificationUseCase(repo *fakeUserRepo, verifier verify.Verifier) *userUseCaseImpl {
+	uc := NewUserUseCase(repo, nil, nil, nil, nil, fakeSkillUseCase{}, nil,
+		map[string]verify.Verifier{entity.PlatformTelegram: verifier},
+		&config.Verification{CodeTTL: time.Hour})
+	return uc.(*userUseCaseImpl)
+}
+
+func TestCheckVerificationMarksHandleVerified(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{ID: 1, Username: "jane", Telegram: "jane_t"})
+	fake := verify.NewFake()
+	uc := newVerificationUseCase(repo, fake)
+
+	verification, instructions, err := uc.StartVerification(1, entity.PlatformTelegram)
+	if err != nil {
+		t.Fatalf("StartVerification: %v", err)
+	}
+	if verification.Handle != "jane_t" || instructions == "" {
+		t.Fatalf("verification = %+v, instructions = %q", verification, instructions)
+	}
+
+	if err := uc.CheckVerification(context.Background(), 1, entity.PlatformTelegram); !errors.Is(err, ErrCodeNotFound) {
+		t.Fatalf("before posting: err = %v, want ErrCodeNotFound", err)
+	}
+
+	fake.Post("someone_else", verification.Code)
+	if err := uc.CheckVerification(context.Background(), 1, entity.PlatformTelegram); !errors.Is(err, ErrCodeNotFound) {
+		t.Fatalf("posted by another handle: err = %v, want ErrCodeNotFound", err)
+	}
+
+	fake.Post("@jane_t", "here it is: "+verification.Code)
+	if err := uc.CheckVerification(context.Background(), 1, entity.PlatformTelegram); err != nil {
+		t.Fatalf("CheckVerification: %v", err)
+	}
+	if !repo.users[1].TelegramVerified {
+		t.Error("handle should be verified")
+	}
+	if _, ok := repo.verifications[entity.PlatformTelegram]; ok {
+		t.Error("the used verification should be deleted")
+	}
+
+	if _, _, err := uc.StartVerification(1, entity.PlatformTelegram); !errors.Is(err, ErrAlreadyVerified) {
+		t.Errorf("restart: err = %v, want ErrAlreadyVerified", err)
+	}
+}
+
+func TestStartVerificationRequiresHandleAndVerifier(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{ID: 1, Username: "jane", Discord: "jane_d"})
+	uc := newVerificationUseCase(repo, verify.NewFake())
+
+	if _, _, err := uc.StartVerification(1, entity.PlatformTelegram); !errors.Is(err, ErrNoHandle) {
+		t.Errorf("no handle: err = %v, want ErrNoHandle", err)
+	}
+	if _, _, err := uc.StartVerification(1, entity.PlatformDiscord); !errors.Is(err, ErrVerificationUnavailable) {
+		t.Errorf("no verifier: err = %v, want ErrVerificationUnavailable", err)
+	}
+	if _, _, err := uc.StartVerification(1, "myspace"); !errors.Is(err, ErrUnknownPlatform) {
+		t.Errorf("unknown platform: err = %v, want ErrUnknownPlatform", err)
+	}
+}
+
+func TestCheckVerificationRejectsExpiredCode(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{ID: 1, Username: "jane", Telegram: "jane_t"})
+	fake := verify.NewFake()
+	uc := newVerificationUseCase(repo, fake)
+
+	verification, _, err := uc.StartVerification(1, entity.PlatformTelegram)
+	if err != nil {
+		t.Fatalf("StartVerification: %v", err)
+	}
+	verification.ExpiresAt = time.Now().Add(-time.Minute)
+	fake.Post("jane_t", verification.Code)
+
+	if err := uc.CheckVerification(context.Background(), 1, entity.PlatformTelegram); !errors.Is(err, ErrVerificationExpired) {
+		t.Fatalf("err = %v, want ErrVerificationExpired", err)
+	}
+	if repo.users[1].TelegramVerified {
+		t.Error("an expired code must not verify the handle")
+	}
+}
+
+func TestCheckVerificationRejectsChangedHandle(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{ID: 1, Username: "jane", Telegram: "jane_t"})
+	fake := verify.NewFake()
+	uc := newVerificationUseCase(repo, fake)
+
+	verification, _, err := uc.StartVerification(1, entity.PlatformTelegram)
+	if err != nil {
+		t.Fatalf("StartVerification: %v", err)
+	}
+	fake.Post("jane_t", verification.Code)
+	repo.users[1].Telegram = "someone_famous"
+
+	if err := uc.CheckVerification(context.Background(), 1, entity.PlatformTelegram); !errors.Is(err, ErrVerificationExpired) {
+		t.Fatalf("err = %v, want ErrVerificationExpired", err)
+	}
+	if repo.users[1].TelegramVerified {
+		t.Error("a code posted by the old handle must not verify the new one")
+	}
+}
+
+func TestUpdateUserInfoClearsVerificationOfChangedHandles(t *testing.T) {
+	repo := newFakeUserRepo(&entity.User{
+		ID:               1,
+		Username:         "jane",
+		Telegram:         "jane_t",
+		TelegramVerified: true,
+		Discord:          "jane_d",
+		DiscordVerified:  true,
+	})
+	uc := newVerificationUseCase(repo, verify.NewFake())
+
+	err := uc.UpdateUserInfo(&entity.User{ID: 1, Username: "jane", Telegram: "someone_famous", Discord: "jane_d"})
+	if err != nil {
+		t.Fatalf("UpdateUserInfo: %v", err)
+	}
+	user := repo.users[1]
+	if user.TelegramVerified {
+		t.Error("changing the Telegram handle must clear its verification")
+	}
+	if !user.DiscordVerified {
+		t.Error("an unchanged Discord handle must stay verified")
+	}
+}
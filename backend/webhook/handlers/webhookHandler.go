@@ -0,0 +1,13 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+type WebhookHandler interface {
+	CreateWebhook(c echo.Context) error
+	GetWebhooks(c echo.Context) error
+	GetWebhook(c echo.Context) error
+	UpdateWebhook(c echo.Context) error
+	DeleteWebhook(c echo.Context) error
+	GetDeliveries(c echo.Context) error
+	Redeliver(c echo.Context) error
+}
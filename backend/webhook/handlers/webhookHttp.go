@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/helpers"
+	"DiplomaV2/backend/internal/validator"
+	postsFilter "DiplomaV2/backend/post"
+	"DiplomaV2/backend/webhook/repository"
+	"DiplomaV2/backend/webhook/usecase"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type webhookHttpHandler struct {
+	webhookUseCase usecase.WebhookUseCase
+}
+
+type webhookInput struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Secret      string   `json:"secret"`
+	// Active is only used on update; new webhooks are active.
+	Active *bool `json:"active"`
+}
+
+// CreateWebhook returns the secret once; it is not shown again.
+func (w *webhookHttpHandler) CreateWebhook(c echo.Context) error {
+	webhook, v, err := w.bindWebhook(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+
+	if err := w.webhookUseCase.CreateWebhook(webhook, isAdmin(c)); err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"webhook": webhook, "secret": webhook.Secret})
+}
+
+func (w *webhookHttpHandler) GetWebhooks(c echo.Context) error {
+	userID := c.Get("userID").(int64)
+
+	webhooks, err := w.webhookUseCase.GetWebhooks(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"webhooks": webhooks})
+}
+
+func (w *webhookHttpHandler) GetWebhook(c echo.Context) error {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook id"})
+	}
+
+	webhook, err := w.webhookUseCase.GetWebhook(c.Get("userID").(int64), webhookID)
+	if err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, webhook)
+}
+
+func (w *webhookHttpHandler) UpdateWebhook(c echo.Context) error {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook id"})
+	}
+
+	webhook, v, err := w.bindWebhook(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if !v.Valid() {
+		return c.JSON(http.StatusUnprocessableEntity, v.Errors)
+	}
+	webhook.ID = webhookID
+
+	if err := w.webhookUseCase.UpdateWebhook(webhook.UserID, webhook, isAdmin(c)); err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, webhook)
+}
+
+func (w *webhookHttpHandler) DeleteWebhook(c echo.Context) error {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook id"})
+	}
+
+	if err := w.webhookUseCase.DeleteWebhook(c.Get("userID").(int64), webhookID); err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (w *webhookHttpHandler) GetDeliveries(c echo.Context) error {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook id"})
+	}
+
+	v := validator.New()
+	qs := c.Request().URL.Query()
+
+	filters := postsFilter.Filters{
+		Page:         helpers.ReadInt(qs, "page", 1, v),
+		PageSize:     helpers.ReadInt(qs, "pageSize", 20, v),
+		Sort:         helpers.ReadString(qs, "sort", "-created_at"),
+		SortSafeList: []string{"created_at", "-created_at"},
+	}
+
+	if postsFilter.ValidateFilters(v, filters); !v.Valid() {
+		return c.JSON(http.StatusBadRequest, v.Errors)
+	}
+
+	deliveries, metadata, err := w.webhookUseCase.GetDeliveries(c.Get("userID").(int64), webhookID, filters)
+	if err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	type Response struct {
+		Deliveries []*entity.WebhookDelivery `json:"deliveries"`
+		Metadata   postsFilter.Metadata      `json:"metadata"`
+	}
+
+	return c.JSON(http.StatusOK, Response{Deliveries: deliveries, Metadata: metadata})
+}
+
+func (w *webhookHttpHandler) Redeliver(c echo.Context) error {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid webhook id"})
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid delivery id"})
+	}
+
+	delivery, err := w.webhookUseCase.Redeliver(c.Get("userID").(int64), webhookID, deliveryID)
+	if err != nil {
+		return w.errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, delivery)
+}
+
+func (w *webhookHttpHandler) bindWebhook(c echo.Context) (*entity.Webhook, *validator.Validator, error) {
+	var input webhookInput
+	if err := c.Bind(&input); err != nil {
+		return nil, nil, err
+	}
+
+	webhook := &entity.Webhook{
+		UserID:      c.Get("userID").(int64),
+		URL:         strings.TrimSpace(input.URL),
+		Events:      input.Events,
+		Description: strings.TrimSpace(input.Description),
+		Secret:      input.Secret,
+		Active:      true,
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	v := validator.New()
+	validator.ValidateWebhook(v, webhook)
+	return webhook, v, nil
+}
+
+func (w *webhookHttpHandler) errorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrWebhookNotFound), errors.Is(err, repository.ErrDeliveryNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrNotWebhookOwner), errors.Is(err, usecase.ErrUserEventsForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrTooManyWebhooks):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrForbiddenURL):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"url": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func isAdmin(c echo.Context) bool {
+	role, _ := c.Get("userRole").(string)
+	return role == entity.RoleAdmin
+}
+
+func NewWebhookHttpHandler(webhookUseCase usecase.WebhookUseCase) WebhookHandler {
+	return &webhookHttpHandler{
+		webhookUseCase: webhookUseCase,
+	}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"time"
+)
+
+type WebhookRepository interface {
+	Insert(webhook *entity.Webhook) error
+	GetByID(id int64) (*entity.Webhook, error)
+	GetAllForUser(userID int64) ([]*entity.Webhook, error)
+	CountForUser(userID int64) (int64, error)
+	Update(webhook *entity.Webhook) error
+	Delete(id int64) error
+	GetSubscribed(event string) ([]*entity.Webhook, error)
+	InsertDelivery(delivery *entity.WebhookDelivery) error
+	UpdateDelivery(delivery *entity.WebhookDelivery) error
+	GetDelivery(webhookID, deliveryID int64) (*entity.WebhookDelivery, error)
+	GetDeliveries(webhookID int64, filters postsFilter.Filters) ([]*entity.WebhookDelivery, postsFilter.Metadata, error)
+	GetDueDeliveries(now time.Time, limit int) ([]*entity.WebhookDelivery, error)
+}
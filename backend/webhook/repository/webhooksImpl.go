@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"DiplomaV2/backend/internal/database"
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"math"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type webhookRepository struct {
+	DB database.Database
+}
+
+func NewWebhookRepository(db database.Database) WebhookRepository {
+	return &webhookRepository{DB: db}
+}
+
+func (r *webhookRepository) Insert(webhook *entity.Webhook) error {
+	return r.DB.GetDb().Omit("User").Create(webhook).Error
+}
+
+func (r *webhookRepository) GetByID(id int64) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	if err := r.DB.GetDb().First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetAllForUser(userID int64) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	if err := r.DB.GetDb().Where("user_id = ?", userID).Order("created_at").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) CountForUser(userID int64) (int64, error) {
+	var count int64
+	err := r.DB.GetDb().Model(&entity.Webhook{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *webhookRepository) Update(webhook *entity.Webhook) error {
+	return r.DB.GetDb().Omit("User").Save(webhook).Error
+}
+
+func (r *webhookRepository) Delete(id int64) error {
+	result := r.DB.GetDb().Delete(&entity.Webhook{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetSubscribed returns the active webhooks subscribed to event.
+func (r *webhookRepository) GetSubscribed(event string) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := r.DB.GetDb().
+		Where("active = ? AND events @> ?", true, pq.Array([]string{event})).
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) InsertDelivery(delivery *entity.WebhookDelivery) error {
+	return r.DB.GetDb().Omit("Webhook").Create(delivery).Error
+}
+
+func (r *webhookRepository) UpdateDelivery(delivery *entity.WebhookDelivery) error {
+	return r.DB.GetDb().Omit("Webhook").Save(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(webhookID, deliveryID int64) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	err := r.DB.GetDb().Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveries(webhookID int64, filters postsFilter.Filters) ([]*entity.WebhookDelivery, postsFilter.Metadata, error) {
+	var deliveries []*entity.WebhookDelivery
+	query := r.DB.GetDb().Model(&entity.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	var totalRecords int64
+	countQuery := *query
+	if err := countQuery.Count(&totalRecords).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s", filters.SortColumn(), filters.SortDirection())).Order("id DESC")
+	query = query.Offset((filters.Page - 1) * filters.PageSize).Limit(filters.PageSize)
+
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+
+	metadata := calculateMetadata(int(totalRecords), filters.Page, filters.PageSize)
+	return deliveries, metadata, nil
+}
+
+// GetDueDeliveries returns the pending deliveries whose next attempt is due,
+// oldest first, with their webhooks preloaded.
+func (r *webhookRepository) GetDueDeliveries(now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	err := r.DB.GetDb().
+		Preload("Webhook").
+		Where("status = ? AND next_attempt_at <= ?", entity.DeliveryStatusPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func calculateMetadata(totalRecords, page, pageSize int) postsFilter.Metadata {
+	if totalRecords == 0 {
+		return postsFilter.Metadata{}
+	}
+	return postsFilter.Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
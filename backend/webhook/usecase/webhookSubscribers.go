@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/dto"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/events"
+)
+
+// SubscribeEvents dispatches the internal events that have a webhook
+// counterpart. It must be called once per bus.
+func SubscribeEvents(bus *events.Bus, webhooks WebhookUseCase) {
+	events.SubscribeAsync(bus, func(event events.PostCreated) error {
+		if !publicPost(&event.Post) {
+			return nil
+		}
+		return webhooks.Dispatch(entity.WebhookPostCreated, dto.NewPost(&event.Post))
+	})
+	events.SubscribeAsync(bus, func(event events.PostUpdated) error {
+		if !publicPost(&event.Post) {
+			return nil
+		}
+		return webhooks.Dispatch(entity.WebhookPostUpdated, dto.NewPost(&event.Post))
+	})
+	events.SubscribeAsync(bus, func(event events.PostDeleted) error {
+		return webhooks.Dispatch(entity.WebhookPostDeleted, map[string]int64{"id": event.Post.ID})
+	})
+	events.SubscribeAsync(bus, func(event events.UserRegistered) error {
+		return webhooks.Dispatch(entity.WebhookUserRegistered, dto.NewAuthor(&event.User))
+	})
+	events.SubscribeAsync(bus, func(event events.UserActivated) error {
+		return webhooks.Dispatch(entity.WebhookUserActivated, dto.NewAuthor(&event.User))
+	})
+}
+
+// publicPost reports whether a post can be sent out. Drafts and hidden
+// posts aren't listed anywhere else either.
+func publicPost(post *entity.Post) bool {
+	return !post.Hidden && post.Status != entity.PostStatusDraft
+}
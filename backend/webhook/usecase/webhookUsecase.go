@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/entity"
+	postsFilter "DiplomaV2/backend/post"
+	"golang.org/x/net/context"
+)
+
+type WebhookUseCase interface {
+	CreateWebhook(webhook *entity.Webhook, isAdmin bool) error
+	GetWebhooks(userID int64) ([]*entity.Webhook, error)
+	GetWebhook(userID, webhookID int64) (*entity.Webhook, error)
+	UpdateWebhook(userID int64, webhook *entity.Webhook, isAdmin bool) error
+	DeleteWebhook(userID, webhookID int64) error
+	GetDeliveries(userID, webhookID int64, filters postsFilter.Filters) ([]*entity.WebhookDelivery, postsFilter.Metadata, error)
+	Redeliver(userID, webhookID, deliveryID int64) (*entity.WebhookDelivery, error)
+	Dispatch(event string, data interface{}) error
+	RetryDeliveries(ctx context.Context) error
+}
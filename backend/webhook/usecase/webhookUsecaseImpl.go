@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/netguard"
+	postsFilter "DiplomaV2/backend/post"
+	"DiplomaV2/backend/webhook/repository"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooManyWebhooks     = errors.New("webhook limit reached")
+	ErrNotWebhookOwner     = errors.New("webhook doesn't belong to this user")
+	ErrUserEventsForbidden = errors.New("only admins can subscribe to user events")
+	ErrForbiddenURL        = errors.New("webhook URL must resolve to a public address")
+)
+
+const (
+	// maxResponseBody caps how much of a receiver's response is logged.
+	maxResponseBody = 4096
+	// retryBatchSize caps the deliveries retried in one job run.
+	retryBatchSize = 100
+)
+
+type webhookUseCaseImpl struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	conf   *config.Webhooks
+}
+
+// NewWebhookUseCase takes the client used for deliveries. The server passes
+// a netguard client, so deliveries can't reach internal addresses even if a
+// webhook's host is rebound after it was checked.
+func NewWebhookUseCase(repo repository.WebhookRepository, client *http.Client, conf *config.Webhooks) WebhookUseCase {
+	return &webhookUseCaseImpl{
+		repo:   repo,
+		client: client,
+		conf:   conf,
+	}
+}
+
+func (w *webhookUseCaseImpl) CreateWebhook(webhook *entity.Webhook, isAdmin bool) error {
+	if !isAdmin && hasUserEvents(webhook.Events) {
+		return ErrUserEventsForbidden
+	}
+
+	count, err := w.repo.CountForUser(webhook.UserID)
+	if err != nil {
+		return err
+	}
+	if count >= int64(w.conf.MaxPerUser) {
+		return ErrTooManyWebhooks
+	}
+	if err := w.checkURL(webhook.URL); err != nil {
+		return err
+	}
+
+	if webhook.Secret == "" {
+		if webhook.Secret, err = generateSecret(); err != nil {
+			return err
+		}
+	}
+	webhook.Active = true
+	return w.repo.Insert(webhook)
+}
+
+func (w *webhookUseCaseImpl) GetWebhooks(userID int64) ([]*entity.Webhook, error) {
+	return w.repo.GetAllForUser(userID)
+}
+
+func (w *webhookUseCaseImpl) GetWebhook(userID, webhookID int64) (*entity.Webhook, error) {
+	return w.getOwned(userID, webhookID)
+}
+
+// UpdateWebhook replaces the URL, events, description and active flag. The
+// secret is kept unless a new one is given.
+func (w *webhookUseCaseImpl) UpdateWebhook(userID int64, webhook *entity.Webhook, isAdmin bool) error {
+	existing, err := w.getOwned(userID, webhook.ID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && hasUserEvents(webhook.Events) {
+		return ErrUserEventsForbidden
+	}
+	if err := w.checkURL(webhook.URL); err != nil {
+		return err
+	}
+
+	webhook.UserID = existing.UserID
+	webhook.CreatedAt = existing.CreatedAt
+	if webhook.Secret == "" {
+		webhook.Secret = existing.Secret
+	}
+	return w.repo.Update(webhook)
+}
+
+func (w *webhookUseCaseImpl) DeleteWebhook(userID, webhookID int64) error {
+	if _, err := w.getOwned(userID, webhookID); err != nil {
+		return err
+	}
+	return w.repo.Delete(webhookID)
+}
+
+func (w *webhookUseCaseImpl) GetDeliveries(userID, webhookID int64, filters postsFilter.Filters) ([]*entity.WebhookDelivery, postsFilter.Metadata, error) {
+	if _, err := w.getOwned(userID, webhookID); err != nil {
+		return nil, postsFilter.Metadata{}, err
+	}
+	return w.repo.GetDeliveries(webhookID, filters)
+}
+
+// Redeliver sends the payload of a past delivery again as a new delivery
+// and returns it with the outcome of the first attempt.
+func (w *webhookUseCaseImpl) Redeliver(userID, webhookID, deliveryID int64) (*entity.WebhookDelivery, error) {
+	webhook, err := w.getOwned(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := w.repo.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := w.enqueue(webhook, original.Event, original.Payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery.RedeliveryOf = &original.ID
+	if err := w.attempt(delivery, webhook); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Dispatch records a delivery of event for every subscribed webhook and
+// makes the first attempt right away. Failed attempts are left to
+// RetryDeliveries.
+func (w *webhookUseCaseImpl) Dispatch(event string, data interface{}) error {
+	webhooks, err := w.repo.GetSubscribed(event)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":     event,
+		"createdAt": time.Now().UTC(),
+		"data":      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		delivery, err := w.enqueue(webhook, event, string(payload))
+		if err != nil {
+			return err
+		}
+		if err := w.attempt(delivery, webhook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *webhookUseCaseImpl) RetryDeliveries(ctx context.Context) error {
+	deliveries, err := w.repo.GetDueDeliveries(time.Now(), retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !delivery.Webhook.Active {
+			delivery.Status = entity.DeliveryStatusFailed
+			delivery.Error = "webhook is disabled"
+			delivery.NextAttemptAt = nil
+			if err := w.repo.UpdateDelivery(delivery); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.attempt(delivery, &delivery.Webhook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue stores a pending delivery. Its first retry is scheduled one
+// backoff away, so the retry job doesn't pick it up while the immediate
+// attempt is still running and a crash before that attempt isn't lost.
+func (w *webhookUseCaseImpl) enqueue(webhook *entity.Webhook, event, payload string) (*entity.WebhookDelivery, error) {
+	next := time.Now().Add(w.conf.RetryBackoff)
+	delivery := &entity.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         event,
+		Payload:       payload,
+		Status:        entity.DeliveryStatusPending,
+		NextAttemptAt: &next,
+	}
+	if err := w.repo.InsertDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// attempt POSTs the delivery and records the outcome. A failed attempt is
+// rescheduled with exponential backoff until MaxAttempts is reached. The
+// returned error is only about saving the outcome.
+func (w *webhookUseCaseImpl) attempt(delivery *entity.WebhookDelivery, webhook *entity.Webhook) error {
+	delivery.Attempts++
+	start := time.Now()
+	code, body, err := w.post(delivery, webhook)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	delivery.ResponseCode = code
+	delivery.ResponseBody = body
+	delivery.Error = ""
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		delivery.Status = entity.DeliveryStatusSucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= w.conf.MaxAttempts:
+		delivery.Status = entity.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(w.conf.RetryBackoff << (delivery.Attempts - 1))
+		delivery.Status = entity.DeliveryStatusPending
+		delivery.NextAttemptAt = &next
+	}
+	return w.repo.UpdateDelivery(delivery)
+}
+
+// post sends the payload signed with the webhook secret. The signature is
+// hex HMAC-SHA256 of "<timestamp>.<body>", so receivers can reject replays.
+func (w *webhookUseCaseImpl) post(delivery *entity.WebhookDelivery, webhook *entity.Webhook) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TeamFinder-Webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	if err != nil {
+		return res.StatusCode, "", err
+	}
+	return res.StatusCode, string(body), nil
+}
+
+// Sign returns the signature a receiver should expect for body.
+func Sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkURL resolves the webhook host, so URLs pointing at internal addresses
+// are refused when they are saved rather than failing on every delivery.
+func (w *webhookUseCaseImpl) checkURL(rawURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.conf.Timeout)
+	defer cancel()
+	if err := netguard.CheckURL(ctx, rawURL); err != nil {
+		return errors.Wrap(ErrForbiddenURL, err.Error())
+	}
+	return nil
+}
+
+func (w *webhookUseCaseImpl) getOwned(userID, webhookID int64) (*entity.Webhook, error) {
+	webhook, err := w.repo.GetByID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != userID {
+		return nil, ErrNotWebhookOwner
+	}
+	return webhook, nil
+}
+
+func hasUserEvents(events []string) bool {
+	for _, event := range events {
+		for _, userEvent := range entity.WebhookUserEvents {
+			if event == userEvent {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,357 @@
+package usecase
+
+import (
+	"DiplomaV2/backend/internal/config"
+	"DiplomaV2/backend/internal/entity"
+	"DiplomaV2/backend/internal/netguard"
+	"DiplomaV2/backend/webhook/repository"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	webhooks   []*entity.Webhook
+	deliveries []*entity.WebhookDelivery
+}
+
+func (r *fakeWebhookRepo) GetByID(id int64) (*entity.Webhook, error) {
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (r *fakeWebhookRepo) CountForUser(userID int64) (int64, error) {
+	return int64(len(r.webhooks)), nil
+}
+
+func (r *fakeWebhookRepo) Insert(webhook *entity.Webhook) error {
+	webhook.ID = int64(len(r.webhooks) + 1)
+	r.webhooks = append(r.webhooks, webhook)
+	return nil
+}
+
+func (r *fakeWebhookRepo) Update(*entity.Webhook) error {
+	return nil
+}
+
+func (r *fakeWebhookRepo) GetSubscribed(event string) ([]*entity.Webhook, error) {
+	var subscribed []*entity.Webhook
+	for _, webhook := range r.webhooks {
+		for _, e := range webhook.Events {
+			if webhook.Active && e == event {
+				subscribed = append(subscribed, webhook)
+			}
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *fakeWebhookRepo) InsertDelivery(delivery *entity.WebhookDelivery) error {
+	delivery.ID = int64(len(r.deliveries) + 1)
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(*entity.WebhookDelivery) error {
+	return nil
+}
+
+func (r *fakeWebhookRepo) GetDelivery(webhookID, deliveryID int64) (*entity.WebhookDelivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.ID == deliveryID && delivery.WebhookID == webhookID {
+			return delivery, nil
+		}
+	}
+	return nil, repository.ErrDeliveryNotFound
+}
+
+func (r *fakeWebhookRepo) GetDueDeliveries(now time.Time, limit int) ([]*entity.WebhookDelivery, error) {
+	var due []*entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == entity.DeliveryStatusPending && !delivery.NextAttemptAt.After(now) {
+			delivery.Webhook = *r.webhooks[0]
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+// makeDue moves every pending delivery's next attempt into the past, as if
+// the backoff had passed.
+func (r *fakeWebhookRepo) makeDue() {
+	past := time.Now().Add(-time.Second)
+	for _, delivery := range r.deliveries {
+		if delivery.NextAttemptAt != nil {
+			delivery.NextAttemptAt = &past
+		}
+	}
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   string
+}
+
+// receiver records the requests it gets and answers with the next status of
+// statuses, repeating the last one.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: string(body)})
+	status := r.statuses[0]
+	if len(r.statuses) > 1 {
+		r.statuses = r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("ok"))
+}
+
+var testConf = &config.Webhooks{
+	MaxPerUser:   10,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	RetryBackoff: time.Minute,
+}
+
+func newTestUseCase(t *testing.T, statuses ...int) (*webhookUseCaseImpl, *fakeWebhookRepo, *receiver) {
+	t.Helper()
+	rec := &receiver{statuses: statuses}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	repo := &fakeWebhookRepo{webhooks: []*entity.Webhook{{
+		ID:     1,
+		UserID: 7,
+		URL:    server.URL + "/hook",
+		Secret: testSecret,
+		Events: []string{entity.WebhookPostCreated},
+		Active: true,
+	}}}
+	// The receiver listens on loopback, so the test uses a plain client that
+	// only shares the redirect policy of the production one.
+	client := &http.Client{Timeout: time.Second, CheckRedirect: netguard.NoRedirects}
+	uc := NewWebhookUseCase(repo, client, testConf).(*webhookUseCaseImpl)
+	return uc, repo, rec
+}
+
+func assertSigned(t *testing.T, req receivedRequest) {
+	t.Helper()
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("X-Webhook-Timestamp = %q, want a unix timestamp", timestamp)
+	}
+	want := "sha256=" + Sign(testSecret, timestamp, req.body)
+	if got := req.header.Get("X-Webhook-Signature"); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+}
+
+func TestDispatchDeliversSignedPayload(t *testing.T) {
+	uc, repo, rec := newTestUseCase(t, http.StatusOK)
+
+	if err := uc.Dispatch(entity.WebhookPostCreated, map[string]string{"name": "Team"}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rec.requests))
+	}
+	req := rec.requests[0]
+	assertSigned(t, req)
+	if got := req.header.Get("X-Webhook-Event"); got != entity.WebhookPostCreated {
+		t.Errorf("X-Webhook-Event = %q", got)
+	}
+	if got := req.header.Get("X-Webhook-Delivery"); got != "1" {
+		t.Errorf("X-Webhook-Delivery = %q, want 1", got)
+	}
+
+	var payload struct {
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Event != entity.WebhookPostCreated || payload.Data["name"] != "Team" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	delivery := repo.deliveries[0]
+	if delivery.Status != entity.DeliveryStatusSucceeded || delivery.Attempts != 1 || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want succeeded after one attempt", delivery)
+	}
+	if delivery.ResponseCode != http.StatusOK || delivery.ResponseBody != "ok" {
+		t.Errorf("response = %d %q", delivery.ResponseCode, delivery.ResponseBody)
+	}
+}
+
+func TestDispatchSkipsUnsubscribedEvents(t *testing.T) {
+	uc, repo, rec := newTestUseCase(t, http.StatusOK)
+
+	if err := uc.Dispatch(entity.WebhookPostDeleted, nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(rec.requests) != 0 || len(repo.deliveries) != 0 {
+		t.Error("no delivery expected for an event the webhook isn't subscribed to")
+	}
+}
+
+func TestFailedDeliveriesBackOffUntilMaxAttempts(t *testing.T) {
+	uc, repo, rec := newTestUseCase(t, http.StatusInternalServerError)
+
+	before := time.Now()
+	if err := uc.Dispatch(entity.WebhookPostCreated, nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	delivery := repo.deliveries[0]
+	assertRetryAfter(t, delivery, before, testConf.RetryBackoff)
+
+	// The second attempt waits twice as long.
+	repo.makeDue()
+	before = time.Now()
+	if err := uc.RetryDeliveries(context.Background()); err != nil {
+		t.Fatalf("RetryDeliveries: %v", err)
+	}
+	assertRetryAfter(t, delivery, before, 2*testConf.RetryBackoff)
+
+	// The third attempt is the last one.
+	repo.makeDue()
+	if err := uc.RetryDeliveries(context.Background()); err != nil {
+		t.Fatalf("RetryDeliveries: %v", err)
+	}
+	if delivery.Status != entity.DeliveryStatusFailed || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want failed without a next attempt", delivery)
+	}
+	if delivery.Attempts != testConf.MaxAttempts || len(rec.requests) != testConf.MaxAttempts {
+		t.Errorf("attempts = %d, requests = %d, want %d", delivery.Attempts, len(rec.requests), testConf.MaxAttempts)
+	}
+	for _, req := range rec.requests {
+		assertSigned(t, req)
+	}
+
+	repo.makeDue()
+	if err := uc.RetryDeliveries(context.Background()); err != nil {
+		t.Fatalf("RetryDeliveries: %v", err)
+	}
+	if len(rec.requests) != testConf.MaxAttempts {
+		t.Error("a failed delivery must not be retried")
+	}
+}
+
+func TestRetrySucceedsAfterFailure(t *testing.T) {
+	uc, repo, _ := newTestUseCase(t, http.StatusBadGateway, http.StatusNoContent)
+
+	if err := uc.Dispatch(entity.WebhookPostCreated, nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	repo.makeDue()
+	if err := uc.RetryDeliveries(context.Background()); err != nil {
+		t.Fatalf("RetryDeliveries: %v", err)
+	}
+
+	delivery := repo.deliveries[0]
+	if delivery.Status != entity.DeliveryStatusSucceeded || delivery.Attempts != 2 || delivery.DeliveredAt == nil {
+		t.Errorf("delivery = %+v, want succeeded on the second attempt", delivery)
+	}
+}
+
+func TestRedirectsAreNotFollowed(t *testing.T) {
+	uc, repo, rec := newTestUseCase(t, http.StatusFound)
+
+	if err := uc.Dispatch(entity.WebhookPostCreated, nil); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(rec.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rec.requests))
+	}
+	if delivery := repo.deliveries[0]; delivery.Status != entity.DeliveryStatusPending || delivery.ResponseCode != http.StatusFound {
+		t.Errorf("delivery = %+v, want a failed attempt with the redirect status", delivery)
+	}
+}
+
+func TestRedeliverSendsPayloadAgain(t *testing.T) {
+	uc, repo, rec := newTestUseCase(t, http.StatusOK)
+
+	if err := uc.Dispatch(entity.WebhookPostCreated, map[string]int{"id": 3}); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	original := repo.deliveries[0]
+
+	redelivery, err := uc.Redeliver(7, 1, original.ID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if redelivery.ID == original.ID || redelivery.RedeliveryOf == nil || *redelivery.RedeliveryOf != original.ID {
+		t.Errorf("redelivery = %+v, want a new delivery of %d", redelivery, original.ID)
+	}
+	if redelivery.Status != entity.DeliveryStatusSucceeded {
+		t.Errorf("redelivery status = %q", redelivery.Status)
+	}
+
+	if len(rec.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(rec.requests))
+	}
+	if rec.requests[1].body != original.Payload {
+		t.Error("redelivery must send the original payload")
+	}
+	assertSigned(t, rec.requests[1])
+	if got := rec.requests[1].header.Get("X-Webhook-Delivery"); got != strconv.FormatInt(redelivery.ID, 10) {
+		t.Errorf("X-Webhook-Delivery = %q, want %d", got, redelivery.ID)
+	}
+
+	if _, err := uc.Redeliver(8, 1, original.ID); err != ErrNotWebhookOwner {
+		t.Errorf("Redeliver by another user: err = %v, want ErrNotWebhookOwner", err)
+	}
+}
+
+func TestInternalURLsAreRefused(t *testing.T) {
+	uc, repo, _ := newTestUseCase(t, http.StatusOK)
+
+	for _, rawURL := range []string{"http://127.0.0.1:9000/hook", "http://169.254.169.254/", "http://localhost/hook"} {
+		err := uc.CreateWebhook(&entity.Webhook{UserID: 7, URL: rawURL, Events: []string{entity.WebhookPostCreated}}, false)
+		if !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("CreateWebhook(%q): err = %v, want ErrForbiddenURL", rawURL, err)
+		}
+
+		update := &entity.Webhook{ID: 1, URL: rawURL, Events: []string{entity.WebhookPostCreated}}
+		if err := uc.UpdateWebhook(7, update, false); !errors.Is(err, ErrForbiddenURL) {
+			t.Errorf("UpdateWebhook(%q): err = %v, want ErrForbiddenURL", rawURL, err)
+		}
+	}
+	if len(repo.webhooks) != 1 {
+		t.Error("no webhook may be stored for an internal URL")
+	}
+}
+
+func assertRetryAfter(t *testing.T, delivery *entity.WebhookDelivery, before time.Time, backoff time.Duration) {
+	t.Helper()
+	if delivery.Status != entity.DeliveryStatusPending || delivery.NextAttemptAt == nil {
+		t.Fatalf("delivery = %+v, want pending with a next attempt", delivery)
+	}
+	earliest := before.Add(backoff)
+	latest := time.Now().Add(backoff)
+	if delivery.NextAttemptAt.Before(earliest) || delivery.NextAttemptAt.After(latest) {
+		t.Errorf("next attempt at %v, want %v after the attempt", delivery.NextAttemptAt, backoff)
+	}
+}
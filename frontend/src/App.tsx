@@ -19,6 +19,8 @@ import  { ChangePassword } from "./pages/change-password.tsx";
 import  { ForgotPassword } from "./pages/forgot-password.tsx";
 import  { ResetPassword } from "./pages/reset-password.tsx";
 import  { ProfilePage } from "./pages/profile-page.tsx";
+import  { Activate } from "./pages/activate.tsx";
+import  { ConfirmEmail } from "./pages/confirm-email.tsx";
 
 export default function App() {
     return (
@@ -39,6 +41,8 @@ export default function App() {
                         <Route path="/profile/change-password" element={<ChangePasswordRoute />} />
                         <Route path="/forgot-password" element={<ForgotPasswordRoute />} />
                         <Route path="/reset-password/:token" element={<ResetPasswordRoute />} />
+                        <Route path="/activate/:token" element={<Activate />} />
+                        <Route path="/confirm-email/:token" element={<ConfirmEmail />} />
                         <Route path="/profile/:id" element={<ProfilePageRoute />} /> {/* Update route path */}
                     </Routes>
                 </Router>
@@ -0,0 +1,79 @@
+import { TextInput, Paper, Title, Container, Button, Text, Anchor } from '@mantine/core';
+import { SetStateAction, useEffect, useRef, useState } from 'react';
+import { useParams } from 'react-router-dom';
+import axios from "axios";
+
+export const Activate = () => {
+    const { token } = useParams();
+    const [activating, setActivating] = useState(true);
+    const [activated, setActivated] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+    const [email, setEmail] = useState('');
+    const [loading, setLoading] = useState(false);
+    const [resendMessage, setResendMessage] = useState<string | null>(null);
+    const requested = useRef(false);
+
+    useEffect(() => {
+        // StrictMode runs effects twice in development; the token is single use.
+        if (requested.current) {
+            return;
+        }
+        requested.current = true;
+
+        axios.get(`http://localhost:4000/v2/users/activate/${token}`)
+            .then(() => setActivated(true))
+            .catch((error) => setError(error.response?.data?.error || 'Activation failed'))
+            .finally(() => setActivating(false));
+    }, [token]);
+
+    const handleEmailChange = (event: { target: { value: SetStateAction<string>; }; }) => {
+        setEmail(event.target.value);
+    };
+
+    const handleResend = async (e: { preventDefault: () => void; }) => {
+        e.preventDefault();
+        setLoading(true);
+        setResendMessage(null);
+
+        try {
+            const response = await axios.post(
+                'http://localhost:4000/v2/users/activation/resend',
+                { email }
+            );
+            setResendMessage(response.data.message);
+        } catch (error) {
+            // @ts-ignore
+            setResendMessage(error.response?.data?.error || 'An error occurred');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    return (
+        <Container size={420} my={40}>
+            <Title ta="center">
+                TeamFinder Account Activation
+            </Title>
+            <Paper withBorder shadow="md" p={30} mt={30} radius="md">
+                {activating && <Text size="sm">Activating your account...</Text>}
+                {activated && (
+                    <Text c="green" size="sm">
+                        Your account is activated. You can now <Anchor href="/login">log in</Anchor>.
+                    </Text>
+                )}
+                {error && (
+                    <>
+                        <Text c="red" size="sm">{error}</Text>
+                        <TextInput mt="md" label="Email" placeholder="example@mail.ru" value={email} onChange={handleEmailChange} required />
+                        <Button fullWidth mt="xl" onClick={handleResend} disabled={loading}>
+                            {loading ? 'Sending...' : 'Send a new activation link'}
+                        </Button>
+                        {resendMessage && <Text size="sm" mt="sm">{resendMessage}</Text>}
+                    </>
+                )}
+            </Paper>
+        </Container>
+    );
+}
+
+export default Activate;
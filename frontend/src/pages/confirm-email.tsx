@@ -0,0 +1,53 @@
+import { Paper, Title, Container, Button, Text, Anchor } from '@mantine/core';
+import { useState } from 'react';
+import { useParams } from 'react-router-dom';
+import axios from "axios";
+
+// The change is only confirmed by pressing the button, so mail scanners
+// that open the link in the email can't confirm it.
+export const ConfirmEmail = () => {
+    const { token } = useParams();
+    const [loading, setLoading] = useState(false);
+    const [confirmed, setConfirmed] = useState(false);
+    const [error, setError] = useState<string | null>(null);
+
+    const handleConfirm = async () => {
+        setLoading(true);
+        setError(null);
+
+        try {
+            await axios.post('http://localhost:4000/v2/users/email/confirm', { token });
+            setConfirmed(true);
+        } catch (error) {
+            // @ts-ignore
+            setError(error.response?.data?.error || 'Failed to change email');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    return (
+        <Container size={420} my={40}>
+            <Title ta="center">
+                Confirm Your New Email
+            </Title>
+            <Paper withBorder shadow="md" p={30} mt={30} radius="md">
+                {confirmed ? (
+                    <Text c="green" size="sm">
+                        Your email address has been changed. You can now <Anchor href="/login">log in</Anchor> with it.
+                    </Text>
+                ) : (
+                    <>
+                        <Text size="sm">Confirm that you want to use this address for your TeamFinder account.</Text>
+                        <Button fullWidth mt="xl" onClick={handleConfirm} disabled={loading}>
+                            {loading ? 'Confirming...' : 'Confirm email change'}
+                        </Button>
+                        {error && <Text c="red" size="sm" mt="sm">{error}</Text>}
+                    </>
+                )}
+            </Paper>
+        </Container>
+    );
+}
+
+export default ConfirmEmail;